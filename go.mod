@@ -4,24 +4,30 @@ go 1.19
 
 require github.com/julienschmidt/httprouter v1.3.0
 
-require github.com/mattn/go-sqlite3 v1.14.12
+require (
+	github.com/cloudflare/cloudflare-go v0.55.0
+	github.com/golang-jwt/jwt/v4 v4.4.2
+	github.com/google/uuid v1.3.0
+	github.com/mattn/go-sqlite3 v1.14.12
+	github.com/miekg/dns v1.1.50
+	github.com/natefinch/lumberjack v2.0.0+incompatible
+	go.uber.org/zap v1.21.0
+	golang.org/x/crypto v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/cloudflare/cloudflare-go v0.55.0 // indirect
-	github.com/golang-jwt/jwt/v4 v4.4.2 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
-	github.com/google/uuid v1.3.0 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
 	github.com/hashicorp/go-retryablehttp v0.7.2 // indirect
-	github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.8.0 // indirect
-	go.uber.org/zap v1.21.0 // indirect
-	golang.org/x/crypto v0.7.0 // indirect
+	golang.org/x/mod v0.8.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/tools v0.6.0 // indirect
 )
 
 replace legocerthub-backend/pkg/acme => /pkg/acme
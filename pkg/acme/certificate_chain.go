@@ -0,0 +1,67 @@
+package acme
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"strings"
+)
+
+// alternateLinks returns the urls of any alternate certificate chains that
+// ACME provided in the Link header (see: RFC8555 7.4.2)
+func alternateLinks(headers http.Header) (altLinks []string) {
+	for _, link := range headers.Values("Link") {
+		// a header may contain multiple comma separated links
+		for _, oneLink := range strings.Split(link, ",") {
+			parts := strings.Split(oneLink, ";")
+
+			// check params for rel="alternate"
+			isAlternate := false
+			for _, param := range parts[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+				if param == `rel="alternate"` || param == "rel=alternate" {
+					isAlternate = true
+					break
+				}
+			}
+
+			if isAlternate {
+				url := strings.TrimSpace(parts[0])
+				url = strings.TrimPrefix(url, "<")
+				url = strings.TrimSuffix(url, ">")
+				altLinks = append(altLinks, url)
+			}
+		}
+	}
+
+	return altLinks
+}
+
+// chainRootMatches returns true if the topmost certificate in the pem chain either
+// has, or was issued by, the specified common name. That is, the root the chain
+// leads to has the specified common name.
+func chainRootMatches(pemChain string, rootCN string) bool {
+	var topCert *x509.Certificate
+
+	// decode all certs to find the last one in the chain
+	rest := []byte(pemChain)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return false
+		}
+		topCert = cert
+	}
+
+	if topCert == nil {
+		return false
+	}
+
+	return topCert.Issuer.CommonName == rootCN || topCert.Subject.CommonName == rootCN
+}
@@ -0,0 +1,92 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+)
+
+// TlsAlpn01Protocol is the ALPN protocol name that the ACME server will
+// negotiate when validating a tls-alpn-01 challenge (RFC 8737 6.2)
+const TlsAlpn01Protocol = "acme-tls/1"
+
+// idPeAcmeIdentifier is the object identifier of the acmeIdentifier
+// extension (RFC 8737 6.1)
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// tlsAlpn01ValidationCert creates the self signed certificate used to validate a
+// tls-alpn-01 challenge for the specified identifier. The certificate and its private
+// key are returned as a single pem string.
+func (accountKey *AccountKey) tlsAlpn01ValidationCert(identifier Identifier, token string) (pemCertAndKey string, err error) {
+	// get the keyAuth
+	keyAuth, err := accountKey.keyAuthorization(token)
+	if err != nil {
+		return "", err
+	}
+
+	// acmeIdentifier extension value is the DER encoded OCTET STRING
+	// of the SHA-256 digest of the key authorization
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	extValue, err := asn1.Marshal(keyAuthDigest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// the certificate's key is single use, only for this validation
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{
+				Id:       idPeAcmeIdentifier,
+				Critical: true,
+				Value:    extValue,
+			},
+		},
+	}
+
+	// the identifier must be the only subjectAltName (RFC 8737 3 & RFC 8738 6)
+	if identifier.Type == identifierTypeIp {
+		template.IPAddresses = []net.IP{net.ParseIP(identifier.Value)}
+	} else {
+		template.Subject = pkix.Name{CommonName: identifier.Value}
+		template.DNSNames = []string{identifier.Value}
+	}
+
+	derCert, err := x509.CreateCertificate(rand.Reader, &template, &template, &certKey.PublicKey, certKey)
+	if err != nil {
+		return "", err
+	}
+
+	derKey, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return "", err
+	}
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derCert})
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: derKey})
+
+	return string(pemCert) + string(pemKey), nil
+}
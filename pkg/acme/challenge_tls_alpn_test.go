@@ -0,0 +1,74 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"testing"
+)
+
+// TestTlsAlpn01ValidationCert confirms the validation certificate contains the
+// critical acmeIdentifier extension with the key authorization's digest and the
+// identifier as its only subjectAltName
+func TestTlsAlpn01ValidationCert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate account key: %s", err)
+	}
+	accountKey := AccountKey{Key: key}
+
+	const token = "evaGxfADs6pSRb2LAv9IZf17Dt3juxGJ-PCt92wr-oA"
+	keyAuth, err := accountKey.keyAuthorization(token)
+	if err != nil {
+		t.Fatalf("failed to make key authorization: %s", err)
+	}
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	wantExtValue, _ := asn1.Marshal(keyAuthDigest[:])
+
+	for _, identifier := range []Identifier{NewIdentifier("example.com"), NewIdentifier("192.0.2.1")} {
+		pemCertAndKey, err := accountKey.tlsAlpn01ValidationCert(identifier, token)
+		if err != nil {
+			t.Fatalf("%s: failed to make validation cert: %s", identifier.Value, err)
+		}
+
+		tlsCert, err := tls.X509KeyPair([]byte(pemCertAndKey), []byte(pemCertAndKey))
+		if err != nil {
+			t.Fatalf("%s: validation cert and key are invalid: %s", identifier.Value, err)
+		}
+		cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+		if err != nil {
+			t.Fatalf("%s: failed to parse validation cert: %s", identifier.Value, err)
+		}
+
+		found := false
+		for _, ext := range cert.Extensions {
+			if !ext.Id.Equal(idPeAcmeIdentifier) {
+				continue
+			}
+			found = true
+			if !ext.Critical {
+				t.Errorf("%s: acmeIdentifier extension is not critical", identifier.Value)
+			}
+			if string(ext.Value) != string(wantExtValue) {
+				t.Errorf("%s: acmeIdentifier extension value is not the key authorization digest", identifier.Value)
+			}
+		}
+		if !found {
+			t.Errorf("%s: acmeIdentifier extension (%s) is missing", identifier.Value, idPeAcmeIdentifier)
+		}
+
+		// the identifier must be the only subjectAltName
+		var sans []string
+		sans = append(sans, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		if len(sans) != 1 || sans[0] != identifier.Value || len(cert.EmailAddresses) != 0 || len(cert.URIs) != 0 {
+			t.Errorf("%s: subjectAltNames: got %v, want only %s", identifier.Value, sans, identifier.Value)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package acme
 
 import (
 	"errors"
+	"strings"
 )
 
 var (
 	errUnsupportedChallengeType = errors.New("unsupported challenge type")
-	errWrongIdentifierType      = errors.New("acme identifier is not of type dns")
+	errWrongIdentifierType      = errors.New("acme identifier type is not supported by the challenge type")
+
+	ErrWildcardRequiresDns01 = errors.New("wildcard identifiers can only be validated using dns-01")
+	ErrIpCantUseDns01        = errors.New("ip identifiers can not be validated using dns-01")
 )
 
 // Define challenge types (per RFC 8555)
@@ -15,8 +19,9 @@ type ChallengeType string
 const (
 	UnknownChallengeType ChallengeType = ""
 
-	ChallengeTypeHttp01 ChallengeType = "http-01"
-	ChallengeTypeDns01  ChallengeType = "dns-01"
+	ChallengeTypeHttp01    ChallengeType = "http-01"
+	ChallengeTypeDns01     ChallengeType = "dns-01"
+	ChallengeTypeTlsAlpn01 ChallengeType = "tls-alpn-01"
 )
 
 // ValidationResource creates the resource name and content that are required
@@ -40,9 +45,10 @@ func (challType ChallengeType) ValidationResource(identifier Identifier, key Acc
 // ValidationResourceName returns the resource name that is required to
 // validate the specified identifier
 func (challType ChallengeType) validationResourceName(identifier Identifier, token string) (name string, err error) {
-	// verify identifier is the proper type (only dns identifiers are supported)
-	if identifier.Type != identifierTypeDns {
-		return "", errWrongIdentifierType
+	// verify identifier is the proper type
+	err = challType.checkIdentifierType(identifier)
+	if err != nil {
+		return "", err
 	}
 
 	// return resource name based on challenge type
@@ -58,6 +64,20 @@ func (challType ChallengeType) validationResourceName(identifier Identifier, tok
 		// (e.g. "_acme-challenge.idendifier.example.com") as the resource name
 		name = "_acme-challenge." + identifier.Value
 
+	// tls-alpn-01 (TLS ALPN Challenge - RFC 8737 3), tls-alpn-01 uses the
+	// identifier value as the resource name (it is the SNI of the validation
+	// request). ip identifiers use the reverse dns name as the SNI instead
+	// (RFC 8738 6).
+	case ChallengeTypeTlsAlpn01:
+		if identifier.Type == identifierTypeIp {
+			name, err = reverseDnsName(identifier.Value)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			name = identifier.Value
+		}
+
 	// any other type is error
 	default:
 		return "", errUnsupportedChallengeType
@@ -69,9 +89,10 @@ func (challType ChallengeType) validationResourceName(identifier Identifier, tok
 // validationResourceContent returns the resource content that is required to
 // validate the specified identifier
 func (challType ChallengeType) validationResourceContent(identifier Identifier, key AccountKey, token string) (content string, err error) {
-	// verify identifier is the proper type (only dns identifiers are supported)
-	if identifier.Type != identifierTypeDns {
-		return "", errWrongIdentifierType
+	// verify identifier is the proper type
+	err = challType.checkIdentifierType(identifier)
+	if err != nil {
+		return "", err
 	}
 
 	// return resource info based on challenge type
@@ -86,6 +107,12 @@ func (challType ChallengeType) validationResourceContent(identifier Identifier,
 	case ChallengeTypeDns01:
 		content, err = key.keyAuthorizationEndodedSHA256(token)
 
+	// tls-alpn-01 (TLS ALPN Challenge - RFC 8737 3)
+	// tls-alpn-01 uses a self signed certificate (and its key) containing the
+	// acmeIdentifier extension as the resource content. Both are pem encoded.
+	case ChallengeTypeTlsAlpn01:
+		content, err = key.tlsAlpn01ValidationCert(identifier, token)
+
 	// any other type is error
 	default:
 		return "", errUnsupportedChallengeType
@@ -98,3 +125,45 @@ func (challType ChallengeType) validationResourceContent(identifier Identifier,
 
 	return content, nil
 }
+
+// checkIdentifierType returns an error if the identifier's type can't be validated
+// using the challenge type. dns identifiers work with any type, but ip identifiers
+// can't use dns-01 (RFC 8738 7).
+func (challType ChallengeType) checkIdentifierType(identifier Identifier) error {
+	switch identifier.Type {
+	case identifierTypeDns:
+		return nil
+
+	case identifierTypeIp:
+		if challType == ChallengeTypeHttp01 || challType == ChallengeTypeTlsAlpn01 {
+			return nil
+		}
+	}
+
+	return errWrongIdentifierType
+}
+
+// CheckIdentifierValue returns an error explaining why an identifier with the
+// specified value (e.g. as it would appear in a certificate) can't be validated
+// using the challenge type. If the challenge type can be used, nil is returned.
+// Unlike an authorization's identifier, the value may include a wildcard.
+func (challType ChallengeType) CheckIdentifierValue(value string) error {
+	switch challType {
+	case ChallengeTypeHttp01, ChallengeTypeDns01, ChallengeTypeTlsAlpn01:
+		// no-op
+	default:
+		return errUnsupportedChallengeType
+	}
+
+	// wildcard (RFC 8555 7.1.3)
+	if strings.HasPrefix(value, "*.") && challType != ChallengeTypeDns01 {
+		return ErrWildcardRequiresDns01
+	}
+
+	// ip (RFC 8738 7)
+	if NewIdentifier(value).Type == identifierTypeIp && challType == ChallengeTypeDns01 {
+		return ErrIpCantUseDns01
+	}
+
+	return nil
+}
@@ -16,13 +16,14 @@ var (
 
 // acmeDirectory struct holds ACME directory object
 type directory struct {
-	NewNonce   string `json:"newNonce"`
-	NewAccount string `json:"newAccount"`
-	NewOrder   string `json:"newOrder"`
-	NewAuthz   string `json:"newAuthz"`
-	RevokeCert string `json:"revokeCert"`
-	KeyChange  string `json:"keyChange"`
-	Meta       struct {
+	NewNonce    string `json:"newNonce"`
+	NewAccount  string `json:"newAccount"`
+	NewOrder    string `json:"newOrder"`
+	NewAuthz    string `json:"newAuthz"`
+	RevokeCert  string `json:"revokeCert"`
+	KeyChange   string `json:"keyChange"`
+	RenewalInfo string `json:"renewalInfo,omitempty"` // optional, only present if server supports ARI
+	Meta        struct {
 		TermsOfService          string   `json:"termsOfService"`
 		Website                 string   `json:"website"`
 		CaaIdentities           []string `json:"caaIdentities"`
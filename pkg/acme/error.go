@@ -3,6 +3,7 @@ package acme
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // ACME error
@@ -10,6 +11,9 @@ type Error struct {
 	Status int    `json:"status"`
 	Type   string `json:"type"`
 	Detail string `json:"detail"`
+	// RetryAfter is how long the server asked to wait before retrying (e.g. when
+	// rate limited), 0 if not specified
+	RetryAfter time.Duration `json:"-"`
 }
 
 // Error() implements the error interface
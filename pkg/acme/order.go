@@ -2,13 +2,17 @@ package acme
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"time"
 )
 
 // NewOrderPayload is the payload to post to ACME newOrder
 type NewOrderPayload struct {
 	// notBefore and notAfter are optional and not implemented
 	Identifiers []Identifier `json:"identifiers"`
+	// replaces is the ARI certificate id of the certificate this order replaces
+	Replaces string `json:"replaces,omitempty"`
 }
 
 // ACME identifier object
@@ -24,8 +28,31 @@ const (
 	UnknownIdentifierType identifierType = ""
 
 	identifierTypeDns = "dns"
+	identifierTypeIp  = "ip" // RFC 8738
 )
 
+// NewIdentifier returns the identifier for the specified value. If the value
+// is an IP address, the identifier type is 'ip', otherwise it is 'dns'.
+func NewIdentifier(value string) Identifier {
+	if net.ParseIP(value) != nil {
+		return Identifier{Type: identifierTypeIp, Value: value}
+	}
+
+	return Identifier{Type: identifierTypeDns, Value: value}
+}
+
+// ParseIdentifier returns the identifier for the specified type and value (e.g. as
+// previously saved to storage). If the type is unknown, the type is determined from
+// the value (see: NewIdentifier).
+func ParseIdentifier(idType string, value string) Identifier {
+	switch idType {
+	case identifierTypeDns, identifierTypeIp:
+		return Identifier{Type: identifierType(idType), Value: value}
+	}
+
+	return NewIdentifier(value)
+}
+
 // a slice of identifiers
 // allows writing a method for an array of them
 type IdentifierSlice []Identifier
@@ -42,6 +69,9 @@ type Order struct {
 	NotBefore      timeString      `json:"notBefore,omitempty"`
 	NotAfter       timeString      `json:"notAfter,omitempty"`
 	Location       string          `json:"-"` // omit because it is in the header
+	// RetryAfter is when the server suggests checking the order again (e.g. while
+	// it is processing), 0 if not specified
+	RetryAfter time.Duration `json:"-"`
 }
 
 // dnsIdentifiers returns a slice of the value strings for a response's
@@ -58,6 +88,20 @@ func (ids *IdentifierSlice) DnsIdentifiers() []string {
 	return s
 }
 
+// IpIdentifiers returns a slice of the value strings for a response's
+// array of identifier objects that are of type 'ip'
+func (ids *IdentifierSlice) IpIdentifiers() []string {
+	var s []string
+
+	for _, id := range *ids {
+		if id.Type == identifierTypeIp {
+			s = append(s, id.Value)
+		}
+	}
+
+	return s
+}
+
 // Account response decoder
 func unmarshalOrder(bodyBytes []byte, headers http.Header) (response Order, err error) {
 	err = json.Unmarshal(bodyBytes, &response)
@@ -67,6 +111,7 @@ func unmarshalOrder(bodyBytes []byte, headers http.Header) (response Order, err
 
 	// order location (url) isn't part of the JSON response, add it from the header.
 	response.Location = headers.Get("Location")
+	response.RetryAfter = parseRetryAfter(headers)
 
 	return response, nil
 }
@@ -130,23 +175,39 @@ func (service *Service) FinalizeOrder(finalizeUrl string, derCsr []byte, account
 }
 
 // DownloadCertificate uses POST-as-GET to download a valid certificate from the specified
-// url.
-func (service *Service) DownloadCertificate(certificateUrl string, accountKey AccountKey) (pemChain string, err error) {
+// url. If preferredRootCN is specified and the default chain does not match it, any
+// alternate chains ACME offers are downloaded and the first matching one is returned
+// instead. If no chain matches, the default chain is returned.
+func (service *Service) DownloadCertificate(certificateUrl string, accountKey AccountKey, preferredRootCN string) (pemChain string, err error) {
 	// POST-as-GET
-	bodyBytes, _, err := service.postAsGet(certificateUrl, accountKey)
+	bodyBytes, headers, err := service.postAsGet(certificateUrl, accountKey)
 	if err != nil {
 		return "", err
 	}
+	defaultChain := string(bodyBytes)
+
+	// no preference or default is already preferred
+	if preferredRootCN == "" || chainRootMatches(defaultChain, preferredRootCN) {
+		return defaultChain, nil
+	}
+
+	// check alternate chains
+	altLinks := alternateLinks(headers)
+	service.logger.Debugf("alternate download links: %s", altLinks)
+
+	for _, altLink := range altLinks {
+		altBodyBytes, _, err := service.postAsGet(altLink, accountKey)
+		if err != nil {
+			// log error but try any other alternates
+			service.logger.Errorf("failed to download alternate chain %s (%s)", altLink, err)
+			continue
+		}
+
+		if chainRootMatches(string(altBodyBytes), preferredRootCN) {
+			return string(altBodyBytes), nil
+		}
+	}
 
-	// log alternate links in debug
-	// TODO: maybe care about these at some point
-	// altLinks := []string{}
-	// for _, altLink := range headers.Values("Link") {
-	// 	if strings.HasSuffix(altLink, "rel=\"alternate\"") {
-	// 		altLinks = append(altLinks, altLink)
-	// 	}
-	// }
-	// service.logger.Debugf("alternate download links: %s", altLinks)
-
-	return string(bodyBytes), nil
+	service.logger.Infof("no chain matches preferred root cn '%s', using default chain", preferredRootCN)
+	return defaultChain, nil
 }
@@ -151,6 +151,7 @@ func (service *Service) postToUrlSigned(payload any, url string, accountKey Acco
 	// re: acmeError decode
 	// if it didn't error, that means an error response WAS decoded
 	if err == nil {
+		acmeError.RetryAfter = parseRetryAfter(response.Header)
 		return nil, nil, acmeError
 	}
 
@@ -0,0 +1,134 @@
+package acme
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRenewalInfoUnsupported = errors.New("acme server does not support renewal info (ari)")
+	errAriBadPem              = errors.New("ari: failed to decode certificate pem")
+	errAriNoAki               = errors.New("ari: certificate is missing authority key identifier")
+)
+
+// errTypeAlreadyReplaced is the ACME problem type returned when a new order's
+// 'replaces' cert has already been replaced by another order
+const errTypeAlreadyReplaced = "urn:ietf:params:acme:error:alreadyReplaced"
+
+// RenewalInfo is the ACME Renewal Information (ARI) response for a
+// certificate (see: draft-ietf-acme-ari)
+type RenewalInfo struct {
+	SuggestedWindow struct {
+		Start timeString `json:"start"`
+		End   timeString `json:"end"`
+	} `json:"suggestedWindow"`
+	ExplanationURL string `json:"explanationURL,omitempty"`
+}
+
+// SupportsRenewalInfo returns true if the ACME server's directory advertises
+// a renewalInfo endpoint
+func (service *Service) SupportsRenewalInfo() bool {
+	return service.dir.RenewalInfo != ""
+}
+
+// AriCertificateId returns the ARI unique identifier for the certificate pem (or
+// pem chain) that is passed in. The identifier is the base64url encoded Authority
+// Key Identifier and the base64url encoded DER serial number, joined by a period.
+func AriCertificateId(pemCert string) (string, error) {
+	// decode pem (if a chain, take the first cert and discard the rest)
+	pemBlock, _ := pem.Decode([]byte(pemCert))
+	if pemBlock == nil {
+		return "", errAriBadPem
+	}
+
+	cert, err := x509.ParseCertificate(pemBlock.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	if len(cert.AuthorityKeyId) == 0 {
+		return "", errAriNoAki
+	}
+
+	// DER encoding of the serial (without tag and length) requires a leading zero
+	// byte if the most significant bit is set (otherwise it would be negative)
+	serialBytes := cert.SerialNumber.Bytes()
+	if len(serialBytes) == 0 || serialBytes[0]&0x80 != 0 {
+		serialBytes = append([]byte{0x00}, serialBytes...)
+	}
+
+	return encodeString(cert.AuthorityKeyId) + "." + encodeString(serialBytes), nil
+}
+
+// GetRenewalInfo fetches the ARI suggested renewal window for the specified
+// certificate pem. ARI requests are unauthenticated GETs.
+func (service *Service) GetRenewalInfo(pemCert string) (renewalInfo RenewalInfo, err error) {
+	if !service.SupportsRenewalInfo() {
+		return RenewalInfo{}, ErrRenewalInfoUnsupported
+	}
+
+	certId, err := AriCertificateId(pemCert)
+	if err != nil {
+		return RenewalInfo{}, err
+	}
+
+	url := strings.TrimSuffix(service.dir.RenewalInfo, "/") + "/" + certId
+
+	response, err := service.httpClient.Get(url)
+	if err != nil {
+		return RenewalInfo{}, err
+	}
+	defer response.Body.Close()
+
+	bodyBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return RenewalInfo{}, err
+	}
+
+	// check for error response
+	if response.StatusCode != http.StatusOK {
+		acmeError, err := unmarshalErrorResponse(bodyBytes)
+		if err == nil {
+			return RenewalInfo{}, acmeError
+		}
+		return RenewalInfo{}, fmt.Errorf("acme ari error: status code %d", response.StatusCode)
+	}
+
+	err = json.Unmarshal(bodyBytes, &renewalInfo)
+	if err != nil {
+		return RenewalInfo{}, err
+	}
+
+	return renewalInfo, nil
+}
+
+// WindowStart returns the start of the suggested renewal window. An error is
+// returned if the start isn't a valid RFC 3339 timestamp.
+func (ri RenewalInfo) WindowStart() (time.Time, error) {
+	return time.Parse(time.RFC3339, string(ri.SuggestedWindow.Start))
+}
+
+// WindowEnd returns the end of the suggested renewal window. An error is
+// returned if the end isn't a valid RFC 3339 timestamp.
+func (ri RenewalInfo) WindowEnd() (time.Time, error) {
+	return time.Parse(time.RFC3339, string(ri.SuggestedWindow.End))
+}
+
+// IsAlreadyReplacedError returns true if err is the ACME error returned when a new
+// order's 'replaces' cert was already replaced (the alreadyReplaced problem type, or
+// a 409 Conflict from servers that don't use the problem type).
+func IsAlreadyReplacedError(err error) bool {
+	var acmeErr Error
+	if !errors.As(err, &acmeErr) {
+		return false
+	}
+
+	return acmeErr.Type == errTypeAlreadyReplaced || acmeErr.Status == http.StatusConflict
+}
@@ -0,0 +1,45 @@
+package acme
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRetryAfter returns the duration the server asked the client to wait,
+// per the Retry-After header (RFC 8555 8.2 and RFC 7231 7.1.3). The header may
+// be a number of seconds or an http-date. If the header is missing, invalid, or
+// in the past, 0 is returned.
+func parseRetryAfter(headers http.Header) time.Duration {
+	if headers == nil {
+		return 0
+	}
+
+	value := strings.TrimSpace(headers.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	// delay-seconds
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	// http-date
+	retryTime, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	wait := time.Until(retryTime)
+	if wait <= 0 {
+		return 0
+	}
+
+	return wait
+}
@@ -0,0 +1,32 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var errBadIpAddress = errors.New("invalid ip address")
+
+// reverseDnsName returns the reverse dns name (without the trailing dot) of the
+// specified ip address (e.g. 192.0.2.5 -> 5.2.0.192.in-addr.arpa)
+func reverseDnsName(ipAddress string) (string, error) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return "", errBadIpAddress
+	}
+
+	// ipv4
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0]), nil
+	}
+
+	// ipv6 (each nibble, in reverse order)
+	nibbles := make([]string, 0, 32)
+	for i := len(ip) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", ip[i]&0x0f), fmt.Sprintf("%x", ip[i]>>4))
+	}
+
+	return strings.Join(nibbles, ".") + ".ip6.arpa", nil
+}
@@ -0,0 +1,119 @@
+package challenges
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/challenges/providers/dns01acmedns"
+	"time"
+)
+
+// acme-dns providers with auto_register enabled register domains with their
+// acme-dns server as needed. The registrations are saved in storage with the
+// credentials encrypted.
+
+var (
+	errRegistrationStorage = errors.New("acme-dns registrations are not available until storage is configured")
+	errNoDnsChecker        = errors.New("acme-dns registration cname can't be checked (dns checker is not configured)")
+)
+
+// AcmeDnsRegistration is an automatic acme-dns registration as saved in storage
+type AcmeDnsRegistration struct {
+	ID                int
+	AcmeDnsAddress    string
+	RealDomain        string
+	FullDomain        string
+	UsernameEncrypted string
+	PasswordEncrypted string
+	CreatedAt         int
+}
+
+// providerApp is the App passed to providers. It adds the parts of the challenges
+// service that some providers need.
+type providerApp struct {
+	App
+	service *Service
+}
+
+// providerApp returns the App to pass to providers
+func (service *Service) providerApp() providerApp {
+	return providerApp{
+		App:     service.app,
+		service: service,
+	}
+}
+
+// GetAcmeDnsRegistrar returns the registrar for acme-dns automatic registrations
+func (pa providerApp) GetAcmeDnsRegistrar() dns01acmedns.Registrar {
+	return acmeDnsRegistrar{service: pa.service}
+}
+
+// acmeDnsRegistrar implements dns01acmedns.Registrar using the challenges
+// service's storage and dns checker
+type acmeDnsRegistrar struct {
+	service *Service
+}
+
+// GetAcmeDnsRegistration returns the saved registration (with the credentials
+// decrypted)
+func (r acmeDnsRegistrar) GetAcmeDnsRegistration(acmeDnsAddress string, realDomain string) (dns01acmedns.Registration, error) {
+	if r.service.storage == nil {
+		return dns01acmedns.Registration{}, errRegistrationStorage
+	}
+
+	reg, err := r.service.storage.GetAcmeDnsRegistration(acmeDnsAddress, realDomain)
+	if err != nil {
+		return dns01acmedns.Registration{}, err
+	}
+
+	username, err := r.service.cipher.DecryptString(reg.UsernameEncrypted)
+	if err != nil {
+		return dns01acmedns.Registration{}, err
+	}
+	password, err := r.service.cipher.DecryptString(reg.PasswordEncrypted)
+	if err != nil {
+		return dns01acmedns.Registration{}, err
+	}
+
+	return dns01acmedns.Registration{
+		RealDomain: reg.RealDomain,
+		FullDomain: reg.FullDomain,
+		Username:   username,
+		Password:   password,
+	}, nil
+}
+
+// SaveAcmeDnsRegistration encrypts the registration's credentials and saves it
+func (r acmeDnsRegistrar) SaveAcmeDnsRegistration(acmeDnsAddress string, registration dns01acmedns.Registration) error {
+	if r.service.storage == nil {
+		return errRegistrationStorage
+	}
+
+	username, err := r.service.cipher.EncryptString(registration.Username)
+	if err != nil {
+		return err
+	}
+	password, err := r.service.cipher.EncryptString(registration.Password)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.service.storage.PostNewAcmeDnsRegistration(AcmeDnsRegistration{
+		AcmeDnsAddress:    acmeDnsAddress,
+		RealDomain:        registration.RealDomain,
+		FullDomain:        registration.FullDomain,
+		UsernameEncrypted: username,
+		PasswordEncrypted: password,
+		CreatedAt:         int(time.Now().Unix()),
+	})
+
+	return err
+}
+
+// ResolveCNAME resolves the fqdn's CNAME chain using the dns checker
+func (r acmeDnsRegistrar) ResolveCNAME(fqdn string) (string, error) {
+	dnsChecker := r.service.getDnsChecker()
+	if dnsChecker == nil {
+		return "", errNoDnsChecker
+	}
+
+	return dnsChecker.ResolveCNAME(fqdn)
+}
@@ -0,0 +1,233 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/challenges/dns_checker"
+	"legocerthub-backend/pkg/order_events"
+	"sync"
+)
+
+var (
+	errBatchNotDns01 = errors.New("batch solving only supports dns-01 challenge methods")
+)
+
+// BatchChallenge is one authorization's identifier, challenges, and the method
+// to solve it with, as part of a batch
+type BatchChallenge struct {
+	Identifier acme.Identifier
+	Challenges []acme.Challenge
+	Method     Method
+}
+
+// batchRecord is a batch challenge's challenge and resource
+type batchRecord struct {
+	identifier      acme.Identifier
+	method          Method
+	challenge       acme.Challenge
+	resourceName    string
+	resourceContent string
+}
+
+// BatchDns01 returns true if dns-01 challenges should be solved together per
+// order (see: SolveDns01Batch)
+func (service *Service) BatchDns01() bool {
+	return service.batchDns01
+}
+
+// SolveDns01Batch solves the dns-01 challenges of multiple authorizations
+// together. All of the records are provisioned first (a name may have more than
+// one value, e.g. a wildcard and its apex), propagation is checked once for all
+// of them, and then all of the challenges are answered. The records are all
+// deprovisioned once the challenges are done. Statuses are returned in the same
+// order as the batch. An error is returned if any challenge can't resolve a valid
+// or invalid state. Any external provider executions are logged with the orderId.
+// Canceling ctx stops solving, but the records are still deprovisioned.
+func (service *Service) SolveDns01Batch(ctx context.Context, batch []BatchChallenge, key acme.AccountKey, isStaging bool, orderId int) (statuses []string, err error) {
+	records := make([]batchRecord, len(batch))
+
+	for i := range batch {
+		if batch[i].Method.ChallengeType != acme.ChallengeTypeDns01 {
+			return nil, errBatchNotDns01
+		}
+
+		// range to the dns-01 challenge
+		found := false
+		for j := range batch[i].Challenges {
+			if batch[i].Challenges[j].Type == acme.ChallengeTypeDns01 {
+				found = true
+				records[i].challenge = batch[i].Challenges[j]
+			}
+		}
+		if !found {
+			return nil, errChallengeTypeNotFound
+		}
+
+		records[i].identifier = batch[i].Identifier
+		records[i].method = batch[i].Method
+
+		// hold the provider until done (so it isn't replaced mid challenge)
+		release, err := service.useProvider(batch[i].Method.Value)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	dnsChecker := service.getDnsChecker()
+	if dnsChecker == nil {
+		return nil, errDnsCheckerMissing
+	}
+
+	// provision all of the records concurrently
+	var wg sync.WaitGroup
+	provisionErrs := make([]error, len(records))
+	for i := range records {
+		wg.Add(1)
+		go func(record *batchRecord, provisionErr *error) {
+			defer wg.Done()
+
+			// calculate the needed resource
+			record.resourceName, record.resourceContent, *provisionErr = service.resource(record.identifier, record.method, key, record.challenge.Token)
+			if *provisionErr != nil {
+				return
+			}
+
+			*provisionErr = service.provisionResource(ctx, record.identifier, record.method, record.resourceName, record.resourceContent, orderId)
+		}(&records[i], &provisionErrs[i])
+	}
+	wg.Wait()
+
+	// deprovision all of the records once done, even if provisioning errored, to
+	// ensure any records that were created get cleaned up
+	defer service.deprovisionBatch(records, orderId)
+
+	// Provision error check
+	for i := range provisionErrs {
+		if provisionErrs[i] != nil {
+			return nil, provisionErrs[i]
+		}
+	}
+
+	// check propagation of all of the records at once
+	// (using the timing of the slowest method in the batch)
+	txtRecords := make([]dns_checker.TXTRecord, len(records))
+	methods := make([]Method, len(records))
+	for i := range records {
+		txtRecords[i] = dns_checker.TXTRecord{
+			Fqdn:  records[i].resourceName,
+			Value: records[i].resourceContent,
+		}
+		methods[i] = records[i].method
+	}
+	timing := service.batchTiming(methods)
+
+	propagated, err := dnsChecker.CheckTXTsWithRetry(ctx, txtRecords, timing.propagation, timing.thresholds)
+	if err != nil {
+		service.logger.Error(err)
+	} else if !propagated {
+		err = dns_checker.ErrDnsRecordNotFound
+	}
+	for i := range records {
+		service.events.Record(orderId, order_events.TypePropagationConfirmed, records[i].identifier.Value, records[i].resourceName, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// make pointer for the correct acme.Service (to avoid repeat of if/else)
+	var acmeService *acme.Service
+	if isStaging {
+		acmeService = service.acmeStaging
+	} else {
+		acmeService = service.acmeProd
+	}
+
+	// don't answer the challenges if canceled while checking propagation
+	if ctx.Err() != nil {
+		return nil, service.canceledErr()
+	}
+
+	// inform ACME that all of the challenges are ready
+	for i := range records {
+		_, err = acmeService.ValidateChallenge(records[i].challenge.Url, key)
+		service.events.Record(orderId, order_events.TypeChallengeAnswered, records[i].identifier.Value, string(records[i].method.ChallengeType), err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// monitor for processing to complete
+	statuses = make([]string, len(records))
+	done, err := timing.poll.Run(ctx, func() (bool, error) {
+		// get each unfinished challenge and check for error or final Statuses
+		done := true
+		for j := range records {
+			if statuses[j] != "" {
+				continue
+			}
+
+			challenge, err := acmeService.GetChallenge(records[j].challenge.Url, key)
+			if err != nil {
+				return false, err
+			}
+
+			if challenge.Status == "valid" {
+				statuses[j] = challenge.Status
+			} else if challenge.Status == "invalid" {
+				service.logger.Debug(challenge.Error)
+				statuses[j] = challenge.Status
+			} else {
+				done = false
+				continue
+			}
+			service.events.Record(orderId, order_events.TypeChallengeCompleted, records[j].identifier.Value, challengeEventDetail(challenge), nil)
+		}
+
+		// done if all have reached a final status
+		return done, nil
+	})
+	if err != nil {
+		// cancel/error if canceled or shutting down
+		if ctx.Err() != nil {
+			err = service.canceledErr()
+		}
+	} else if !done {
+		// polling ended without all reaching valid or invalid Status
+		err = errChallengeRetriesExhausted
+	}
+	if err != nil {
+		// record the challenges that didn't complete
+		for j := range records {
+			if statuses[j] == "" {
+				service.events.Record(orderId, order_events.TypeChallengeCompleted, records[j].identifier.Value, "", err)
+			}
+		}
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// deprovisionBatch concurrently deprovisions all of the batch's records that
+// have a calculated resource
+func (service *Service) deprovisionBatch(records []batchRecord, orderId int) {
+	var wg sync.WaitGroup
+	for i := range records {
+		if records[i].resourceName == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(record batchRecord) {
+			defer wg.Done()
+
+			err := service.deprovisionResource(record.identifier, record.method, record.resourceName, record.resourceContent, orderId)
+			if err != nil {
+				service.logger.Error(err)
+			}
+		}(records[i])
+	}
+	wg.Wait()
+}
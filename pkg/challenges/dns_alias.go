@@ -0,0 +1,66 @@
+package challenges
+
+import (
+	"strings"
+)
+
+// dns-01 records can be delegated to another name (e.g. to a zone the provider
+// has write access to) with a CNAME such as:
+// _acme-challenge.example.com CNAME _acme-challenge.validation.example.net
+// The record is then provisioned at (and checked at) the delegated name, which is
+// found using the configured aliases or by following the CNAME chain.
+
+// DnsAliasConfig configures dns-01 challenge delegation
+type DnsAliasConfig struct {
+	FollowCNAME *bool      `yaml:"follow_cname"`
+	Aliases     []DnsAlias `yaml:"aliases"`
+}
+
+// DnsAlias replaces the IdentifierSuffix of a dns-01 resource name with the
+// AliasDomain (e.g. suffix example.com and alias validation.example.net makes
+// _acme-challenge.www.example.com -> _acme-challenge.www.validation.example.net)
+type DnsAlias struct {
+	IdentifierSuffix string `yaml:"identifier_suffix"`
+	AliasDomain      string `yaml:"alias_domain"`
+}
+
+// dnsResourceName returns the name the dns-01 resource should actually be
+// provisioned at. A configured alias is used first, then (if enabled) the CNAME
+// chain is followed. If neither applies, resourceName is returned unchanged.
+func (service *Service) dnsResourceName(method Method, resourceName string) (string, error) {
+	// acme-dns is always used via a CNAME and updates the target itself
+	if method.Value.providerType() == methodValueDns01AcmeDns {
+		return resourceName, nil
+	}
+
+	// configured alias (longest matching suffix)
+	lowerName := strings.ToLower(resourceName)
+	var alias *DnsAlias
+	for i := range service.dnsAliases {
+		suffix := strings.ToLower(strings.Trim(service.dnsAliases[i].IdentifierSuffix, "."))
+		if suffix != "" && strings.HasSuffix(lowerName, "."+suffix) &&
+			(alias == nil || len(suffix) > len(strings.Trim(alias.IdentifierSuffix, "."))) {
+			alias = &service.dnsAliases[i]
+		}
+	}
+	if alias != nil {
+		suffix := strings.Trim(alias.IdentifierSuffix, ".")
+		aliasName := resourceName[:len(resourceName)-len(suffix)] + strings.Trim(alias.AliasDomain, ".")
+		service.logger.Debugf("dns-01 resource %s using alias %s", resourceName, aliasName)
+		return aliasName, nil
+	}
+
+	// follow cname
+	if dnsChecker := service.getDnsChecker(); service.followCNAME && dnsChecker != nil {
+		target, err := dnsChecker.ResolveCNAME(resourceName)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(target, resourceName) {
+			service.logger.Debugf("dns-01 resource %s delegated to %s", resourceName, target)
+		}
+		return target, nil
+	}
+
+	return resourceName, nil
+}
@@ -0,0 +1,217 @@
+package dns_checker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errNoRecursiveServers = errors.New("dns check: no recursive dns servers configured to discover authoritative servers")
+	errZoneNotFound       = errors.New("dns check: could not find zone (soa) for record")
+	errNoAuthoritative    = errors.New("dns check: could not find any authoritative servers for zone")
+)
+
+// query sends a single dns query using the exchanger. Responses other than
+// success or name error (i.e. the name doesn't exist) are returned as an error.
+func query(ex exchanger, name string, qtype uint16, recursionDesired bool) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = recursionDesired
+
+	response, err := ex.exchange(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("dns check: query for %s (%s) to %s failed (%s)", name,
+			dns.TypeToString[qtype], ex, dns.RcodeToString[response.Rcode])
+	}
+
+	return response, nil
+}
+
+// recursiveExchange sends the query to the configured recursive servers, in order,
+// until one of them responds
+func (service *Service) recursiveExchange(name string, qtype uint16) (response *dns.Msg, err error) {
+	if len(service.recursiveServers) == 0 {
+		return nil, errNoRecursiveServers
+	}
+
+	for _, server := range service.recursiveServers {
+		response, err = query(server, name, qtype, true)
+		if err == nil {
+			return response, nil
+		}
+	}
+
+	return nil, err
+}
+
+// findZone walks up the fqdn's labels until it finds the name that is the apex
+// of a zone (i.e. the name that owns an SOA record)
+func (service *Service) findZone(fqdn string) (zone string, err error) {
+	name := dns.Fqdn(fqdn)
+
+	for {
+		response, err := service.recursiveExchange(name, dns.TypeSOA)
+		if err != nil {
+			return "", err
+		}
+
+		for _, rr := range response.Answer {
+			if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(soa.Hdr.Name, name) {
+				return soa.Hdr.Name, nil
+			}
+		}
+
+		// move to parent, fail if already at root
+		nextLabel, end := dns.NextLabel(name, 0)
+		if end {
+			return "", errZoneNotFound
+		}
+		name = name[nextLabel:]
+	}
+}
+
+// authoritativeServers returns the addresses (ip:53) of all of the zone's
+// authoritative nameservers. IPv4 is preferred, but IPv6 is used if the
+// nameserver has no IPv4 address.
+func (service *Service) authoritativeServers(zone string) (servers []string, err error) {
+	response, err := service.recursiveExchange(zone, dns.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rr := range response.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+
+		// lookup address of nameserver
+		var ip string
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			addrResponse, err := service.recursiveExchange(ns.Ns, qtype)
+			if err != nil {
+				service.logger.Debugf("dns check: failed to lookup address of nameserver %s (%s)", ns.Ns, err)
+				continue
+			}
+
+			ip = firstAddress(addrResponse.Answer)
+
+			if ip != "" {
+				break
+			}
+		}
+
+		if ip == "" {
+			// a nameserver that can't be found can't be checked, so the
+			// record can't be confirmed as propagated
+			return nil, fmt.Errorf("dns check: could not find address of authoritative nameserver %s", ns.Ns)
+		}
+
+		servers = append(servers, net.JoinHostPort(ip, "53"))
+	}
+
+	if len(servers) == 0 {
+		return nil, errNoAuthoritative
+	}
+
+	return servers, nil
+}
+
+// checkDnsRecordAuthoritative finds all of the authoritative nameservers for the
+// fqdn's zone and directly queries each of them for the specified record. TRUE is
+// only returned if every authoritative nameserver returns the expected record. Any
+// error (including a single nameserver failing) returns an error.
+func (service *Service) checkDnsRecordAuthoritative(fqdn string, recordValue string, recordType dnsRecordType) (exists bool, err error) {
+	// only TXT is supported
+	if recordType != txtRecord {
+		return false, errors.New("unsupported dns record type")
+	}
+
+	zone, err := service.findZone(fqdn)
+	if err != nil {
+		return false, err
+	}
+
+	servers, err := service.authoritativeServers(zone)
+	if err != nil {
+		return false, err
+	}
+	service.logger.Debugf("dns check (%s): checking zone %s authoritative servers: %s", fqdn, zone, servers)
+
+	// query every authoritative server concurrently
+	var wg sync.WaitGroup
+	wg.Add(len(servers))
+	wgResults := make(chan bool, len(servers))
+	wgErrors := make(chan error, len(servers))
+
+	for i := range servers {
+		go func(server string) {
+			defer wg.Done()
+			result, e := checkTXTAuthoritative(fqdn, recordValue, server)
+			wgResults <- result
+			wgErrors <- e
+		}(servers[i])
+	}
+
+	wg.Wait()
+	close(wgResults)
+	close(wgErrors)
+
+	for err := range wgErrors {
+		if err != nil {
+			return false, err
+		}
+	}
+
+	successCount := 0
+	for existed := range wgResults {
+		if existed {
+			successCount++
+		}
+	}
+	service.logger.Debugf("dns check (%s): authoritative success count: %d, server count: %d", fqdn, successCount, len(servers))
+
+	return successCount == len(servers), nil
+}
+
+// checkTXTAuthoritative directly queries the server (non-recursively) for the
+// fqdn's TXT records and returns true if one of them matches recordValue
+func checkTXTAuthoritative(fqdn string, recordValue string, server string) (exists bool, err error) {
+	response, err := query(plainExchanger(server), fqdn, dns.TypeTXT, false)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rr := range response.Answer {
+		// long TXT values may be split into multiple strings
+		if txt, ok := rr.(*dns.TXT); ok && strings.Join(txt.Txt, "") == recordValue {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// firstAddress returns the first A or AAAA address in the records, or blank
+// if there isn't one
+func firstAddress(rrs []dns.RR) string {
+	for _, rr := range rrs {
+		switch addr := rr.(type) {
+		case *dns.A:
+			return addr.A.String()
+		case *dns.AAAA:
+			return addr.AAAA.String()
+		}
+	}
+
+	return ""
+}
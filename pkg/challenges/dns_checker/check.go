@@ -3,37 +3,24 @@ package dns_checker
 import (
 	"context"
 	"errors"
-	"net"
-	"strings"
 	"sync"
 	"time"
 )
 
-// thresholds to decide if checking succeeded for not.
-// propagationRequirement is the portion of functioning dns services that need
-// to return the expected record for the check to yield TRUE (e.g. 1 = 100%)
-// functioningRequirement is the portion of DNS services that must not error in
-// order for a check to not produce an Error.
-const (
-	propagationRequirement = 1.0
-	functioningRequirement = 0.5
-)
-
 // checkDnsRecordAllServices sends concurrent dns requests using all configured
 // resolvers to check for the existence of the specified record. If the propagation
-// requirement is met, TRUE is returned. An Error is returned if the functioning
-// requirement is not met.
-func (service *Service) checkDnsRecordAllServices(fqdn string, recordValue string, recordType dnsRecordType) (exists bool, err error) {
+// threshold is met, TRUE is returned. An Error is returned if the functioning
+// threshold is not met. ctx cancels the skip wait.
+func (service *Service) checkDnsRecordAllServices(ctx context.Context, fqdn string, recordValue string, recordType dnsRecordType, thresholds Thresholds) (exists bool, err error) {
 	// if no resolvers (i.e. configured to skip)
 	if service.dnsResolvers == nil {
 		// sleep the skip wait and then return true (assume propagated)
 		service.logger.Debugf("dns check (%s): skipping and sleeping %d seconds", fqdn, int(service.skipWait.Seconds()))
 
-		// sleep or cancel/error if shutdown is called
+		// sleep or cancel/error if canceled or shutdown is called
 		select {
-		case <-service.shutdownContext.Done():
-			// cancel/error if shutting down
-			return false, errShutdown
+		case <-ctx.Done():
+			return false, service.canceledErr()
 
 		case <-time.After(service.skipWait):
 			// sleep and retry
@@ -42,6 +29,11 @@ func (service *Service) checkDnsRecordAllServices(fqdn string, recordValue strin
 		return true, nil
 	}
 
+	// if configured, check authoritative servers instead of the resolvers
+	if service.checkAuthoritative {
+		return service.checkDnsRecordAuthoritative(fqdn, recordValue, recordType)
+	}
+
 	// use waitgroup for concurrent checking
 	var wg sync.WaitGroup
 	resolverTotal := len(service.dnsResolvers)
@@ -78,9 +70,9 @@ func (service *Service) checkDnsRecordAllServices(fqdn string, recordValue strin
 	// calculate error rate
 	errCount := len(returnedErrs)
 	errRate := float32(errCount) / float32(resolverTotal)
-	service.logger.Debugf("dns check (%s): error count: %d, error rate: %.2f, error threshold: %.2f", fqdn, errCount, errRate, functioningRequirement)
+	service.logger.Debugf("dns check (%s): error count: %d, error rate: %.2f, error threshold: %.2f", fqdn, errCount, errRate, thresholds.Functioning)
 	// if error rate is greater than tolerable, error
-	if errRate > (1 - functioningRequirement) {
+	if errRate > float32(1-thresholds.Functioning) {
 		return false, returnedErrs[0]
 	}
 
@@ -94,8 +86,8 @@ func (service *Service) checkDnsRecordAllServices(fqdn string, recordValue strin
 
 	// calculate propagation
 	propagationRate := float32(successCount) / float32(resolverTotal-errCount)
-	service.logger.Debugf("dns check (%s): success count: %d, resolver count: %d, propagation rate: %.2f, propagation req: %.2f", fqdn, successCount, resolverTotal, propagationRate, propagationRequirement)
-	if propagationRate < propagationRequirement {
+	service.logger.Debugf("dns check (%s): success count: %d, resolver count: %d, propagation rate: %.2f, propagation req: %.2f", fqdn, successCount, resolverTotal, propagationRate, thresholds.Propagation)
+	if propagationRate < float32(thresholds.Propagation) {
 		// not fully propagated, return false
 		return false, nil
 	}
@@ -107,7 +99,7 @@ func (service *Service) checkDnsRecordAllServices(fqdn string, recordValue strin
 // checkDnsRecord checks if the fqdn has a record of the specified type, set to the specified
 // value, on the specified dns resolver. If the record does not exist or exists but the value is
 // different, false is returned. If there is an error querying for the record, an error is returned.
-func checkDnsRecord(fqdn string, recordValue string, recordType dnsRecordType, r *net.Resolver) (exists bool, err error) {
+func checkDnsRecord(fqdn string, recordValue string, recordType dnsRecordType, r resolver) (exists bool, err error) {
 	var values []string
 
 	// nil check
@@ -115,15 +107,11 @@ func checkDnsRecord(fqdn string, recordValue string, recordType dnsRecordType, r
 		return false, errors.New("can't check record, resolver is nil")
 	}
 
-	// timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
-	defer cancel()
-
 	// run appropriate query function
 	switch recordType {
 	// TXT records
 	case txtRecord:
-		values, err = r.LookupTXT(ctx, fqdn)
+		values, err = r.lookupTXT(fqdn)
 
 	// any other (unsupported)
 	default:
@@ -132,12 +120,7 @@ func checkDnsRecord(fqdn string, recordValue string, recordType dnsRecordType, r
 
 	// error check
 	if err != nil {
-		// error is "no such host" - aka success but record doesn't exist
-		// succeeded but record does not exist
-		if strings.Contains(err.Error(), "no such host") {
-			return false, nil
-		}
-		// any other error, server failed
+		// any error, server failed
 		return false, err
 	}
 
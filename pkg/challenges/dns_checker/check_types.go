@@ -1,8 +1,8 @@
 package dns_checker
 
 import (
+	"context"
 	"errors"
-	"time"
 )
 
 // define dnsRecordType
@@ -19,36 +19,85 @@ const (
 var (
 	ErrDnsRecordNotFound = errors.New("dns record not found by checker")
 	errShutdown          = errors.New("dns provisioning canceled due to shutdown")
+	errCanceled          = errors.New("dns provisioning canceled")
 )
 
-// CheckTXTWithRetry checks for the specified record. If the check fails, sleep and retry
-// the check again up to the maxTries specified. After exhausing retries, return false
-// if still not successful.
-func (service *Service) CheckTXTWithRetry(fqdn string, recordValue string, maxTries int) (propagated bool, err error) {
-	// retry loop
-	for i := 1; i <= maxTries; i++ {
+// canceledErr returns the error for a check that was canceled (ctx is done),
+// depending on if the app is shutting down
+func (service *Service) canceledErr() error {
+	if service.shutdownContext.Err() != nil {
+		return errShutdown
+	}
+	return errCanceled
+}
+
+// CheckTXTWithRetry checks for the specified record. If the check fails, retry
+// according to the schedule. Once the schedule's max wait has elapsed, return
+// false if still not successful. An error is returned if ctx is canceled.
+func (service *Service) CheckTXTWithRetry(ctx context.Context, fqdn string, recordValue string, schedule Schedule, thresholds Thresholds) (propagated bool, err error) {
+	propagated, err = schedule.Run(ctx, func() (bool, error) {
 		// check for propagation
-		propagated, err := service.checkDnsRecordAllServices(fqdn, recordValue, txtRecord)
+		propagated, err := service.checkDnsRecordAllServices(ctx, fqdn, recordValue, txtRecord, thresholds)
 		// if error, log error but still retry
 		if err != nil {
 			service.logger.Error(err)
-		} else if propagated {
-			// if propagated, done & success
-			return true, nil
+			return false, nil
 		}
+		return propagated, nil
+	})
+	if err != nil {
+		// canceled or shutting down
+		return false, service.canceledErr()
+	}
 
-		// sleep or cancel/error if shutdown is called
-		select {
-		case <-service.shutdownContext.Done():
-			// cancel/error if shutting down
-			return false, errShutdown
+	if !propagated {
+		// schedule exhausted without success
+		service.logger.Error(ErrDnsRecordNotFound)
+	}
+
+	return propagated, nil
+}
 
-		case <-time.After(time.Duration(i) * 15 * time.Second):
-			// sleep and retry
+// TXTRecord is a TXT record's fqdn and value
+type TXTRecord struct {
+	Fqdn  string
+	Value string
+}
+
+// CheckTXTsWithRetry checks for all of the specified records. Each try only
+// rechecks the records that haven't been found yet. If any are still missing,
+// retry according to the schedule. Once the schedule's max wait has elapsed,
+// return false if still not successful. An error is returned if ctx is canceled.
+func (service *Service) CheckTXTsWithRetry(ctx context.Context, records []TXTRecord, schedule Schedule, thresholds Thresholds) (propagated bool, err error) {
+	remaining := records
+
+	propagated, err = schedule.Run(ctx, func() (bool, error) {
+		// check each remaining record for propagation
+		var notFound []TXTRecord
+		for _, record := range remaining {
+			propagated, err := service.checkDnsRecordAllServices(ctx, record.Fqdn, record.Value, txtRecord, thresholds)
+			// if error, log error but still retry
+			if err != nil {
+				service.logger.Error(err)
+			}
+			if err != nil || !propagated {
+				notFound = append(notFound, record)
+			}
 		}
+		remaining = notFound
+
+		// if all propagated, done & success
+		return len(notFound) == 0, nil
+	})
+	if err != nil {
+		// canceled or shutting down
+		return false, service.canceledErr()
+	}
+
+	if !propagated {
+		// schedule exhausted without success
+		service.logger.Errorf("%s (%d of %d records)", ErrDnsRecordNotFound, len(remaining), len(records))
 	}
 
-	// loop exhausted without success
-	service.logger.Error(ErrDnsRecordNotFound)
-	return false, nil
+	return propagated, nil
 }
@@ -0,0 +1,44 @@
+package dns_checker
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// maxCNAMEChain is the maximum number of CNAMEs that will be followed
+const maxCNAMEChain = 10
+
+var errCNAMEChainTooLong = errors.New("dns check: cname chain too long (possible loop)")
+
+// ResolveCNAME follows the CNAME chain (if any) starting at fqdn and returns the
+// final target name. If fqdn is not a CNAME, fqdn is returned unchanged.
+func (service *Service) ResolveCNAME(fqdn string) (target string, err error) {
+	name := dns.Fqdn(fqdn)
+
+	for i := 0; i < maxCNAMEChain; i++ {
+		response, err := service.recursiveExchange(name, dns.TypeCNAME)
+		if err != nil {
+			return "", err
+		}
+
+		next := ""
+		for _, rr := range response.Answer {
+			if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Hdr.Name, name) {
+				next = cname.Target
+				break
+			}
+		}
+
+		// not a cname, chain is done
+		if next == "" {
+			return strings.TrimSuffix(name, "."), nil
+		}
+
+		service.logger.Debugf("dns cname: %s -> %s", name, next)
+		name = next
+	}
+
+	return "", errCNAMEChainTooLong
+}
@@ -0,0 +1,218 @@
+package dns_checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DNS services can be plain dns (an ip address), DNS-over-HTTPS (an https:// url)
+// or DNS-over-TLS (tls://host or tls://host:port).
+
+const (
+	dohPrefix      = "https://"
+	dotPrefix      = "tls://"
+	dotDefaultPort = "853"
+)
+
+var errNoCARoots = errors.New("dns check: ca roots file does not contain any certificates")
+
+// exchanger sends a dns query message to a dns service and returns the response
+type exchanger interface {
+	exchange(msg *dns.Msg) (*dns.Msg, error)
+	String() string
+}
+
+// isEncrypted returns true if the dns service address is DoH or DoT
+func isEncrypted(address string) bool {
+	return strings.HasPrefix(address, dohPrefix) || strings.HasPrefix(address, dotPrefix)
+}
+
+// makeExchanger creates the exchanger for the dns service address. rootCAs are
+// used to verify DoH and DoT servers (nil uses the system's roots).
+func makeExchanger(address string, rootCAs *x509.CertPool) (exchanger, error) {
+	switch {
+	case strings.HasPrefix(address, dohPrefix):
+		return &dohExchanger{
+			url: address,
+			client: &http.Client{
+				Timeout: timeoutSeconds * time.Second,
+				Transport: &http.Transport{
+					Proxy:             http.ProxyFromEnvironment,
+					TLSClientConfig:   &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+					ForceAttemptHTTP2: true,
+				},
+			},
+		}, nil
+
+	case strings.HasPrefix(address, dotPrefix):
+		hostPort := strings.TrimPrefix(address, dotPrefix)
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			// no port specified
+			host, port = hostPort, dotDefaultPort
+		}
+		if host == "" {
+			return nil, fmt.Errorf("dns check: invalid dns-over-tls address %s", address)
+		}
+
+		return &dotExchanger{
+			address: net.JoinHostPort(host, port),
+			client: &dns.Client{
+				Net:       "tcp-tls",
+				Timeout:   timeoutSeconds * time.Second,
+				TLSConfig: &tls.Config{RootCAs: rootCAs, ServerName: host, MinVersion: tls.VersionTLS12},
+			},
+		}, nil
+
+	default:
+		return plainExchanger(net.JoinHostPort(address, "53")), nil
+	}
+}
+
+// loadRootCAs loads the pem certificates from the file to use as the roots for
+// verifying DoH and DoT servers. If file is blank, nil (system roots) is returned.
+func loadRootCAs(file string) (*x509.CertPool, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	pemBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, errNoCARoots
+	}
+
+	return pool, nil
+}
+
+// plainExchanger is a plain dns server (ip:port). Queries are sent over udp and
+// retried over tcp if the response is truncated.
+type plainExchanger string
+
+func (server plainExchanger) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	client := &dns.Client{Timeout: timeoutSeconds * time.Second}
+	response, _, err := client.Exchange(msg, string(server))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Truncated {
+		client.Net = "tcp"
+		response, _, err = client.Exchange(msg, string(server))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func (server plainExchanger) String() string {
+	return string(server)
+}
+
+// dotExchanger is a DNS-over-TLS server (RFC 7858)
+type dotExchanger struct {
+	address string
+	client  *dns.Client
+}
+
+func (dot *dotExchanger) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	response, _, err := dot.client.Exchange(msg, dot.address)
+	return response, err
+}
+
+func (dot *dotExchanger) String() string {
+	return dotPrefix + dot.address
+}
+
+// dohExchanger is a DNS-over-HTTPS server (RFC 8484)
+type dohExchanger struct {
+	url    string
+	client *http.Client
+}
+
+func (doh *dohExchanger) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	// id should be 0 to be cache friendly (RFC 8484 4.1)
+	msg = msg.Copy()
+	msg.Id = 0
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doh.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := doh.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dns check: dns-over-https server %s returned status %d", doh.url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response := new(dns.Msg)
+	err = response.Unpack(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (doh *dohExchanger) String() string {
+	return doh.url
+}
+
+// exchangeResolver resolves TXT records by sending queries with an exchanger
+type exchangeResolver struct {
+	exchanger exchanger
+}
+
+func (r exchangeResolver) lookupTXT(fqdn string) (values []string, err error) {
+	response, err := query(r.exchanger, fqdn, dns.TypeTXT, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rr := range response.Answer {
+		// long TXT values may be split into multiple strings
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,179 @@
+package dns_checker
+
+import (
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// newTestDohServer starts a DoH server (using a test CA) and returns it and a
+// file containing its CA's pem certificate. Each query is answered with a TXT
+// record (and then extraBody, if any).
+func newTestDohServer(t *testing.T, status int, extraBody func(w io.Writer)) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" ||
+			r.Header.Get("Accept") != "application/dns-message" {
+			http.Error(w, "bad doh request", http.StatusBadRequest)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		query := new(dns.Msg)
+		err := query.Unpack(body)
+		if err != nil || query.Id != 0 || len(query.Question) != 1 {
+			http.Error(w, "bad dns query", http.StatusBadRequest)
+			return
+		}
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+
+		response := new(dns.Msg)
+		response.SetReply(query)
+		response.Answer = append(response.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+			Txt: []string{"test-value"},
+		})
+		packed, _ := response.Pack()
+
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(packed)
+		if extraBody != nil {
+			extraBody(w)
+		}
+	}))
+	// the untrusted client's handshake errors are expected
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err := os.WriteFile(caFile, caPem, 0600)
+	if err != nil {
+		t.Fatalf("failed to write ca file: %s", err)
+	}
+
+	return server, caFile
+}
+
+// testTXTQuery returns a TXT query with a non-zero id
+func testTXTQuery() *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetQuestion("_acme-challenge.example.com.", dns.TypeTXT)
+	msg.Id = 1234
+	return msg
+}
+
+// TestDohExchange confirms a DoH query is POSTed as a dns message with id 0, and
+// the server is verified with the custom CA roots
+func TestDohExchange(t *testing.T) {
+	server, caFile := newTestDohServer(t, http.StatusOK, nil)
+
+	rootCAs, err := loadRootCAs(caFile)
+	if err != nil {
+		t.Fatalf("failed to load ca roots: %s", err)
+	}
+	exch, err := makeExchanger(server.URL, rootCAs)
+	if err != nil {
+		t.Fatalf("failed to make exchanger: %s", err)
+	}
+
+	query := testTXTQuery()
+	response, err := exch.exchange(query)
+	if err != nil {
+		t.Fatalf("exchange failed: %s", err)
+	}
+	if len(response.Answer) != 1 || response.Answer[0].(*dns.TXT).Txt[0] != "test-value" {
+		t.Errorf("answer: got %v", response.Answer)
+	}
+	if query.Id != 1234 {
+		t.Errorf("caller's query id changed to %d", query.Id)
+	}
+
+	// system roots don't trust the test CA
+	exch, err = makeExchanger(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to make exchanger: %s", err)
+	}
+	_, err = exch.exchange(testTXTQuery())
+	if err == nil {
+		t.Error("exchange with an untrusted server succeeded")
+	}
+}
+
+// TestDohExchangeErrors confirms a non-200 status is an error and that a response
+// body is not read past the max dns message size
+func TestDohExchangeErrors(t *testing.T) {
+	server, caFile := newTestDohServer(t, http.StatusInternalServerError, nil)
+	rootCAs, err := loadRootCAs(caFile)
+	if err != nil {
+		t.Fatalf("failed to load ca roots: %s", err)
+	}
+	exch, _ := makeExchanger(server.URL, rootCAs)
+
+	_, err = exch.exchange(testTXTQuery())
+	if err == nil {
+		t.Error("exchange with status 500 succeeded")
+	}
+
+	// endless body (until the client stops reading)
+	server, caFile = newTestDohServer(t, http.StatusOK, func(w io.Writer) {
+		padding := make([]byte, 4096)
+		for {
+			if _, err := w.Write(padding); err != nil {
+				return
+			}
+		}
+	})
+	rootCAs, err = loadRootCAs(caFile)
+	if err != nil {
+		t.Fatalf("failed to load ca roots: %s", err)
+	}
+	exch, _ = makeExchanger(server.URL, rootCAs)
+
+	// without the limit, reading wouldn't stop until the client timed out
+	start := time.Now()
+	response, err := exch.exchange(testTXTQuery())
+	if err != nil {
+		t.Fatalf("exchange with a long body failed: %s", err)
+	}
+	if len(response.Answer) != 1 {
+		t.Errorf("answer: got %v", response.Answer)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("long body took %s, it should stop at the max message size", elapsed)
+	}
+}
+
+// TestLoadRootCAs confirms a blank file uses the system roots and a file without
+// certificates is an error
+func TestLoadRootCAs(t *testing.T) {
+	rootCAs, err := loadRootCAs("")
+	if rootCAs != nil || err != nil {
+		t.Errorf("blank file: got %v, %v, want nil, nil", rootCAs, err)
+	}
+
+	emptyFile := filepath.Join(t.TempDir(), "empty.pem")
+	err = os.WriteFile(emptyFile, []byte("not a certificate\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	_, err = loadRootCAs(emptyFile)
+	if !errors.Is(err, errNoCARoots) {
+		t.Errorf("file without certificates: got %v, want %v", err, errNoCARoots)
+	}
+}
@@ -2,8 +2,10 @@ package dns_checker
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"net"
+	"strings"
 	"time"
 )
 
@@ -14,20 +16,32 @@ var (
 	errBlankIP = errors.New("can't create resolver, ip address is blank")
 )
 
+// resolver looks up the values of TXT records. If the record does not exist, no
+// values (and no error) are returned.
+type resolver interface {
+	lookupTXT(fqdn string) (values []string, err error)
+}
+
 // makeResolvers generates all of the resolver pairs for a slice
 // of DNS Service IP Pairs
 func makeResolvers(dnsServices []DnsServiceIPPair) ([]dnsResolverPair, error) {
 	// add each service pair to the resolver pairs
 	dnsResolverPairs := []dnsResolverPair{}
 	for i := range dnsServices {
+		// custom roots for DoH and DoT (if any)
+		rootCAs, err := loadRootCAs(dnsServices[i].CARootsFile)
+		if err != nil {
+			return nil, err
+		}
+
 		// make primary
-		primaryR, err := makeResolver(dnsServices[i].Primary)
+		primaryR, err := makeResolver(dnsServices[i].Primary, rootCAs)
 		if err != nil {
 			return nil, err
 		}
 
 		// make secondary (blank is okay, just exclude it)
-		secondaryR, err := makeResolver(dnsServices[i].Secondary)
+		secondaryR, err := makeResolver(dnsServices[i].Secondary, rootCAs)
 		if err != nil && !errors.Is(err, errBlankIP) {
 			return nil, err
 		}
@@ -45,18 +59,35 @@ func makeResolvers(dnsServices []DnsServiceIPPair) ([]dnsResolverPair, error) {
 	return dnsResolverPairs, nil
 }
 
-// makeResolver creates a net.Resolver to resolve DNS queries using
-// the specified DNS server IP.
-func makeResolver(ipAddress string) (*net.Resolver, error) {
-	if ipAddress == "" {
+// makeResolver creates a resolver to resolve DNS queries using the specified
+// DNS server (ip address, DoH url, or DoT host).
+func makeResolver(address string, rootCAs *x509.CertPool) (resolver, error) {
+	if address == "" {
 		return nil, errBlankIP
 	}
 
+	// DoH and DoT
+	if isEncrypted(address) {
+		ex, err := makeExchanger(address, rootCAs)
+		if err != nil {
+			return nil, err
+		}
+		r := exchangeResolver{exchanger: ex}
+
+		// make sure the dns resolver actually works
+		_, err = r.lookupTXT("google.com")
+		if err != nil {
+			return nil, err
+		}
+
+		return r, nil
+	}
+
 	r := &net.Resolver{
 		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
 			d := net.Dialer{}
-			return d.DialContext(ctx, network, ipAddress+":53")
+			return d.DialContext(ctx, network, address+":53")
 		},
 	}
 
@@ -69,5 +100,27 @@ func makeResolver(ipAddress string) (*net.Resolver, error) {
 		return nil, err
 	}
 
-	return r, nil
+	return netResolver{resolver: r}, nil
+}
+
+// netResolver resolves using a net.Resolver (plain dns)
+type netResolver struct {
+	resolver *net.Resolver
+}
+
+func (r netResolver) lookupTXT(fqdn string) (values []string, err error) {
+	// timeout context
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
+	defer cancel()
+
+	values, err = r.resolver.LookupTXT(ctx, fqdn)
+	if err != nil {
+		// error is "no such host" - aka success but record doesn't exist
+		if strings.Contains(err.Error(), "no such host") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return values, nil
 }
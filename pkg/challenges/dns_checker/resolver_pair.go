@@ -1,18 +1,20 @@
 package dns_checker
 
-import "net"
-
 // DnsServiceIPPair contains a primary and secondary DNS server for
-// a given DNS service
+// a given DNS service. Each server can be an ip address, a DNS-over-HTTPS url
+// (https://...), or a DNS-over-TLS host (tls://host or tls://host:port). If
+// CARootsFile is specified, its pem certificates are used (instead of the system's)
+// to verify DoH and DoT servers.
 type DnsServiceIPPair struct {
-	Primary   string `yaml:"primary_ip"`
-	Secondary string `yaml:"secondary_ip"`
+	Primary     string `yaml:"primary_ip"`
+	Secondary   string `yaml:"secondary_ip"`
+	CARootsFile string `yaml:"ca_roots_file"`
 }
 
-// dnsResolverPair contains the net.Resolver pair for a specific DNS service
+// dnsResolverPair contains the resolver pair for a specific DNS service
 type dnsResolverPair struct {
-	primary   *net.Resolver
-	secondary *net.Resolver
+	primary   resolver
+	secondary resolver
 }
 
 // checkDnsRecord attempts to find the specified record using the dnsResolverPair. It
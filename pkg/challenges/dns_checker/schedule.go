@@ -0,0 +1,86 @@
+package dns_checker
+
+import (
+	"context"
+	"time"
+)
+
+// Schedule configures when repeated attempts (e.g. checking for a record) are
+// made. The first attempt is after InitialDelay and each following attempt is
+// after Interval, which is multiplied by BackoffFactor and then increased by
+// IntervalIncrement after each attempt. Attempts stop once MaxWait (which
+// includes InitialDelay) has elapsed.
+type Schedule struct {
+	InitialDelay      time.Duration
+	Interval          time.Duration
+	BackoffFactor     float64
+	IntervalIncrement time.Duration
+	MaxWait           time.Duration
+}
+
+// Thresholds to decide if checking succeeded or not.
+// Propagation is the portion of functioning dns services that need to return the
+// expected record for the check to yield TRUE (e.g. 1 = 100%).
+// Functioning is the portion of DNS services that must not error in order for a
+// check to not produce an Error.
+// Thresholds are not used when checking authoritative servers (every server must
+// return the record).
+type Thresholds struct {
+	Propagation float64
+	Functioning float64
+}
+
+// Run calls attempt according to the schedule until attempt is done, attempt
+// errors, or MaxWait has elapsed (in which case false is returned). If ctx is
+// canceled, ctx's error is returned.
+func (schedule Schedule) Run(ctx context.Context, attempt func() (done bool, err error)) (done bool, err error) {
+	elapsed := schedule.InitialDelay
+	err = sleep(ctx, schedule.InitialDelay)
+	if err != nil {
+		return false, err
+	}
+
+	interval := schedule.Interval
+	for {
+		done, err = attempt()
+		if err != nil || done {
+			return done, err
+		}
+
+		// out of time
+		if elapsed >= schedule.MaxWait {
+			return false, nil
+		}
+
+		// don't wait past MaxWait, make a final attempt at MaxWait instead (also
+		// if the interval is invalid, to avoid spinning)
+		wait := interval
+		if wait <= 0 || elapsed+wait > schedule.MaxWait {
+			wait = schedule.MaxWait - elapsed
+		}
+
+		err = sleep(ctx, wait)
+		if err != nil {
+			return false, err
+		}
+		elapsed += wait
+
+		interval = time.Duration(float64(interval)*schedule.BackoffFactor) + schedule.IntervalIncrement
+	}
+}
+
+// sleep sleeps for the duration or until ctx is canceled (in which case ctx's
+// error is returned)
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-time.After(d):
+		return nil
+	}
+}
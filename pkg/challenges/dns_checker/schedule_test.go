@@ -0,0 +1,113 @@
+package dns_checker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// unit scales the test schedules (e.g. 15 units = 15 seconds in real timing)
+const unit = 5 * time.Millisecond
+
+// TestScheduleRun confirms attempts are made at the scheduled times
+func TestScheduleRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		// wantAt is when each attempt should be made, in units
+		wantAt []int
+	}{
+		{
+			name: "fixed interval",
+			schedule: Schedule{
+				InitialDelay:  2 * unit,
+				Interval:      3 * unit,
+				BackoffFactor: 1.0,
+				MaxWait:       11 * unit,
+			},
+			// final attempt is at max wait
+			wantAt: []int{2, 5, 8, 11},
+		},
+		{
+			name: "backoff factor",
+			schedule: Schedule{
+				Interval:      2 * unit,
+				BackoffFactor: 2.0,
+				MaxWait:       15 * unit,
+			},
+			wantAt: []int{0, 2, 6, 14, 15},
+		},
+		{
+			// the default propagation schedule (10 tries, the interval growing by
+			// 15s after each try, the last try at 675s)
+			name: "interval increment",
+			schedule: Schedule{
+				Interval:          1 * unit,
+				BackoffFactor:     1.0,
+				IntervalIncrement: 1 * unit,
+				MaxWait:           45 * unit,
+			},
+			wantAt: []int{0, 1, 3, 6, 10, 15, 21, 28, 36, 45},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			var at []time.Duration
+			done, err := tt.schedule.Run(context.Background(), func() (bool, error) {
+				at = append(at, time.Since(start))
+				return false, nil
+			})
+			if done || err != nil {
+				t.Fatalf("got done %t, err %v, want false, nil", done, err)
+			}
+
+			if len(at) != len(tt.wantAt) {
+				t.Fatalf("attempts: got %d (%v), want %d", len(at), at, len(tt.wantAt))
+			}
+			for i := range at {
+				if at[i] < time.Duration(tt.wantAt[i])*unit {
+					t.Errorf("attempt %d: made at %s, want no earlier than %s", i, at[i], time.Duration(tt.wantAt[i])*unit)
+				}
+			}
+		})
+	}
+}
+
+// TestScheduleRunStops confirms Run stops when an attempt is done or errors, or
+// when the context is canceled
+func TestScheduleRunStops(t *testing.T) {
+	schedule := Schedule{Interval: unit, BackoffFactor: 1.0, MaxWait: 100 * unit}
+
+	attempts := 0
+	done, err := schedule.Run(context.Background(), func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	if !done || err != nil || attempts != 3 {
+		t.Errorf("done: got done %t, err %v after %d attempts, want true, nil after 3", done, err, attempts)
+	}
+
+	errAttempt := errors.New("attempt failed")
+	_, err = schedule.Run(context.Background(), func() (bool, error) {
+		return false, errAttempt
+	})
+	if !errors.Is(err, errAttempt) {
+		t.Errorf("error: got %v, want %v", err, errAttempt)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	_, err = schedule.Run(ctx, func() (bool, error) {
+		attempts++
+		if attempts == 2 {
+			cancel()
+		}
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) || attempts != 2 {
+		t.Errorf("canceled: got err %v after %d attempts, want %v after 2", err, attempts, context.Canceled)
+	}
+}
@@ -20,6 +20,7 @@ type App interface {
 type Config struct {
 	SkipCheckWaitSeconds *int               `yaml:"skip_check_wait_seconds"`
 	DnsServices          []DnsServiceIPPair `yaml:"dns_services"`
+	CheckAuthoritative   *bool              `yaml:"check_authoritative"`
 }
 
 // service struct
@@ -28,6 +29,9 @@ type Service struct {
 	logger          *zap.SugaredLogger
 	skipWait        time.Duration
 	dnsResolvers    []dnsResolverPair
+	// authoritative checking
+	checkAuthoritative bool
+	recursiveServers   []exchanger
 }
 
 // NewService creates a new service
@@ -54,6 +58,35 @@ func NewService(app App, cfg Config) (service *Service, err error) {
 			service.logger.Errorf("failed to configure dns checker resolvers (%s)", err)
 			return nil, err
 		}
+
+		// authoritative mode uses the dns services to discover the authoritative servers
+		if cfg.CheckAuthoritative != nil && *cfg.CheckAuthoritative {
+			service.logger.Info("dns checker will query authoritative nameservers directly")
+			service.checkAuthoritative = true
+		}
+	}
+
+	// recursive servers for direct queries (e.g. authoritative discovery and cname
+	// resolution), these are used even if checking is skipped
+	for i := range cfg.DnsServices {
+		rootCAs, err := loadRootCAs(cfg.DnsServices[i].CARootsFile)
+		if err != nil {
+			service.logger.Errorf("failed to load dns checker ca roots (%s)", err)
+			return nil, err
+		}
+
+		for _, address := range []string{cfg.DnsServices[i].Primary, cfg.DnsServices[i].Secondary} {
+			if address == "" {
+				continue
+			}
+
+			server, err := makeExchanger(address, rootCAs)
+			if err != nil {
+				service.logger.Errorf("failed to configure dns checker recursive server (%s)", err)
+				return nil, err
+			}
+			service.recursiveServers = append(service.recursiveServers, server)
+		}
 	}
 
 	return service, nil
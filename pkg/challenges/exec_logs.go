@@ -0,0 +1,61 @@
+package challenges
+
+import (
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/challenges/providers/external"
+	"legocerthub-backend/pkg/order_events"
+)
+
+// noOrder is the orderId used when provisioning isn't for an order (e.g. self
+// tests and sweeping orphaned resources). Executions and events without an order
+// are only logged, not saved.
+const noOrder = order_events.NoOrder
+
+// ProviderExecLog is a provider's execution of an external program (e.g. a dns
+// script) while solving an order's challenges
+type ProviderExecLog struct {
+	ID              int
+	OrderID         int
+	MethodValue     MethodValue
+	IdentifierValue string
+	Action          string
+	Command         string
+	ExitCode        int
+	TimedOut        bool
+	Stdout          string
+	Stderr          string
+	Error           string
+	StartedAt       int
+	DurationMs      int
+}
+
+// logExecutions saves the provider's executions to storage with the orderId. If
+// saving fails, the error is logged but solving continues.
+func (service *Service) logExecutions(orderId int, identifier acme.Identifier, method Method, executions []external.Execution) {
+	for _, execution := range executions {
+		service.logger.Debugf("%s %s (%s) exit code %d, stdout: %s, stderr: %s", method.Value, execution.Action, identifier.Value, execution.ExitCode, execution.Stdout, execution.Stderr)
+
+		// storage isn't configured until the app's storage is open
+		if orderId == noOrder || service.storage == nil {
+			continue
+		}
+
+		_, err := service.storage.PostNewProviderExecLog(ProviderExecLog{
+			OrderID:         orderId,
+			MethodValue:     method.Value,
+			IdentifierValue: identifier.Value,
+			Action:          execution.Action,
+			Command:         execution.Command,
+			ExitCode:        execution.ExitCode,
+			TimedOut:        execution.TimedOut,
+			Stdout:          execution.Stdout,
+			Stderr:          execution.Stderr,
+			Error:           execution.Error,
+			StartedAt:       int(execution.StartedAt.Unix()),
+			DurationMs:      int(execution.Duration.Milliseconds()),
+		})
+		if err != nil {
+			service.logger.Errorf("failed to save %s execution log for order %d (%s)", method.Value, orderId, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package challenges
+
+import (
+	"encoding/json"
+	"legocerthub-backend/pkg/output"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// selfTestWait is how long the test handler waits for the test to complete
+// before returning the in progress result (this must be less than the server's
+// write timeout)
+const selfTestWait = 5 * time.Second
+
+// selfTestPayload is the payload to start a method self test
+type selfTestPayload struct {
+	Domain *string `json:"domain"`
+}
+
+// TestMethod starts a self test of the method's provider using the specified
+// domain. If the test completes quickly the final result is returned, otherwise
+// the in progress result is returned (status 202) and the result can be fetched
+// later using GetMethodTest.
+func (service *Service) TestMethod(w http.ResponseWriter, r *http.Request) (err error) {
+	methodValue := MethodValue(httprouter.ParamsFromContext(r.Context()).ByName("value"))
+
+	var payload selfTestPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	if payload.Domain == nil {
+		service.logger.Debug("self test missing domain")
+		return output.ErrValidationFailed
+	}
+
+	testId, done, err := service.StartSelfTest(methodValue, *payload.Domain)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	// wait for result
+	status := http.StatusOK
+	select {
+	case <-done:
+	case <-time.After(selfTestWait):
+		status = http.StatusAccepted
+	}
+
+	result, err := service.SelfTestResult(testId)
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrInternal
+	}
+
+	_, err = service.output.WriteJSON(w, status, result, "self_test")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// GetMethodTest returns the current result of a method self test
+func (service *Service) GetMethodTest(w http.ResponseWriter, r *http.Request) (err error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	result, err := service.SelfTestResult(params.ByName("testid"))
+	if err != nil || result.Method != MethodValue(params.ByName("value")) {
+		return output.ErrNotFound
+	}
+
+	_, err = service.output.WriteJSON(w, http.StatusOK, result, "self_test")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
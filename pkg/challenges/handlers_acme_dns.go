@@ -0,0 +1,187 @@
+package challenges
+
+import (
+	"encoding/json"
+	"errors"
+	"legocerthub-backend/pkg/challenges/providers/dns01acmedns"
+	"legocerthub-backend/pkg/output"
+	"legocerthub-backend/pkg/storage"
+	"legocerthub-backend/pkg/validation"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// acmeDnsRegistrationResponse is an automatic acme-dns registration (without
+// its credentials) and the CNAME record the user must create to use it
+type acmeDnsRegistrationResponse struct {
+	ID             int    `json:"id"`
+	AcmeDnsAddress string `json:"acme_dns_address"`
+	RealDomain     string `json:"real_domain"`
+	CNAMEName      string `json:"cname_name"`
+	CNAMETarget    string `json:"cname_target"`
+	CNAMEValid     bool   `json:"cname_valid"`
+	CreatedAt      int    `json:"created_at"`
+}
+
+// acmeDnsRegistrationPayload is the payload to register a domain before it is
+// used in an order
+type acmeDnsRegistrationPayload struct {
+	MethodValue *MethodValue `json:"method_value"`
+	Domain      *string      `json:"domain"`
+}
+
+// acmeDnsRegistrationResponse returns the registration's response, checking if
+// the CNAME record currently points to the registration
+func (service *Service) acmeDnsRegistrationResponse(reg AcmeDnsRegistration) acmeDnsRegistrationResponse {
+	response := acmeDnsRegistrationResponse{
+		ID:             reg.ID,
+		AcmeDnsAddress: reg.AcmeDnsAddress,
+		RealDomain:     reg.RealDomain,
+		CNAMEName:      "_acme-challenge." + reg.RealDomain,
+		CNAMETarget:    reg.FullDomain,
+		CreatedAt:      reg.CreatedAt,
+	}
+
+	if dnsChecker := service.getDnsChecker(); dnsChecker != nil {
+		target, err := dnsChecker.ResolveCNAME(response.CNAMEName)
+		if err != nil {
+			service.logger.Debugf("failed to resolve cname for %s (%s)", response.CNAMEName, err)
+		}
+		response.CNAMEValid = err == nil && strings.EqualFold(target, strings.TrimSuffix(reg.FullDomain, "."))
+	}
+
+	return response
+}
+
+// GetAllAcmeDnsRegistrations returns all of the automatic acme-dns registrations
+// and the CNAME record needed for each
+func (service *Service) GetAllAcmeDnsRegistrations(w http.ResponseWriter, r *http.Request) (err error) {
+	registrations, err := service.storage.GetAllAcmeDnsRegistrations()
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	// check the cnames concurrently
+	response := make([]acmeDnsRegistrationResponse, len(registrations))
+	var wg sync.WaitGroup
+	for i := range registrations {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response[i] = service.acmeDnsRegistrationResponse(registrations[i])
+		}(i)
+	}
+	wg.Wait()
+
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "acme_dns_registrations")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// PostNewAcmeDnsRegistration registers the domain with the method's acme-dns
+// server (if it isn't already registered) and returns the registration and the
+// CNAME record needed to use it
+func (service *Service) PostNewAcmeDnsRegistration(w http.ResponseWriter, r *http.Request) (err error) {
+	var payload acmeDnsRegistrationPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	// domain (a wildcard uses the same record as its apex)
+	if payload.Domain == nil || !validation.DomainValid(*payload.Domain, true) {
+		service.logger.Debug("missing or invalid domain")
+		return output.ErrValidationFailed
+	}
+	domain := strings.TrimPrefix(*payload.Domain, "*.")
+
+	// method must be an acme-dns provider with auto register
+	if payload.MethodValue == nil || payload.MethodValue.providerType() != methodValueDns01AcmeDns {
+		service.logger.Debug("missing or non acme-dns method value")
+		return output.ErrValidationFailed
+	}
+
+	release, err := service.useProvider(*payload.MethodValue)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+	defer release()
+
+	provider, err := service.provider(*payload.MethodValue)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+	acmeDns, ok := provider.(*dns01acmedns.Service)
+	if !ok || !acmeDns.AutoRegister() {
+		service.logger.Debugf("method %s does not have auto register enabled", *payload.MethodValue)
+		return output.Error{Status: http.StatusBadRequest, Message: "method does not have acme-dns auto register enabled"}
+	}
+
+	_, err = acmeDns.Register(domain)
+	if err != nil {
+		service.logger.Error(err)
+		return output.Error{Status: http.StatusBadGateway, Message: err.Error()}
+	}
+
+	registration, err := service.storage.GetAcmeDnsRegistration(acmeDns.AcmeDnsAddress(), domain)
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	_, err = service.output.WriteJSON(w, http.StatusCreated, service.acmeDnsRegistrationResponse(registration), "acme_dns_registration")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// DeleteAcmeDnsRegistration deletes an automatic acme-dns registration. The
+// account is not removed from the acme-dns server (acme-dns has no api for it).
+// If the domain is used again, it is registered again (and needs a new CNAME).
+func (service *Service) DeleteAcmeDnsRegistration(w http.ResponseWriter, r *http.Request) (err error) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	err = service.storage.DeleteAcmeDnsRegistration(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return output.ErrNotFound
+		}
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	response := output.JsonResponse{
+		Status:  http.StatusOK,
+		Message: "deleted",
+		ID:      id,
+	}
+
+	_, err = service.output.WriteJSON(w, response.Status, response, "response")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package challenges
+
+import (
+	"encoding/json"
+	"errors"
+	"legocerthub-backend/pkg/output"
+	"legocerthub-backend/pkg/storage"
+	"legocerthub-backend/pkg/validation"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// providerConfigPayload is the payload to create or update a stored provider config
+type providerConfigPayload struct {
+	MethodValue *MethodValue    `json:"method_value"`
+	Config      json.RawMessage `json:"config"`
+}
+
+// providerConfigOutputError converts errors from the provider config functions
+// into the appropriate output error (and logs them)
+func (service *Service) providerConfigOutputError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNoRecord):
+		service.logger.Debug(err)
+		return output.ErrNotFound
+
+	case errors.Is(err, ErrProviderInUse):
+		service.logger.Debug(err)
+		return output.Error{Status: http.StatusConflict, Message: err.Error()}
+
+	case errors.Is(err, errProviderConfigJs) || errors.Is(err, errProviderTypeNotManageable) || errors.Is(err, errInstanceNameInvalid):
+		service.logger.Debug(err)
+		return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+
+	default:
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+}
+
+// providerConfigIdParam returns the id from the request's params
+func (service *Service) providerConfigIdParam(r *http.Request) (int, error) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(err)
+		return -1, output.ErrValidationFailed
+	}
+
+	return id, nil
+}
+
+// GetAllProviderConfigs returns all of the stored provider configs (with secrets
+// redacted)
+func (service *Service) GetAllProviderConfigs(w http.ResponseWriter, r *http.Request) (err error) {
+	providerConfigs, err := service.storage.GetAllProviderConfigs()
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	response := []providerConfigResponse{}
+	for _, pc := range providerConfigs {
+		pcResponse, err := pc.response(service.cipher)
+		if err != nil {
+			service.logger.Error(err)
+			return output.ErrInternal
+		}
+		response = append(response, pcResponse)
+	}
+
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "challenge_providers")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// GetOneProviderConfig returns one stored provider config (with secrets redacted)
+func (service *Service) GetOneProviderConfig(w http.ResponseWriter, r *http.Request) (err error) {
+	id, err := service.providerConfigIdParam(r)
+	if err != nil {
+		return err
+	}
+
+	providerConfig, err := service.storage.GetOneProviderConfig(id)
+	if err != nil {
+		return service.providerConfigOutputError(err)
+	}
+
+	response, err := providerConfig.response(service.cipher)
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrInternal
+	}
+
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "challenge_provider")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// PostNewProviderConfig stores a new provider config and replaces the method's
+// provider with it
+func (service *Service) PostNewProviderConfig(w http.ResponseWriter, r *http.Request) (err error) {
+	var payload providerConfigPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	// method value
+	if payload.MethodValue == nil {
+		service.logger.Debug("missing method value")
+		return output.ErrValidationFailed
+	}
+	err = validManageableValue(*payload.MethodValue)
+	if err != nil {
+		return service.providerConfigOutputError(err)
+	}
+
+	// only one stored config per method
+	existing, err := service.storage.GetAllProviderConfigs()
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+	for _, pc := range existing {
+		if pc.MethodValue == *payload.MethodValue {
+			service.logger.Debugf("stored config for %s already exists", pc.MethodValue)
+			return output.Error{Status: http.StatusConflict, Message: "a stored config for this method already exists (update it instead)"}
+		}
+	}
+
+	id, err := service.createProviderConfig(*payload.MethodValue, payload.Config)
+	if err != nil {
+		var configErr *providerConfigError
+		if errors.As(err, &configErr) {
+			service.logger.Debug(err)
+			return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+		}
+		return service.providerConfigOutputError(err)
+	}
+
+	response := output.JsonResponse{
+		Status:  http.StatusCreated,
+		Message: "created",
+		ID:      id,
+	}
+
+	_, err = service.output.WriteJSON(w, response.Status, response, "response")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// PutProviderConfig updates a stored provider config and replaces the method's
+// provider with it. Redacted values in the config are kept unchanged.
+func (service *Service) PutProviderConfig(w http.ResponseWriter, r *http.Request) (err error) {
+	id, err := service.providerConfigIdParam(r)
+	if err != nil {
+		return err
+	}
+
+	var payload providerConfigPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	err = service.updateProviderConfig(id, payload.Config)
+	if err != nil {
+		var configErr *providerConfigError
+		if errors.As(err, &configErr) {
+			service.logger.Debug(err)
+			return output.Error{Status: http.StatusBadRequest, Message: err.Error()}
+		}
+		return service.providerConfigOutputError(err)
+	}
+
+	response := output.JsonResponse{
+		Status:  http.StatusOK,
+		Message: "updated",
+		ID:      id,
+	}
+
+	_, err = service.output.WriteJSON(w, response.Status, response, "response")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// DeleteProviderConfig deletes a stored provider config and reverts the method's
+// provider to the config file's config
+func (service *Service) DeleteProviderConfig(w http.ResponseWriter, r *http.Request) (err error) {
+	id, err := service.providerConfigIdParam(r)
+	if err != nil {
+		return err
+	}
+
+	err = service.deleteProviderConfig(id)
+	if err != nil {
+		return service.providerConfigOutputError(err)
+	}
+
+	response := output.JsonResponse{
+		Status:  http.StatusOK,
+		Message: "deleted",
+		ID:      id,
+	}
+
+	_, err = service.output.WriteJSON(w, response.Status, response, "response")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
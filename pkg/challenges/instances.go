@@ -0,0 +1,123 @@
+package challenges
+
+import (
+	"errors"
+	"fmt"
+	"legocerthub-backend/pkg/validation"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Named provider instances allow more than one provider of the same type (e.g.
+// acme.sh with two different dns hooks). An instance's method value is the
+// provider type's value and the instance name, separated by a colon (e.g.
+// dns-01-acme-sh:route-a).
+
+const instanceSeparator = ":"
+
+var (
+	errInstanceNameInvalid = errors.New("provider instance name is invalid (allowed symbols: - _ . ~ letters and numbers)")
+	errDuplicateInstance   = errors.New("provider instance is configured more than once")
+)
+
+// ConfigProviderInstance is the config of one named provider instance. Config
+// is the same as the config for the provider type in ConfigProviders.
+type ConfigProviderInstance struct {
+	Name   string      `yaml:"name"`
+	Type   MethodValue `yaml:"type"`
+	Config yaml.Node   `yaml:"config"`
+}
+
+// value returns the instance's method value
+func (instance *ConfigProviderInstance) value() MethodValue {
+	return instance.Type + instanceSeparator + MethodValue(instance.Name)
+}
+
+// providerType returns the value of the provider type of the method value (i.e.
+// without the instance name)
+func (value MethodValue) providerType() MethodValue {
+	providerType, _, _ := strings.Cut(string(value), instanceSeparator)
+	return MethodValue(providerType)
+}
+
+// instanceName returns the instance name of the method value, or blank if the
+// method value is not a named instance
+func (value MethodValue) instanceName() string {
+	_, name, _ := strings.Cut(string(value), instanceSeparator)
+	return name
+}
+
+// validManageableValue returns an error if the method value is not a provider
+// type that can be configured at runtime, or if it has an invalid instance name
+func validManageableValue(value MethodValue) error {
+	if _, ok := storedProviderTypes[value.providerType()]; !ok {
+		return errProviderTypeNotManageable
+	}
+
+	if strings.Contains(string(value), instanceSeparator) && !validation.NameValid(value.instanceName()) {
+		return errInstanceNameInvalid
+	}
+
+	return nil
+}
+
+// instanceMethods returns a method for each named instance in providers. Each
+// instance's method copies its provider type's method.
+func instanceMethods(typeMethods []Method, providers map[MethodValue]providerService) (methods []Method) {
+	for value := range providers {
+		if value.instanceName() == "" {
+			continue
+		}
+
+		for _, typeMethod := range typeMethods {
+			if typeMethod.Value == value.providerType() {
+				methods = append(methods, Method{
+					Value:         value,
+					Name:          fmt.Sprintf("%s (%s)", typeMethod.Name, value.instanceName()),
+					Enabled:       true,
+					ChallengeType: typeMethod.ChallengeType,
+				})
+				break
+			}
+		}
+	}
+
+	// map order is random, keep output consistent
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Value < methods[j].Value
+	})
+
+	return methods
+}
+
+// configureFileInstances creates the named provider instances from the config
+// file and adds them to the providers
+func (service *Service) configureFileInstances() error {
+	configured := make(map[MethodValue]struct{})
+
+	for i := range service.fileProviders.Instances {
+		instance := &service.fileProviders.Instances[i]
+		value := instance.value()
+
+		err := validManageableValue(value)
+		if err != nil {
+			return fmt.Errorf("provider instance %s: %w", value, err)
+		}
+		if _, exists := configured[value]; exists {
+			return fmt.Errorf("provider instance %s: %w", value, errDuplicateInstance)
+		}
+		configured[value] = struct{}{}
+
+		provider, err := service.newFileProvider(value)
+		if err != nil {
+			return fmt.Errorf("provider instance %s: %w", value, err)
+		}
+		if provider != nil {
+			service.providers[value] = provider
+		}
+	}
+
+	return nil
+}
@@ -26,12 +26,16 @@ type Method struct {
 // Define values. These values should be assigned once and NEVER
 // changed to avoid storage issues.
 const (
-	unknownMethodValue         MethodValue = ""
-	methodValueHttp01Internal  MethodValue = "http-01-internal"
-	methodValueDns01Manual     MethodValue = "dns-01-manual"
-	methodValueDns01AcmeDns    MethodValue = "dns-01-acme-dns"
-	methodValueDns01AcmeSh     MethodValue = "dns-01-acme-sh"
-	methodValueDns01Cloudflare MethodValue = "dns-01-cloudflare"
+	unknownMethodValue           MethodValue = ""
+	methodValueHttp01Internal    MethodValue = "http-01-internal"
+	methodValueDns01Manual       MethodValue = "dns-01-manual"
+	methodValueDns01AcmeDns      MethodValue = "dns-01-acme-dns"
+	methodValueDns01AcmeSh       MethodValue = "dns-01-acme-sh"
+	methodValueDns01Cloudflare   MethodValue = "dns-01-cloudflare"
+	methodValueTlsAlpn01Internal MethodValue = "tls-alpn-01-internal"
+	methodValueDns01Rfc2136      MethodValue = "dns-01-rfc2136"
+	methodValueDns01Webhook      MethodValue = "dns-01-webhook"
+	methodValueHttp01Webroot     MethodValue = "http-01-webroot"
 )
 
 // UnknownMethod is used when a Method does not match any known Method.
@@ -41,9 +45,10 @@ var UnknownMethod = Method{
 	ChallengeType: acme.UnknownChallengeType,
 }
 
-// Configure the service's methods by loading them and determining
-// if they're currently enabled.
-func (service *Service) configureMethods() error {
+// buildMethods returns all of the defined methods, with Enabled set according to
+// which of the providers are configured. Named provider instances are appended
+// after the defined methods.
+func buildMethods(providers map[MethodValue]providerService) []Method {
 
 	// methods contains the details corresponding to all of the defined
 	// methodValues
@@ -79,25 +84,52 @@ func (service *Service) configureMethods() error {
 			Name:          "DNS Cloudflare",
 			ChallengeType: acme.ChallengeTypeDns01,
 		},
+		{
+			// serve the tls-alpn validation cert from an internal tls server
+			Value:         methodValueTlsAlpn01Internal,
+			Name:          "TLS-ALPN on API Server",
+			ChallengeType: acme.ChallengeTypeTlsAlpn01,
+		},
+		{
+			// send rfc2136 dynamic updates to a primary nameserver
+			Value:         methodValueDns01Rfc2136,
+			Name:          "DNS RFC 2136",
+			ChallengeType: acme.ChallengeTypeDns01,
+		},
+		{
+			// post dns record changes to a webhook
+			Value:         methodValueDns01Webhook,
+			Name:          "DNS Webhook",
+			ChallengeType: acme.ChallengeTypeDns01,
+		},
+		{
+			// write the http record to a web server's webroot
+			Value:         methodValueHttp01Webroot,
+			Name:          "HTTP Webroot",
+			ChallengeType: acme.ChallengeTypeHttp01,
+		},
 	}
 
 	// range through MethodDetailed to set the Enabled field according
-	// to the Service configuration & confirm at least one method is actually
-	// enabled.
-	atLeastOneEnabled := false
+	// to the providers
 	for i := range methodsList {
-		if _, ok := service.providers[methodsList[i].Value]; ok {
+		if _, ok := providers[methodsList[i].Value]; ok {
 			methodsList[i].Enabled = true
-			atLeastOneEnabled = true
 		}
 	}
-	if !atLeastOneEnabled {
-		return errNoProviders
-	}
 
-	service.methods = methodsList
+	return append(methodsList, instanceMethods(methodsList, providers)...)
+}
 
-	return nil
+// anyMethodEnabled returns true if at least one of the methods is enabled
+func anyMethodEnabled(methods []Method) bool {
+	for i := range methods {
+		if methods[i].Enabled {
+			return true
+		}
+	}
+
+	return false
 }
 
 // validationResource creates the resource name and content that are required
@@ -106,18 +138,44 @@ func (method Method) validationResource(identifier acme.Identifier, key acme.Acc
 	return method.ChallengeType.ValidationResource(identifier, key, token)
 }
 
+// IdentifierError returns an error explaining why the method can't be used to
+// validate the specified identifier value. If the method can be used, nil is
+// returned.
+func (method Method) IdentifierError(value string) error {
+	return method.ChallengeType.CheckIdentifierValue(value)
+}
+
+// EnabledMethodsForIdentifier returns the values of all of the enabled methods
+// that can be used to validate the specified identifier value
+func (service *Service) EnabledMethodsForIdentifier(value string) (methodValues []MethodValue) {
+	methodValues = []MethodValue{}
+
+	for _, method := range service.ListOfMethods() {
+		if method.Enabled && method.IdentifierError(value) == nil {
+			methodValues = append(methodValues, method.Value)
+		}
+	}
+
+	return methodValues
+}
+
 // ListOfMethods() returns a slice of challenge methods as currently
 // configured (i.e. with enabled/disabled status)
 func (service *Service) ListOfMethods() (methods []Method) {
+	service.providersMu.RLock()
+	defer service.providersMu.RUnlock()
+
+	// the slice is replaced (never modified) when providers change, so it is
+	// safe to return
 	return service.methods
 }
 
 // MethodByValue returns a challenge method based on its Value.
 // If a method isn't found, UnknownMethod is returned.
 func (service *Service) MethodByStorageValue(value MethodValue) Method {
-	for i := range service.methods {
-		if value == service.methods[i].Value {
-			return service.methods[i]
+	for _, method := range service.ListOfMethods() {
+		if value == method.Value {
+			return method
 		}
 	}
 
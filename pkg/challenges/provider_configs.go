@@ -0,0 +1,469 @@
+package challenges
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"legocerthub-backend/pkg/encryption"
+	"strings"
+	"time"
+)
+
+// Challenge providers can be configured at runtime. Stored provider configs are
+// saved in storage and take the place of the config file's config for the same
+// provider. Removing a stored config reverts that provider to the config file.
+// Secret config values are encrypted in storage.
+
+var (
+	ErrProviderInUse    = errors.New("challenge provider is currently in use, try again later")
+	errProviderConfigJs = errors.New("challenge provider config must be a json object")
+	errSecretNotDecoded = errors.New("challenge provider config secret failed to decode")
+)
+
+// providerConfigError is returned when a provider config is invalid
+type providerConfigError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *providerConfigError) Error() string {
+	return fmt.Sprintf("invalid provider config (%s)", e.err)
+}
+
+// redactedValue replaces secret config values in responses
+const redactedValue = "[redacted]"
+
+// encryptedValuePrefix prefixes secret config values that are encrypted (the
+// encrypted plaintext is the value's json)
+const encryptedValuePrefix = "encrypted:"
+
+// secretConfigKeys are provider config keys whose values are secret
+var secretConfigKeys = map[string]struct{}{
+	"password":       {},
+	"global_api_key": {},
+	"api_token":      {},
+	"tsig_secret":    {},
+	"headers":        {},
+	"environment":    {},
+}
+
+// ProviderConfig is a challenge provider config that is stored in storage
+type ProviderConfig struct {
+	ID          int
+	MethodValue MethodValue
+	Config      json.RawMessage // secret values are encrypted
+	CreatedAt   int
+	UpdatedAt   int
+}
+
+// providerConfigResponse is the client response for a ProviderConfig
+type providerConfigResponse struct {
+	ID          int         `json:"id"`
+	MethodValue MethodValue `json:"method_value"`
+	Config      any         `json:"config"`
+	CreatedAt   int         `json:"created_at"`
+	UpdatedAt   int         `json:"updated_at"`
+}
+
+// response returns the client response for the (stored) ProviderConfig with
+// secrets redacted
+func (pc ProviderConfig) response(cipher *encryption.Cipher) (providerConfigResponse, error) {
+	// decrypt first so redacted values keep their shape (e.g. a map of headers)
+	decrypted, err := decryptConfigSecrets(cipher, pc.Config)
+	if err != nil {
+		return providerConfigResponse{}, err
+	}
+
+	var config any
+	err = json.Unmarshal(decrypted, &config)
+	if err != nil {
+		return providerConfigResponse{}, err
+	}
+
+	return providerConfigResponse{
+		ID:          pc.ID,
+		MethodValue: pc.MethodValue,
+		Config:      redactConfig(config, false),
+		CreatedAt:   pc.CreatedAt,
+		UpdatedAt:   pc.UpdatedAt,
+	}, nil
+}
+
+// redactConfig returns a copy of the config with the values of all secret keys
+// replaced by redactedValue
+func redactConfig(config any, secret bool) any {
+	switch c := config.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(c))
+		for key, value := range c {
+			_, isSecret := secretConfigKeys[key]
+			redacted[key] = redactConfig(value, secret || isSecret)
+		}
+		return redacted
+
+	case []any:
+		redacted := make([]any, len(c))
+		for i := range c {
+			redacted[i] = redactConfig(c[i], secret)
+		}
+		return redacted
+
+	default:
+		if secret && c != nil {
+			return redactedValue
+		}
+		return c
+	}
+}
+
+// mapConfigSecrets returns a copy of config where the value of each secret key is
+// replaced by the result of calling fn on it
+func mapConfigSecrets(config any, fn func(value any) (any, error)) (any, error) {
+	switch c := config.(type) {
+	case map[string]any:
+		mapped := make(map[string]any, len(c))
+		for key, value := range c {
+			var err error
+			if _, isSecret := secretConfigKeys[key]; isSecret && value != nil {
+				mapped[key], err = fn(value)
+			} else {
+				mapped[key], err = mapConfigSecrets(value, fn)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return mapped, nil
+
+	case []any:
+		mapped := make([]any, len(c))
+		for i := range c {
+			var err error
+			mapped[i], err = mapConfigSecrets(c[i], fn)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return mapped, nil
+
+	default:
+		return c, nil
+	}
+}
+
+// encryptConfigSecrets returns a copy of the (plaintext) config with the value of
+// each secret key encrypted so secrets are never saved to storage in plaintext
+func encryptConfigSecrets(cipher *encryption.Cipher, config json.RawMessage) (json.RawMessage, error) {
+	var obj any
+	err := json.Unmarshal(config, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := mapConfigSecrets(obj, func(value any) (any, error) {
+		valueJs, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		ciphertext, err := cipher.EncryptString(string(valueJs))
+		if err != nil {
+			return nil, err
+		}
+
+		return encryptedValuePrefix + ciphertext, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encrypted)
+}
+
+// decryptConfigSecrets returns a copy of the config with each encrypted secret
+// value decrypted. Secret values that are not encrypted are returned as is.
+func decryptConfigSecrets(cipher *encryption.Cipher, config json.RawMessage) (json.RawMessage, error) {
+	var obj any
+	err := json.Unmarshal(config, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := mapConfigSecrets(obj, func(value any) (any, error) {
+		encrypted, ok := value.(string)
+		if !ok || !strings.HasPrefix(encrypted, encryptedValuePrefix) {
+			return value, nil
+		}
+
+		valueJs, err := cipher.DecryptString(strings.TrimPrefix(encrypted, encryptedValuePrefix))
+		if err != nil {
+			return nil, err
+		}
+
+		var plaintext any
+		err = json.Unmarshal([]byte(valueJs), &plaintext)
+		if err != nil {
+			return nil, errSecretNotDecoded
+		}
+
+		return plaintext, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(decrypted)
+}
+
+// unredactConfig returns a copy of newConfig where any value that is redactedValue
+// is replaced by the value at the same location in oldConfig. This allows clients
+// to send back a redacted config with only non-secret changes. An error is
+// returned if a redacted value has no corresponding old value.
+func unredactConfig(newConfig any, oldConfig any) (any, error) {
+	switch c := newConfig.(type) {
+	case map[string]any:
+		oldMap, _ := oldConfig.(map[string]any)
+		unredacted := make(map[string]any, len(c))
+		for key, value := range c {
+			var err error
+			unredacted[key], err = unredactConfig(value, oldMap[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return unredacted, nil
+
+	case []any:
+		oldSlice, _ := oldConfig.([]any)
+		unredacted := make([]any, len(c))
+		for i := range c {
+			var old any
+			if i < len(oldSlice) {
+				old = oldSlice[i]
+			}
+
+			var err error
+			unredacted[i], err = unredactConfig(c[i], old)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return unredacted, nil
+
+	case string:
+		if c == redactedValue {
+			oldString, ok := oldConfig.(string)
+			if !ok {
+				return nil, &providerConfigError{errors.New("redacted value does not have an existing value")}
+			}
+			return oldString, nil
+		}
+		return c, nil
+
+	default:
+		return c, nil
+	}
+}
+
+// ConfigureStorage sets the service's storage and loads any stored provider
+// configs (replacing the config file's config for those providers). At least one
+// method must be enabled once stored configs are loaded. Any orphaned challenge
+// resources are then swept.
+func (service *Service) ConfigureStorage(storage Storage) error {
+	if storage == nil {
+		return errServiceComponent
+	}
+	service.storage = storage
+
+	providerConfigs, err := storage.GetAllProviderConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, pc := range providerConfigs {
+		provider, err := service.newStoredProvider(pc.MethodValue, pc.Config)
+		if err != nil {
+			// don't fail start, the config file's provider (if any) remains in use
+			service.logger.Errorf("failed to configure stored challenge provider %s (id: %d) (%s)", pc.MethodValue, pc.ID, err)
+			continue
+		}
+
+		err = service.swapProvider(pc.MethodValue, provider, nil)
+		if err != nil {
+			return err
+		}
+		service.logger.Infof("configured stored challenge provider %s (id: %d)", pc.MethodValue, pc.ID)
+	}
+
+	if !anyMethodEnabled(service.ListOfMethods()) {
+		return errNoProviders
+	}
+
+	// clean up any resources left behind (e.g. by a crash)
+	err = service.sweepResources()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// swapProvider replaces the method's provider with provider (nil removes it) and
+// rebuilds the methods. If persist is not nil, it is called before the swap and the
+// swap is aborted if it errors. Providers that are in use can't be swapped.
+func (service *Service) swapProvider(methodValue MethodValue, provider providerService, persist func() error) error {
+	service.providersMu.Lock()
+	defer service.providersMu.Unlock()
+
+	if service.providersInUse[methodValue] > 0 {
+		return ErrProviderInUse
+	}
+
+	// replace the map (instead of modifying it) so it is never modified while
+	// a reader holds it
+	providers := make(map[MethodValue]providerService, len(service.providers))
+	for value, p := range service.providers {
+		providers[value] = p
+	}
+	if provider == nil {
+		delete(providers, methodValue)
+	} else {
+		providers[methodValue] = provider
+	}
+	methods := buildMethods(providers)
+
+	// dns checker may be needed now, configure it before persisting so a saved
+	// provider is never left without a checker
+	err := service.configureDnsCheckerLocked(methods)
+	if err != nil {
+		return err
+	}
+
+	if persist != nil {
+		err = persist()
+		if err != nil {
+			return err
+		}
+	}
+
+	service.providers = providers
+	service.methods = methods
+
+	return nil
+}
+
+// normalizeProviderConfig confirms the config is a json object and returns it
+// compacted
+func normalizeProviderConfig(config json.RawMessage) (json.RawMessage, error) {
+	var obj map[string]any
+	err := json.Unmarshal(config, &obj)
+	if err != nil || obj == nil {
+		return nil, errProviderConfigJs
+	}
+
+	return json.Marshal(obj)
+}
+
+// createProviderConfig validates the config by creating the provider, then saves
+// it and puts the provider in use
+func (service *Service) createProviderConfig(methodValue MethodValue, config json.RawMessage) (id int, err error) {
+	config, err = normalizeProviderConfig(config)
+	if err != nil {
+		return -1, err
+	}
+
+	provider, err := service.newStoredProvider(methodValue, config)
+	if err != nil {
+		return -1, &providerConfigError{err}
+	}
+
+	encrypted, err := encryptConfigSecrets(service.cipher, config)
+	if err != nil {
+		return -1, err
+	}
+
+	now := int(time.Now().Unix())
+	err = service.swapProvider(methodValue, provider, func() (err error) {
+		id, err = service.storage.PostNewProviderConfig(ProviderConfig{
+			MethodValue: methodValue,
+			Config:      encrypted,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// updateProviderConfig replaces the stored config (restoring any redacted values),
+// then replaces the provider
+func (service *Service) updateProviderConfig(id int, config json.RawMessage) (err error) {
+	existing, err := service.storage.GetOneProviderConfig(id)
+	if err != nil {
+		return err
+	}
+
+	// restore redacted secrets from existing config
+	var newConfig, oldConfig any
+	err = json.Unmarshal(config, &newConfig)
+	if err != nil {
+		return errProviderConfigJs
+	}
+	existingConfig, err := decryptConfigSecrets(service.cipher, existing.Config)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(existingConfig, &oldConfig)
+	if err != nil {
+		return err
+	}
+	newConfig, err = unredactConfig(newConfig, oldConfig)
+	if err != nil {
+		return err
+	}
+	config, err = json.Marshal(newConfig)
+	if err != nil {
+		return err
+	}
+	config, err = normalizeProviderConfig(config)
+	if err != nil {
+		return err
+	}
+
+	provider, err := service.newStoredProvider(existing.MethodValue, config)
+	if err != nil {
+		return &providerConfigError{err}
+	}
+
+	existing.Config, err = encryptConfigSecrets(service.cipher, config)
+	if err != nil {
+		return err
+	}
+	existing.UpdatedAt = int(time.Now().Unix())
+
+	return service.swapProvider(existing.MethodValue, provider, func() error {
+		return service.storage.PutProviderConfig(existing)
+	})
+}
+
+// deleteProviderConfig removes the stored config and reverts the provider to
+// the config file's config
+func (service *Service) deleteProviderConfig(id int) (err error) {
+	existing, err := service.storage.GetOneProviderConfig(id)
+	if err != nil {
+		return err
+	}
+
+	provider, err := service.newFileProvider(existing.MethodValue)
+	if err != nil {
+		return err
+	}
+
+	return service.swapProvider(existing.MethodValue, provider, func() error {
+		return service.storage.DeleteProviderConfig(id)
+	})
+}
@@ -0,0 +1,96 @@
+package challenges
+
+import (
+	"encoding/json"
+	"legocerthub-backend/pkg/encryption"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestCipher(t *testing.T) *encryption.Cipher {
+	t.Helper()
+
+	cipher, err := encryption.New(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %s", err)
+	}
+
+	return cipher
+}
+
+// TestConfigSecretsRoundTrip confirms secret config values are not stored in
+// plaintext and decrypt back to the original config
+func TestConfigSecretsRoundTrip(t *testing.T) {
+	cipher := newTestCipher(t)
+
+	config := json.RawMessage(`{
+		"url": "https://dns.example.com/hook",
+		"api_token": "token-secret",
+		"headers": {"Authorization": "Bearer header-secret"},
+		"environment": ["API_KEY=env-secret"],
+		"timeout_seconds": 10
+	}`)
+
+	encrypted, err := encryptConfigSecrets(cipher, config)
+	if err != nil {
+		t.Fatalf("failed to encrypt config: %s", err)
+	}
+
+	for _, secret := range []string{"token-secret", "header-secret", "env-secret", "Authorization"} {
+		if strings.Contains(string(encrypted), secret) {
+			t.Errorf("encrypted config contains plaintext %q: %s", secret, encrypted)
+		}
+	}
+
+	var encryptedObj map[string]any
+	err = json.Unmarshal(encrypted, &encryptedObj)
+	if err != nil {
+		t.Fatalf("encrypted config is not json: %s", err)
+	}
+	if encryptedObj["url"] != "https://dns.example.com/hook" || encryptedObj["timeout_seconds"] != float64(10) {
+		t.Errorf("non-secret values changed: %s", encrypted)
+	}
+
+	decrypted, err := decryptConfigSecrets(cipher, encrypted)
+	if err != nil {
+		t.Fatalf("failed to decrypt config: %s", err)
+	}
+
+	var want, got any
+	_ = json.Unmarshal(config, &want)
+	_ = json.Unmarshal(decrypted, &got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decrypted config: got %s, want %s", decrypted, config)
+	}
+
+	// redacted responses keep the shape of the decrypted values
+	response, err := ProviderConfig{Config: encrypted}.response(cipher)
+	if err != nil {
+		t.Fatalf("failed to make response: %s", err)
+	}
+	redacted := response.Config.(map[string]any)
+	if !reflect.DeepEqual(redacted["headers"], map[string]any{"Authorization": redactedValue}) {
+		t.Errorf("redacted headers: got %v", redacted["headers"])
+	}
+}
+
+// TestDecryptConfigSecretsPlaintext confirms a config without encrypted values is
+// returned as is and a tampered value fails to decrypt
+func TestDecryptConfigSecretsPlaintext(t *testing.T) {
+	cipher := newTestCipher(t)
+
+	config := json.RawMessage(`{"api_token":"token-secret","url":"https://dns.example.com/hook"}`)
+	decrypted, err := decryptConfigSecrets(cipher, config)
+	if err != nil {
+		t.Fatalf("failed to decrypt plaintext config: %s", err)
+	}
+	if string(decrypted) != string(config) {
+		t.Errorf("plaintext config: got %s, want %s", decrypted, config)
+	}
+
+	_, err = decryptConfigSecrets(cipher, json.RawMessage(`{"api_token":"`+encryptedValuePrefix+`dGFtcGVyZWQtdmFsdWUtdGhhdC1pcy1sb25n"}`))
+	if err == nil {
+		t.Error("tampered secret decrypted without error")
+	}
+}
@@ -0,0 +1,211 @@
+package dns01acmedns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"legocerthub-backend/pkg/storage"
+	"net/http"
+	"strings"
+)
+
+// When auto_register is enabled, domains that are not in the configured
+// resources are registered with the acme-dns server automatically. The new
+// registration is saved (via the Registrar) and the domain can't be used until
+// the user creates a CNAME record pointing the domain's _acme-challenge name to
+// the registration's full domain.
+
+var (
+	ErrRegisterFailed = errors.New("dns01acmedns failed to register new domain")
+	errNoRegistrar    = errors.New("dns01acmedns registration storage is not available")
+)
+
+// acme-dns register endpoint
+const acmeDnsRegisterEndpoint = "/register"
+
+// dns-01 resource name prefix
+const acmeChallengePrefix = "_acme-challenge."
+
+// Registration is an acme-dns account that was registered automatically for
+// RealDomain
+type Registration struct {
+	RealDomain string
+	FullDomain string
+	Username   string
+	Password   string
+}
+
+// Registrar saves and loads automatic registrations and resolves the CNAME
+// records that point to them. GetAcmeDnsRegistration returns storage.ErrNoRecord
+// if the domain has not been registered with the acme-dns server.
+type Registrar interface {
+	GetAcmeDnsRegistration(acmeDnsAddress string, realDomain string) (Registration, error)
+	SaveAcmeDnsRegistration(acmeDnsAddress string, registration Registration) error
+	ResolveCNAME(fqdn string) (target string, err error)
+}
+
+// CNAMERequiredError is returned when a domain's acme-dns registration can't be
+// used because the domain's CNAME record doesn't point to the registration (yet)
+type CNAMERequiredError struct {
+	Name   string
+	Target string
+}
+
+func (e *CNAMERequiredError) Error() string {
+	return fmt.Sprintf("dns01acmedns: domain is registered but can't be used until the dns record '%s CNAME %s' is created (and resolves)", e.Name, e.Target)
+}
+
+// registerResponse is the acme-dns response to a new registration
+type registerResponse struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	FullDomain string `json:"fulldomain"`
+	SubDomain  string `json:"subdomain"`
+}
+
+// cnameRequired returns the error describing the CNAME record needed for the
+// registration
+func (reg Registration) cnameRequired() *CNAMERequiredError {
+	return &CNAMERequiredError{
+		Name:   acmeChallengePrefix + reg.RealDomain,
+		Target: reg.FullDomain,
+	}
+}
+
+// AutoRegister returns true if the service registers unknown domains
+func (service *Service) AutoRegister() bool {
+	return service.autoRegister
+}
+
+// AcmeDnsAddress returns the address of the service's acme-dns server
+func (service *Service) AcmeDnsAddress() string {
+	return service.acmeDnsAddress
+}
+
+// Register returns the realDomain's registration, registering the domain with
+// the acme-dns server first if it isn't already registered
+func (service *Service) Register(realDomain string) (Registration, error) {
+	if !service.autoRegister {
+		return Registration{}, ErrDomainNotConfigured
+	}
+
+	reg, _, err := service.registration(realDomain, true)
+	return reg, err
+}
+
+// registration returns the saved registration for realDomain. If there isn't one
+// and register is true, the domain is registered with the acme-dns server and the
+// new registration is saved (and created is true).
+func (service *Service) registration(realDomain string, register bool) (reg Registration, created bool, err error) {
+	// lock so concurrent challenges for the same domain (e.g. a wildcard and its
+	// apex) don't each register it
+	service.registerMu.Lock()
+	defer service.registerMu.Unlock()
+
+	reg, err = service.registrar.GetAcmeDnsRegistration(service.acmeDnsAddress, realDomain)
+	if err == nil {
+		return reg, false, nil
+	}
+	if !errors.Is(err, storage.ErrNoRecord) || !register {
+		return Registration{}, false, err
+	}
+
+	reg, err = service.register(realDomain)
+	if err != nil {
+		return Registration{}, false, err
+	}
+
+	err = service.registrar.SaveAcmeDnsRegistration(service.acmeDnsAddress, reg)
+	if err != nil {
+		return Registration{}, false, err
+	}
+
+	service.logger.Infof("dns01acmedns registered %s (create the dns record '%s CNAME %s')", realDomain, acmeChallengePrefix+realDomain, reg.FullDomain)
+
+	return reg, true, nil
+}
+
+// register registers a new account with the acme-dns server for realDomain
+func (service *Service) register(realDomain string) (Registration, error) {
+	req, err := service.httpClient.NewRequest(http.MethodPost, service.acmeDnsAddress+acmeDnsRegisterEndpoint, nil)
+	if err != nil {
+		return Registration{}, err
+	}
+
+	resp, err := service.httpClient.Do(req)
+	if err != nil {
+		return Registration{}, err
+	}
+	defer resp.Body.Close()
+
+	// acme-dns responds 201 to a new registration
+	if resp.StatusCode != http.StatusCreated {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Registration{}, fmt.Errorf("%w (status %d)", ErrRegisterFailed, resp.StatusCode)
+	}
+
+	var response registerResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return Registration{}, fmt.Errorf("%w (%s)", ErrRegisterFailed, err)
+	}
+	if response.Username == "" || response.Password == "" || response.FullDomain == "" {
+		return Registration{}, fmt.Errorf("%w (incomplete response)", ErrRegisterFailed)
+	}
+
+	return Registration{
+		RealDomain: realDomain,
+		FullDomain: response.FullDomain,
+		Username:   response.Username,
+		Password:   response.Password,
+	}, nil
+}
+
+// findResource returns the acme-dns resource for the resourceName. Configured
+// resources are used first. If the resource isn't configured and auto register
+// is enabled, the saved registration is used. When provisioning, an unregistered
+// domain is registered and a registration is only used once its CNAME resolves
+// (otherwise a CNAMERequiredError is returned).
+func (service *Service) findResource(resourceName string, provisioning bool) (*acmeDnsResource, error) {
+	for i := range service.acmeDnsResources {
+		if acmeChallengePrefix+service.acmeDnsResources[i].RealDomain == resourceName {
+			return &service.acmeDnsResources[i], nil
+		}
+	}
+
+	if !service.autoRegister || !strings.HasPrefix(resourceName, acmeChallengePrefix) {
+		return nil, ErrDomainNotConfigured
+	}
+	realDomain := strings.TrimPrefix(resourceName, acmeChallengePrefix)
+
+	reg, created, err := service.registration(realDomain, provisioning)
+	if errors.Is(err, storage.ErrNoRecord) {
+		return nil, ErrDomainNotConfigured
+	} else if err != nil {
+		return nil, err
+	}
+
+	// a new registration can't have a CNAME yet
+	if created {
+		return nil, reg.cnameRequired()
+	}
+
+	// confirm delegation before using the registration
+	if provisioning {
+		target, err := service.registrar.ResolveCNAME(resourceName)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s)", reg.cnameRequired(), err)
+		}
+		if !strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(reg.FullDomain, ".")) {
+			return nil, reg.cnameRequired()
+		}
+	}
+
+	return &acmeDnsResource{
+		RealDomain: reg.RealDomain,
+		FullDomain: reg.FullDomain,
+		Username:   reg.Username,
+		Password:   reg.Password,
+	}, nil
+}
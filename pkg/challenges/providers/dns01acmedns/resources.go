@@ -68,24 +68,14 @@ func (service *Service) updateRequest(adr *acmeDnsResource, resourceContent stri
 
 // Provision updates the acme-dns resource record with the correct content
 func (service *Service) Provision(resourceName string, resourceContent string) error {
-	// check if resource exists
-	adr := acmeDnsResource{}
-	found := false
-	for _, r := range service.acmeDnsResources {
-		if "_acme-challenge."+r.RealDomain == resourceName {
-			adr = r
-			found = true
-			break
-		}
-	}
-	// this package does not support registering new records, so fail if
-	// record was not found
-	if !found {
-		return ErrDomainNotConfigured
+	// find resource (registering it if auto register is enabled)
+	adr, err := service.findResource(resourceName, true)
+	if err != nil {
+		return err
 	}
 
 	// make request
-	req, err := service.updateRequest(&adr, resourceContent)
+	req, err := service.updateRequest(adr, resourceContent)
 	if err != nil {
 		return err
 	}
@@ -111,24 +101,14 @@ func (service *Service) Provision(resourceName string, resourceContent string) e
 // isn't really needed and this could be an empty stub. Clearing the data doesn't
 // hurt though.
 func (service *Service) Deprovision(resourceName string, resourceContent string) error {
-	// check if resource exists
-	adr := acmeDnsResource{}
-	found := false
-	for _, r := range service.acmeDnsResources {
-		if "_acme-challenge."+r.RealDomain == resourceName {
-			adr = r
-			found = true
-			break
-		}
-	}
-	// this package does not support registering new records, so fail if
-	// record was not found
-	if !found {
-		return ErrDomainNotConfigured
+	// find resource
+	adr, err := service.findResource(resourceName, false)
+	if err != nil {
+		return err
 	}
 
 	// make request (dummy text value when not in use)
-	req, err := service.updateRequest(&adr, "VOID_____VOID______VOID_______VOID_____VOID")
+	req, err := service.updateRequest(adr, "VOID_____VOID______VOID_______VOID_____VOID")
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package dns01acmedns
 import (
 	"errors"
 	"legocerthub-backend/pkg/httpclient"
+	"sync"
 
 	"go.uber.org/zap"
 )
@@ -15,6 +16,7 @@ var (
 type App interface {
 	GetLogger() *zap.SugaredLogger
 	GetHttpClient() *httpclient.Client
+	GetAcmeDnsRegistrar() Registrar
 }
 
 // Accounts service struct
@@ -23,6 +25,9 @@ type Service struct {
 	httpClient       *httpclient.Client
 	acmeDnsAddress   string
 	acmeDnsResources []acmeDnsResource
+	autoRegister     bool
+	registrar        Registrar
+	registerMu       sync.Mutex
 }
 
 // Configuration options
@@ -30,6 +35,8 @@ type Config struct {
 	Enable      *bool             `yaml:"enable"`
 	HostAddress *string           `yaml:"acme_dns_address"`
 	Resources   []acmeDnsResource `yaml:"resources"`
+	// register domains that aren't in Resources with the acme-dns server
+	AutoRegister *bool `yaml:"auto_register"`
 }
 
 // NewService creates a new service
@@ -59,5 +66,14 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	// acme-dns resources that will be updated
 	service.acmeDnsResources = cfg.Resources
 
+	// automatic registration of other domains
+	service.autoRegister = cfg.AutoRegister != nil && *cfg.AutoRegister
+	if service.autoRegister {
+		service.registrar = app.GetAcmeDnsRegistrar()
+		if service.registrar == nil {
+			return nil, errNoRegistrar
+		}
+	}
+
 	return service, nil
 }
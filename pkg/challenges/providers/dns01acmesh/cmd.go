@@ -1,25 +1,22 @@
 package dns01acmesh
 
 import (
-	"os"
-	"os/exec"
+	"context"
+	"legocerthub-backend/pkg/challenges/providers/external"
 )
 
-// makeCreateCommand creates the command to make a dns record
-func (service *Service) makeCreateCommand(resourceName string, resourceContent string) *exec.Cmd {
-	return service.makeCommand(resourceName, resourceContent, false)
-}
-
-// makeDeleteCommand creates the command to delete a dns record
-func (service *Service) makeDeleteCommand(resourceName string, resourceContent string) *exec.Cmd {
-	return service.makeCommand(resourceName, resourceContent, true)
-}
+// actions
+const (
+	actionCreate = "create"
+	actionDelete = "delete"
+)
 
-// makeCommand makes a command to create or delete a dns record
-func (service *Service) makeCommand(resourceName string, resourceContent string, delete bool) *exec.Cmd {
+// runCommand runs the acme.sh dns hook's add or rm func. The command is killed
+// if ctx is canceled.
+func (service *Service) runCommand(ctx context.Context, action string, resourceName string, resourceContent string) (external.Execution, error) {
 	// func name
 	funcName := service.dnsHook + "_add"
-	if delete {
+	if action == actionDelete {
 		funcName = service.dnsHook + "_rm"
 	}
 
@@ -32,12 +29,9 @@ func (service *Service) makeCommand(resourceName string, resourceContent string,
 	// actual command  `source [path] ; [func] [args]`
 	args = append(args, "source "+service.shellScriptPath+" ; "+funcName+" "+resourceName+" "+resourceContent)
 
-	// make command
-	cmd := exec.Command(service.shellPath, args...)
-
-	// set command environment
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, service.environmentVars...)
-
-	return cmd
+	return service.runner.Run(ctx, external.Command{
+		Action: action,
+		Path:   service.shellPath,
+		Args:   args,
+	})
 }
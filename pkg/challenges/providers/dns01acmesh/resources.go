@@ -1,46 +1,48 @@
 package dns01acmesh
 
 import (
-	"fmt"
-	"os/exec"
+	"context"
+	"legocerthub-backend/pkg/challenges/providers/external"
 )
 
 // Provision adds the resource to the internal tracking map and provisions
 // the corresponding DNS record.
 func (service *Service) Provision(resourceName string, resourceContent string) error {
-	// add to internal map
-	exists, existingContent := service.dnsRecords.Add(resourceName, resourceContent)
-	// if already exists, but content is different, error
-	if exists && existingContent != resourceContent {
-		return fmt.Errorf("dns-01 (acme.sh) can't add resource (%s), already exists "+
-			"and content does not match", resourceName)
-	}
+	_, err := service.ProvisionWithLog(context.Background(), resourceName, resourceContent)
+	return err
+}
 
-	// run create script
-	// script command
-	cmd := service.makeCreateCommand(resourceName, resourceContent)
+// ProvisionWithLog is Provision, but also returns the script's execution. The
+// script is killed if ctx is canceled.
+func (service *Service) ProvisionWithLog(ctx context.Context, resourceName string, resourceContent string) ([]external.Execution, error) {
+	// add to internal map (keyed by name and content since a name can have
+	// more than one value, e.g. a wildcard and its apex)
+	_, _ = service.dnsRecords.Add(resourceName+" "+resourceContent, resourceContent)
 
-	// run script command
-	result, err := cmd.Output()
+	// run create script
+	execution, err := service.runCommand(ctx, actionCreate, resourceName, resourceContent)
 	if err != nil {
-		// try to get detailed err
-		exitErr, ok := err.(*exec.ExitError)
-		if ok {
-			service.logger.Errorf("acme.sh dns create script std err: %s", exitErr.Stderr)
-		}
+		service.logger.Errorf("acme.sh dns create script std err: %s", execution.Stderr)
 		service.logger.Errorf("acme.sh dns create script error: %s", err)
-		return err
+		return []external.Execution{execution}, err
 	}
-	service.logger.Debugf("acme.sh dns create script output: %s", string(result))
+	service.logger.Debugf("acme.sh dns create script output: %s", execution.Stdout)
 
-	return nil
+	return []external.Execution{execution}, nil
 }
 
 // Deprovision removes the resource from the internal tracking map and deletes
 // the corresponding DNS record.
 func (service *Service) Deprovision(resourceName string, resourceContent string) error {
+	_, err := service.DeprovisionWithLog(context.Background(), resourceName, resourceContent)
+	return err
+}
+
+// DeprovisionWithLog is Deprovision, but also returns the script's execution. The
+// script is killed if ctx is canceled.
+func (service *Service) DeprovisionWithLog(ctx context.Context, resourceName string, resourceContent string) ([]external.Execution, error) {
 	// remove from internal map
-	err := service.dnsRecords.Delete(resourceName)
+	err := service.dnsRecords.Delete(resourceName + " " + resourceContent)
 	if err != nil {
 		service.logger.Errorf("dns-01 (acme.sh) could not remove resource (%s) from "+
 			"internal map", resourceName)
@@ -48,21 +50,13 @@ func (service *Service) Deprovision(resourceName string, resourceContent string)
 	}
 
 	// run delete script
-	// script command
-	cmd := service.makeDeleteCommand(resourceName, resourceContent)
-
-	// run script command
-	result, err := cmd.Output()
+	execution, err := service.runCommand(ctx, actionDelete, resourceName, resourceContent)
 	if err != nil {
-		// try to get detailed err
-		exitErr, ok := err.(*exec.ExitError)
-		if ok {
-			service.logger.Errorf("acme.sh dns delete script std err: %s", exitErr.Stderr)
-		}
+		service.logger.Errorf("acme.sh dns delete script std err: %s", execution.Stderr)
 		service.logger.Errorf("acme.sh dns delete script error: %s", err)
-		return err
+		return []external.Execution{execution}, err
 	}
-	service.logger.Debugf("acme.sh dns delete script output: %s", string(result))
+	service.logger.Debugf("acme.sh dns delete script output: %s", execution.Stdout)
 
-	return nil
+	return []external.Execution{execution}, nil
 }
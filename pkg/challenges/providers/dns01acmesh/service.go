@@ -2,7 +2,9 @@ package dns01acmesh
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"legocerthub-backend/pkg/challenges/providers/external"
 	"legocerthub-backend/pkg/datatypes"
 	"os"
 	"os/exec"
@@ -24,6 +26,7 @@ var (
 // App interface is for connecting to the main app
 type App interface {
 	GetLogger() *zap.SugaredLogger
+	GetShutdownContext() context.Context
 }
 
 // Accounts service struct
@@ -32,7 +35,7 @@ type Service struct {
 	shellPath       string
 	shellScriptPath string
 	dnsHook         string
-	environmentVars []string
+	runner          *external.Runner
 	dnsRecords      *datatypes.SafeMap
 }
 
@@ -42,6 +45,8 @@ type Config struct {
 	AcmeShPath  string   `yaml:"acme_sh_path"`
 	Environment []string `yaml:"environment"`
 	DnsHook     string   `yaml:"dns_hook"`
+	// how long a hook func can run before it is killed
+	TimeoutSeconds *int `yaml:"timeout_seconds"`
 }
 
 // NewService creates a new service
@@ -108,8 +113,11 @@ func NewService(app App, config *Config) (*Service, error) {
 	// hook name (needed for funcs)
 	service.dnsHook = config.DnsHook
 
-	// environment vars
-	service.environmentVars = config.Environment
+	// script runner (with timeout and environment vars)
+	service.runner, err = external.NewRunner(app, *config.TimeoutSeconds, config.Environment)
+	if err != nil {
+		return nil, err
+	}
 
 	// map to hold current dnsRecords
 	service.dnsRecords = datatypes.NewSafeMap()
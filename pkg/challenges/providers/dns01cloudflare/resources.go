@@ -3,7 +3,6 @@ package dns01cloudflare
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/cloudflare/cloudflare-go"
@@ -26,13 +25,9 @@ func newAcmeRecord(resourceName, resourceContent string) cloudflare.DNSRecord {
 // Provision adds the resource to the internal tracking map and provisions
 // the corresponding DNS record on Cloudflare.
 func (service *Service) Provision(resourceName string, resourceContent string) error {
-	// add to internal map
-	exists, existingContent := service.dnsRecords.Add(resourceName, resourceContent)
-	// if already exists, but content is different, error
-	if exists && existingContent != resourceContent {
-		return fmt.Errorf("dns-01 (cloudflare) can't add resource (%s), already exists "+
-			"and content does not match", resourceName)
-	}
+	// add to internal map (keyed by name and content since a name can have
+	// more than one value, e.g. a wildcard and its apex)
+	_, _ = service.dnsRecords.Add(resourceName+" "+resourceContent, resourceContent)
 
 	// get the relevant zone from known list
 	zone, err := service.getResourceZone(resourceName)
@@ -56,7 +51,7 @@ func (service *Service) Provision(resourceName string, resourceContent string) e
 // the corresponding DNS record on Cloudflare.
 func (service *Service) Deprovision(resourceName string, resourceContent string) error {
 	// remove from internal map
-	err := service.dnsRecords.Delete(resourceName)
+	err := service.dnsRecords.Delete(resourceName + " " + resourceContent)
 	if err != nil {
 		service.logger.Errorf("dns-01 (cloudflare) could not remove resource (%s) from "+
 			"internal map", resourceName)
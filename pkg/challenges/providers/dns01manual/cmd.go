@@ -1,37 +1,49 @@
 package dns01manual
 
 import (
-	"os"
-	"os/exec"
+	"context"
+	"legocerthub-backend/pkg/challenges/providers/external"
 	"strings"
 )
 
-// makeCreateCommand creates the command to make a dns record
-func (service *Service) makeCreateCommand(resourceName string, resourceContent string) *exec.Cmd {
-	return service.makeCommand(resourceName, resourceContent, false)
-}
+// actions
+const (
+	actionCreate = "create"
+	actionDelete = "delete"
+)
 
-// makeDeleteCommand creates the command to delete a dns record
-func (service *Service) makeDeleteCommand(resourceName string, resourceContent string) *exec.Cmd {
-	return service.makeCommand(resourceName, resourceContent, true)
+// secondAndTLD returns the 2nd level domain + TLD of the resource name (e.g.
+// example.com)
+func secondAndTLD(resourceName string) string {
+	domainParts := strings.Split(resourceName, ".")
+	return domainParts[len(domainParts)-2] + "." + domainParts[len(domainParts)-1]
 }
 
-// makeCommand makes a command to create or delete a dns record
-func (service *Service) makeCommand(resourceName string, resourceContent string, delete bool) *exec.Cmd {
+// runScript runs the create or delete script using the configured protocol. The
+// script is killed if ctx is canceled.
+func (service *Service) runScript(ctx context.Context, action string, resourceName string, resourceContent string) (external.Execution, error) {
 	// create or delete?
 	scriptPath := service.createScriptPath
-	if delete {
+	if action == actionDelete {
 		scriptPath = service.deleteScriptPath
 	}
 
+	// json protocol sends the record on stdin
+	if service.protocol == external.ProtocolJson {
+		return service.runner.RunJson(ctx, service.shellPath, []string{scriptPath}, external.Request{
+			Action:      action,
+			Domain:      secondAndTLD(resourceName),
+			RecordName:  resourceName,
+			RecordValue: resourceContent,
+		})
+	}
+
 	// make args for command
 	// 0 - script name (e.g. /path/to/script.sh)
 	args := []string{scriptPath}
 
 	// 1 - Domain (2nd Level + TLD)
-	domainParts := strings.Split(resourceName, ".")
-	secondAndTLD := domainParts[len(domainParts)-2] + "." + domainParts[len(domainParts)-1]
-	args = append(args, secondAndTLD)
+	args = append(args, secondAndTLD(resourceName))
 
 	// 2 - RecordName (e.g. _acme-challenge.www.example.com)
 	args = append(args, resourceName)
@@ -39,12 +51,9 @@ func (service *Service) makeCommand(resourceName string, resourceContent string,
 	// 3 - RecordValue (e.g. XKrxpRBosdIKFzxW_CT3KLZNf6q0HG9i01zxXp5CPBs)
 	args = append(args, resourceContent)
 
-	// make command
-	cmd := exec.Command(service.shellPath, args...)
-
-	// set command environment
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, service.environmentVars...)
-
-	return cmd
+	return service.runner.Run(ctx, external.Command{
+		Action: action,
+		Path:   service.shellPath,
+		Args:   args,
+	})
 }
@@ -1,7 +1,9 @@
 package dns01manual
 
 import (
+	"context"
 	"errors"
+	"legocerthub-backend/pkg/challenges/providers/external"
 	"legocerthub-backend/pkg/datatypes"
 	"os"
 	"os/exec"
@@ -17,13 +19,15 @@ var (
 // App interface is for connecting to the main app
 type App interface {
 	GetLogger() *zap.SugaredLogger
+	GetShutdownContext() context.Context
 }
 
 // Accounts service struct
 type Service struct {
 	logger           *zap.SugaredLogger
 	shellPath        string
-	environmentVars  []string
+	runner           *external.Runner
+	protocol         string
 	createScriptPath string
 	deleteScriptPath string
 	dnsRecords       *datatypes.SafeMap
@@ -35,6 +39,10 @@ type Config struct {
 	Environment  []string `yaml:"environment"`
 	CreateScript string   `yaml:"create_script"`
 	DeleteScript string   `yaml:"delete_script"`
+	// how long a script can run before it is killed
+	TimeoutSeconds *int `yaml:"timeout_seconds"`
+	// how the record is passed to the scripts ('args' or 'json')
+	Protocol *string `yaml:"protocol"`
 }
 
 // NewService creates a new service
@@ -78,8 +86,18 @@ func NewService(app App, config *Config) (*Service, error) {
 		}
 	}
 
-	// environment vars
-	service.environmentVars = config.Environment
+	// script runner (with timeout and environment vars)
+	service.runner, err = external.NewRunner(app, *config.TimeoutSeconds, config.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	// script protocol
+	err = external.ValidProtocol(*config.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	service.protocol = *config.Protocol
 
 	// verify scripts exist
 	// create
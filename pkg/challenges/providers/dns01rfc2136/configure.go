@@ -0,0 +1,95 @@
+package dns01rfc2136
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	ErrDuplicateZone = errors.New("duplicate zone configuration found")
+	errNoNameserver  = errors.New("rfc2136 config error: zone is missing nameserver")
+	errNoTsigKey     = errors.New("rfc2136 config error: zone is missing tsig key name or secret")
+)
+
+// Configuration options
+type Config struct {
+	Enable *bool `yaml:"enable"`
+	Zones  []struct {
+		Zone          string `yaml:"zone"`
+		Nameserver    string `yaml:"nameserver"`
+		TsigKeyName   string `yaml:"tsig_key_name"`
+		TsigAlgorithm string `yaml:"tsig_algorithm"`
+		TsigSecret    string `yaml:"tsig_secret"`
+	} `yaml:"zones"`
+}
+
+// tsigAlgorithms maps the supported config algorithm names to the algorithm
+// names used in the TSIG record
+var tsigAlgorithms = map[string]string{
+	"hmac-md5":    dns.HmacMD5,
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// configureZones validates the zones specified within the config and adds
+// them to the service. If any zone is misconfigured, configuration is
+// aborted and an error is returned.
+func (service *Service) configureZones(config *Config) error {
+	service.zones = make(map[string]zone)
+
+	for i := range config.Zones {
+		zoneCfg := config.Zones[i]
+
+		// nameserver, default to port 53 if none specified
+		nameserver := zoneCfg.Nameserver
+		if nameserver == "" {
+			service.logger.Error(errNoNameserver)
+			return errNoNameserver
+		}
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			nameserver = net.JoinHostPort(strings.Trim(nameserver, "[]"), "53")
+		}
+
+		// tsig key
+		if zoneCfg.TsigKeyName == "" || zoneCfg.TsigSecret == "" {
+			service.logger.Error(errNoTsigKey)
+			return errNoTsigKey
+		}
+
+		// algorithm, default to hmac-sha256
+		algorithmName := strings.ToLower(zoneCfg.TsigAlgorithm)
+		if algorithmName == "" {
+			algorithmName = "hmac-sha256"
+		}
+		algorithm, ok := tsigAlgorithms[strings.TrimSuffix(algorithmName, ".")]
+		if !ok {
+			err := fmt.Errorf("rfc2136 config error: unsupported tsig algorithm (%s)", zoneCfg.TsigAlgorithm)
+			service.logger.Error(err)
+			return err
+		}
+
+		z := zone{
+			name:          dns.CanonicalName(zoneCfg.Zone),
+			nameserver:    nameserver,
+			tsigKeyName:   dns.CanonicalName(zoneCfg.TsigKeyName),
+			tsigAlgorithm: algorithm,
+			tsigSecret:    zoneCfg.TsigSecret,
+		}
+
+		// if the same zone is configured more than once, error
+		if _, exists := service.zones[z.name]; exists {
+			service.logger.Error(ErrDuplicateZone)
+			return ErrDuplicateZone
+		}
+		service.zones[z.name] = z
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package dns01rfc2136
+
+import (
+	"fmt"
+
+	"github.com/miekg/dns"
+)
+
+// newAcmeRecord returns the TXT record for a given acme resource name
+// and content
+func newAcmeRecord(resourceName, resourceContent string) *dns.TXT {
+	return &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   dns.CanonicalName(resourceName),
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+			Ttl:    60,
+		},
+		Txt: []string{resourceContent},
+	}
+}
+
+// Provision adds the resource to the internal tracking map and sends a dns
+// update to the zone's primary nameserver to add the corresponding record.
+func (service *Service) Provision(resourceName string, resourceContent string) error {
+	// add to internal map (keyed by name and content since a name can have
+	// more than one value, e.g. a wildcard and its apex)
+	_, _ = service.dnsRecords.Add(resourceName+" "+resourceContent, resourceContent)
+
+	// get the relevant zone
+	zone, err := service.getResourceZone(resourceName)
+	if err != nil {
+		return err
+	}
+
+	// insert the record (adding an identical rr is a no-op for the server)
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone.name)
+	msg.Insert([]dns.RR{newAcmeRecord(resourceName, resourceContent)})
+
+	err = zone.sendUpdate(msg)
+	if err != nil {
+		return fmt.Errorf("dns-01 (rfc2136) failed to add resource (%s) (%s)", resourceName, err)
+	}
+
+	return nil
+}
+
+// Deprovision removes the resource from the internal tracking map and sends a
+// dns update to the zone's primary nameserver to delete the corresponding record.
+func (service *Service) Deprovision(resourceName string, resourceContent string) error {
+	// remove from internal map
+	err := service.dnsRecords.Delete(resourceName + " " + resourceContent)
+	if err != nil {
+		service.logger.Errorf("dns-01 (rfc2136) could not remove resource (%s) from "+
+			"internal map", resourceName)
+		// do not return
+	}
+
+	// get the relevant zone
+	zone, err := service.getResourceZone(resourceName)
+	if err != nil {
+		return err
+	}
+
+	// remove only the record with matching content
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone.name)
+	msg.Remove([]dns.RR{newAcmeRecord(resourceName, resourceContent)})
+
+	err = zone.sendUpdate(msg)
+	if err != nil {
+		return fmt.Errorf("dns-01 (rfc2136) failed to remove resource (%s) (%s)", resourceName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package dns01rfc2136
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const (
+	testKeyName = "lego-key."
+	testSecret  = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+)
+
+type testApp struct{}
+
+func (testApp) GetLogger() *zap.SugaredLogger {
+	return zap.NewNop().Sugar()
+}
+
+// testServer is a minimal in-process dns server that applies TSIG signed
+// updates to its set of TXT records (keyed by "name value")
+type testServer struct {
+	mu      sync.Mutex
+	records map[string]bool
+}
+
+func (ts *testServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if r.IsTsig() == nil || w.TsigStatus() != nil || r.Opcode != dns.OpcodeUpdate {
+		m.Rcode = dns.RcodeRefused
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	ts.mu.Lock()
+	for _, rr := range r.Ns {
+		txt, ok := rr.(*dns.TXT)
+		if !ok {
+			continue
+		}
+		switch rr.Header().Class {
+		case dns.ClassINET:
+			ts.records[txt.Hdr.Name+" "+txt.Txt[0]] = true
+		case dns.ClassNONE:
+			delete(ts.records, txt.Hdr.Name+" "+txt.Txt[0])
+		}
+	}
+	ts.mu.Unlock()
+
+	m.SetTsig(testKeyName, dns.HmacSHA256, 300, int64(r.IsTsig().TimeSigned))
+	_ = w.WriteMsg(m)
+}
+
+func startTestServer(t *testing.T) (*testServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &testServer{records: make(map[string]bool)}
+	server := &dns.Server{
+		Listener:   listener,
+		Handler:    ts,
+		TsigSecret: map[string]string{testKeyName: testSecret},
+		// default accept func rejects updates
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	return ts, listener.Addr().String()
+}
+
+func newTestService(t *testing.T, nameserver, secret string) *Service {
+	cfg := &Config{Enable: new(bool)}
+	*cfg.Enable = true
+	cfg.Zones = append(cfg.Zones, struct {
+		Zone          string `yaml:"zone"`
+		Nameserver    string `yaml:"nameserver"`
+		TsigKeyName   string `yaml:"tsig_key_name"`
+		TsigAlgorithm string `yaml:"tsig_algorithm"`
+		TsigSecret    string `yaml:"tsig_secret"`
+	}{
+		Zone:          "example.com",
+		Nameserver:    nameserver,
+		TsigKeyName:   "lego-key",
+		TsigAlgorithm: "hmac-sha256",
+		TsigSecret:    secret,
+	})
+
+	service, err := NewService(testApp{}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return service
+}
+
+func TestProvisionDeprovision(t *testing.T) {
+	ts, addr := startTestServer(t)
+	service := newTestService(t, addr, testSecret)
+
+	name := "_acme-challenge.www.example.com"
+	err := service.Provision(name, "abc123")
+	if err != nil {
+		t.Fatalf("provision failed: %s", err)
+	}
+	if !ts.records[name+". abc123"] {
+		t.Errorf("record not added, records: %v", ts.records)
+	}
+
+	err = service.Deprovision(name, "abc123")
+	if err != nil {
+		t.Fatalf("deprovision failed: %s", err)
+	}
+	if len(ts.records) != 0 {
+		t.Errorf("record not removed, records: %v", ts.records)
+	}
+}
+
+func TestProvisionMultipleValues(t *testing.T) {
+	ts, addr := startTestServer(t)
+	service := newTestService(t, addr, testSecret)
+
+	// e.g. a wildcard and its apex
+	name := "_acme-challenge.example.com"
+	for _, value := range []string{"abc123", "def456"} {
+		err := service.Provision(name, value)
+		if err != nil {
+			t.Fatalf("provision of %s failed: %s", value, err)
+		}
+	}
+	if !ts.records[name+". abc123"] || !ts.records[name+". def456"] {
+		t.Errorf("records not added, records: %v", ts.records)
+	}
+
+	err := service.Deprovision(name, "abc123")
+	if err != nil {
+		t.Fatalf("deprovision failed: %s", err)
+	}
+	if ts.records[name+". abc123"] || !ts.records[name+". def456"] {
+		t.Errorf("wrong record removed, records: %v", ts.records)
+	}
+}
+
+func TestProvisionBadSecret(t *testing.T) {
+	_, addr := startTestServer(t)
+	service := newTestService(t, addr, "d3Jvbmd3cm9uZ3dyb25nd3Jvbmc=")
+
+	err := service.Provision("_acme-challenge.www.example.com", "abc123")
+	if err == nil {
+		t.Error("provision with wrong tsig secret should fail")
+	}
+}
+
+func TestProvisionZoneNotConfigured(t *testing.T) {
+	_, addr := startTestServer(t)
+	service := newTestService(t, addr, testSecret)
+
+	err := service.Provision("_acme-challenge.www.example.net", "abc123")
+	if err != ErrZoneNotConfigured {
+		t.Errorf("expected zone not configured error, got: %v", err)
+	}
+}
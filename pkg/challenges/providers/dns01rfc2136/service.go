@@ -0,0 +1,64 @@
+package dns01rfc2136
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/datatypes"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errServiceComponent = errors.New("necessary dns-01 rfc2136 challenge service component is missing")
+	errNoZones          = errors.New("rfc2136 config error: no zones found")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+}
+
+// Service struct
+type Service struct {
+	logger     *zap.SugaredLogger
+	zones      map[string]zone
+	dnsRecords *datatypes.SafeMap
+}
+
+// NewService creates a new service
+func NewService(app App, config *Config) (*Service, error) {
+	// if disabled, return nil and no error
+	if !*config.Enable {
+		return nil, nil
+	}
+
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// zones
+	err := service.configureZones(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure at least one zone configured, or the config is bad
+	if len(service.zones) <= 0 {
+		return nil, errNoZones
+	}
+
+	// log configured zones
+	zoneNames := []string{}
+	for name := range service.zones {
+		zoneNames = append(zoneNames, name)
+	}
+	service.logger.Infof("dns01rfc2136 configured zones: %s", zoneNames)
+
+	// map to hold current dnsRecords
+	service.dnsRecords = datatypes.NewSafeMap()
+
+	return service, nil
+}
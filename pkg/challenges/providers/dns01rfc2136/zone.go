@@ -0,0 +1,66 @@
+package dns01rfc2136
+
+import (
+	"errors"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var ErrZoneNotConfigured = errors.New("dns01rfc2136 zone not configured for resource name")
+
+// dnsTimeout is the timeout for update requests to the nameserver
+const dnsTimeout = 10 * time.Second
+
+// zone stores the primary nameserver and TSIG key used to
+// send updates for a zone
+type zone struct {
+	name          string
+	nameserver    string
+	tsigKeyName   string
+	tsigAlgorithm string
+	tsigSecret    string
+}
+
+// getResourceZone returns the most specific configured zone that the
+// resourceName is within
+func (service *Service) getResourceZone(resourceName string) (zone, error) {
+	fqdn := dns.CanonicalName(resourceName)
+
+	var found zone
+	for name, z := range service.zones {
+		if dns.IsSubDomain(name, fqdn) && len(name) > len(found.name) {
+			found = z
+		}
+	}
+
+	if found.name == "" {
+		return zone{}, ErrZoneNotConfigured
+	}
+
+	return found, nil
+}
+
+// sendUpdate signs the update msg with the zone's TSIG key and sends it to
+// the zone's primary nameserver. An error is returned if the update is not
+// successful.
+func (z zone) sendUpdate(msg *dns.Msg) error {
+	msg.SetTsig(z.tsigKeyName, z.tsigAlgorithm, 300, time.Now().Unix())
+
+	client := &dns.Client{
+		Net:        "tcp",
+		Timeout:    dnsTimeout,
+		TsigSecret: map[string]string{z.tsigKeyName: z.tsigSecret},
+	}
+
+	response, _, err := client.Exchange(msg, z.nameserver)
+	if err != nil {
+		return err
+	}
+
+	if response.Rcode != dns.RcodeSuccess {
+		return errors.New("dns update failed: " + dns.RcodeToString[response.Rcode])
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package dns01webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhook actions
+const (
+	actionProvision   = "provision"
+	actionDeprovision = "deprovision"
+)
+
+// retryDelay is the time between failed webhook attempts
+const retryDelay = 5 * time.Second
+
+var errShutdown = errors.New("dns01webhook canceled due to shutdown")
+
+// webhookPayload is the json body POSTed to the webhook
+type webhookPayload struct {
+	Action string `json:"action"`
+	Fqdn   string `json:"fqdn"`
+	Value  string `json:"value"`
+}
+
+// WebhookError is returned when the webhook responds with a non-2xx
+// status code
+type WebhookError struct {
+	Action     string
+	Fqdn       string
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("dns01webhook %s of %s failed (status: %d, response: %s)", e.Action, e.Fqdn, e.StatusCode, e.Body)
+}
+
+// maxErrorBodyLength limits how much of the webhook's response body is
+// included in a WebhookError
+const maxErrorBodyLength = 1024
+
+// sendOnce POSTs the payload to the webhook one time
+func (service *Service) sendOnce(payloadJson []byte, payload webhookPayload) error {
+	req, err := service.httpClient.NewRequest(http.MethodPost, service.url, bytes.NewBuffer(payloadJson))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range service.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := service.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	// drain any remainder
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &WebhookError{
+			Action:     payload.Action,
+			Fqdn:       payload.Fqdn,
+			StatusCode: resp.StatusCode,
+			Body:       string(bodyBytes),
+		}
+	}
+
+	return nil
+}
+
+// send POSTs the action to the webhook, retrying on failure up to the
+// configured number of retries. 4xx responses are not retried as they
+// indicate the request itself is bad.
+func (service *Service) send(action, resourceName, resourceContent string) (err error) {
+	payload := webhookPayload{
+		Action: action,
+		Fqdn:   resourceName,
+		Value:  resourceContent,
+	}
+
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt <= service.retries; attempt++ {
+		if attempt > 0 {
+			service.logger.Debugf("dns01webhook %s of %s failed (%s), retrying (attempt %d of %d)",
+				action, resourceName, err, attempt, service.retries)
+
+			// wait to retry, unless shutting down
+			select {
+			case <-service.shutdownContext.Done():
+				return errShutdown
+			case <-time.After(retryDelay):
+			}
+		}
+
+		err = service.sendOnce(payloadJson, payload)
+		if err == nil {
+			return nil
+		}
+
+		// don't retry client errors
+		if webhookErr, ok := err.(*WebhookError); ok && webhookErr.StatusCode >= 400 && webhookErr.StatusCode < 500 {
+			break
+		}
+	}
+
+	return err
+}
+
+// Provision POSTs the resource to the webhook so it can create the dns record
+func (service *Service) Provision(resourceName string, resourceContent string) error {
+	return service.send(actionProvision, resourceName, resourceContent)
+}
+
+// Deprovision POSTs the resource to the webhook so it can delete the dns record
+func (service *Service) Deprovision(resourceName string, resourceContent string) error {
+	return service.send(actionDeprovision, resourceName, resourceContent)
+}
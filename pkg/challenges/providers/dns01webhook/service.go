@@ -0,0 +1,100 @@
+package dns01webhook
+
+import (
+	"context"
+	"errors"
+	"legocerthub-backend/pkg/httpclient"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errServiceComponent = errors.New("necessary dns-01 webhook component is missing")
+	errBadUrl           = errors.New("dns01webhook config error: url must be an absolute http or https url")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetHttpClient() *httpclient.Client
+	GetShutdownContext() context.Context
+}
+
+// defaultTimeout is used if the configured timeout is invalid
+const defaultTimeout = 10 * time.Second
+
+// Service struct
+type Service struct {
+	logger          *zap.SugaredLogger
+	shutdownContext context.Context
+	httpClient      *httpclient.Client
+	url             string
+	headers         map[string]string
+	retries         int
+}
+
+// Configuration options
+type Config struct {
+	Enable         *bool             `yaml:"enable"`
+	Url            *string           `yaml:"url"`
+	Headers        map[string]string `yaml:"headers"`
+	TimeoutSeconds *int              `yaml:"timeout_seconds"`
+	Retries        *int              `yaml:"retries"`
+}
+
+// NewService creates a new service
+func NewService(app App, cfg *Config) (*Service, error) {
+	// if disabled, return nil and no error
+	if !*cfg.Enable {
+		return nil, nil
+	}
+
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// shutdown context
+	service.shutdownContext = app.GetShutdownContext()
+	if service.shutdownContext == nil {
+		return nil, errServiceComponent
+	}
+
+	// http client
+	httpClient := app.GetHttpClient()
+	if httpClient == nil {
+		return nil, errServiceComponent
+	}
+
+	// webhook url
+	if cfg.Url == nil {
+		return nil, errBadUrl
+	}
+	u, err := url.Parse(*cfg.Url)
+	if err != nil || !(u.Scheme == "http" || u.Scheme == "https") || u.Host == "" {
+		return nil, errBadUrl
+	}
+	service.url = *cfg.Url
+
+	// headers (e.g. auth) to send with every request
+	service.headers = cfg.Headers
+
+	// timeout (the provider's own client so the timeout isn't limited by the
+	// shared client's) and retries
+	timeout := time.Duration(*cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	service.httpClient = httpClient.WithTimeout(timeout)
+	service.retries = *cfg.Retries
+	if service.retries < 0 {
+		service.retries = 0
+	}
+
+	return service, nil
+}
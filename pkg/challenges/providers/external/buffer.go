@@ -0,0 +1,44 @@
+package external
+
+import (
+	"bytes"
+	"sync"
+)
+
+// limitedBuffer is a concurrency safe buffer that keeps the first limit bytes
+// written to it and discards the rest
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write always reports writing all of p so the program isn't interrupted once
+// the limit is reached
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	remaining := lb.limit - lb.buf.Len()
+	if len(p) > remaining {
+		lb.truncated = true
+		_, _ = lb.buf.Write(p[:remaining])
+	} else {
+		_, _ = lb.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// String returns the buffered output, noting if any was discarded
+func (lb *limitedBuffer) String() string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if lb.truncated {
+		return lb.buf.String() + "\n[output truncated]"
+	}
+
+	return lb.buf.String()
+}
@@ -0,0 +1,93 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// With the json protocol, the program receives a Request as a json object on
+// stdin and must write a Response as a json object to stdout. Anything else the
+// program prints (e.g. debugging) should go to stderr.
+//
+// e.g. stdin:  {"action":"create","domain":"example.com","record_name":"_acme-challenge.example.com","record_value":"abc123"}
+//      stdout: {"success":true}
+//          or: {"success":false,"message":"api token is invalid"}
+
+// Protocol values
+const (
+	ProtocolArgs = "args"
+	ProtocolJson = "json"
+)
+
+var (
+	ErrProtocolInvalid  = errors.New("external program protocol must be 'args' or 'json'")
+	ErrResponseInvalid  = errors.New("external program did not write a valid json response to stdout")
+	ErrResponseFailure  = errors.New("external program responded with failure")
+	errRequestMarshal   = errors.New("external program request could not be encoded")
+	errResponseNoResult = errors.New("external program json response is missing 'success'")
+)
+
+// ValidProtocol returns an error if protocol isn't a supported protocol
+func ValidProtocol(protocol string) error {
+	if protocol != ProtocolArgs && protocol != ProtocolJson {
+		return ErrProtocolInvalid
+	}
+	return nil
+}
+
+// Request is sent to programs using the json protocol
+type Request struct {
+	Action      string `json:"action"`
+	Domain      string `json:"domain"`
+	RecordName  string `json:"record_name"`
+	RecordValue string `json:"record_value"`
+}
+
+// Response is expected from programs using the json protocol
+type Response struct {
+	Success *bool  `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// RunJson runs the program at path using the json protocol (see: Run). An error is
+// returned if the execution fails or the program does not respond with success.
+func (runner *Runner) RunJson(ctx context.Context, path string, args []string, request Request) (Execution, error) {
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return Execution{Action: request.Action, ExitCode: -1, Error: err.Error()}, errRequestMarshal
+	}
+
+	execution, err := runner.Run(ctx, Command{
+		Action: request.Action,
+		Path:   path,
+		Args:   args,
+		Stdin:  stdin,
+	})
+	if err != nil {
+		return execution, err
+	}
+
+	// decode response (ignoring any whitespace around it)
+	var response Response
+	err = json.Unmarshal(bytes.TrimSpace([]byte(execution.Stdout)), &response)
+	if err != nil {
+		err = fmt.Errorf("%w (%s)", ErrResponseInvalid, err)
+		execution.Error = err.Error()
+		return execution, err
+	}
+	if response.Success == nil {
+		execution.Error = errResponseNoResult.Error()
+		return execution, errResponseNoResult
+	}
+
+	if !*response.Success {
+		err = fmt.Errorf("%w: %s", ErrResponseFailure, response.Message)
+		execution.Error = err.Error()
+		return execution, err
+	}
+
+	return execution, nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package external
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on windows (process groups are not used)
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command's process (children it started are not
+// killed on windows)
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
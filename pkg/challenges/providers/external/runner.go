@@ -0,0 +1,179 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Providers that run external programs (e.g. scripts) use a Runner to do so.
+// Each run has a timeout and is canceled if its context is canceled or the app
+// shuts down. The program's output is captured and returned (as an Execution)
+// so it can be logged.
+
+const (
+	// defaultTimeout is used if the configured timeout is not positive
+	defaultTimeout = 120 * time.Second
+	// killWait is how long to wait for a killed program to exit before giving up
+	// on it
+	killWait = 5 * time.Second
+	// maxOutputBytes is the maximum stdout and stderr (each) that is captured
+	maxOutputBytes = 64 * 1024
+)
+
+var (
+	ErrTimedOut = errors.New("external program timed out")
+	ErrCanceled = errors.New("external program canceled")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetShutdownContext() context.Context
+}
+
+// Runner runs external programs
+type Runner struct {
+	logger          *zap.SugaredLogger
+	shutdownContext context.Context
+	timeout         time.Duration
+	environmentVars []string
+}
+
+// Command is a program to run and its input
+type Command struct {
+	// Action describes what the command does (e.g. create or delete)
+	Action string
+	Path   string
+	Args   []string
+	Stdin  []byte
+}
+
+// Execution is the result of running a Command
+type Execution struct {
+	Action    string
+	Command   string
+	StartedAt time.Time
+	Duration  time.Duration
+	// ExitCode is -1 if the program did not exit normally (e.g. it was killed)
+	ExitCode int
+	TimedOut bool
+	Stdout   string
+	Stderr   string
+	// Error describes why the execution failed (blank if it succeeded)
+	Error string
+}
+
+// NewRunner creates a Runner with the specified timeout (seconds) and extra
+// environment variables (which are added to the app's environment)
+func NewRunner(app App, timeoutSeconds int, environmentVars []string) (*Runner, error) {
+	runner := &Runner{
+		logger:          app.GetLogger(),
+		shutdownContext: app.GetShutdownContext(),
+		timeout:         time.Duration(timeoutSeconds) * time.Second,
+		environmentVars: environmentVars,
+	}
+	if runner.logger == nil || runner.shutdownContext == nil {
+		return nil, errors.New("necessary external runner component is missing")
+	}
+
+	if runner.timeout <= 0 {
+		runner.timeout = defaultTimeout
+	}
+
+	return runner, nil
+}
+
+// Run runs the command and returns its Execution. The program is killed if ctx is
+// canceled, the app shuts down, or the timeout elapses. An error is returned if the
+// program could not be run, did not exit successfully, timed out, or was canceled.
+func (runner *Runner) Run(ctx context.Context, command Command) (Execution, error) {
+	execution := Execution{
+		Action:   command.Action,
+		Command:  strings.Join(append([]string{command.Path}, command.Args...), " "),
+		ExitCode: -1,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runner.timeout)
+	defer cancel()
+
+	// also cancel on shutdown (ctx might not be derived from the shutdown context)
+	go func() {
+		select {
+		case <-runner.shutdownContext.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	cmd := exec.Command(command.Path, command.Args...)
+	setProcessGroup(cmd)
+	cmd.Env = append(os.Environ(), runner.environmentVars...)
+	if command.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(command.Stdin)
+	}
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	execution.StartedAt = time.Now()
+	err := cmd.Start()
+	if err != nil {
+		execution.Error = err.Error()
+		return execution, err
+	}
+
+	// wait for exit, or kill (the program and its children) on timeout / shutdown
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	// only read the process state once Wait has returned
+	exited := false
+	select {
+	case err = <-done:
+		exited = true
+	case <-ctx.Done():
+		killErr := killProcessGroup(cmd)
+		if killErr != nil {
+			runner.logger.Errorf("failed to kill external program '%s' (%s)", execution.Command, killErr)
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			execution.TimedOut = true
+			err = fmt.Errorf("%w (after %s)", ErrTimedOut, runner.timeout)
+		} else {
+			err = ErrCanceled
+		}
+
+		// give the program a moment to exit so its output is complete
+		select {
+		case <-done:
+		case <-time.After(killWait):
+			runner.logger.Errorf("external program '%s' did not exit after being killed", execution.Command)
+		}
+	}
+
+	execution.Duration = time.Since(execution.StartedAt)
+	execution.Stdout = stdout.String()
+	execution.Stderr = stderr.String()
+	if exited && cmd.ProcessState != nil {
+		execution.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		execution.Error = err.Error()
+		return execution, err
+	}
+
+	return execution, nil
+}
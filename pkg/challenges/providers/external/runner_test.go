@@ -0,0 +1,189 @@
+//go:build !windows
+
+package external
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type testApp struct {
+	shutdownContext context.Context
+}
+
+func (testApp) GetLogger() *zap.SugaredLogger {
+	return zap.NewNop().Sugar()
+}
+
+func (app testApp) GetShutdownContext() context.Context {
+	return app.shutdownContext
+}
+
+func newTestRunner(t *testing.T, shutdownContext context.Context, timeoutSeconds int, environmentVars []string) *Runner {
+	t.Helper()
+
+	runner, err := NewRunner(testApp{shutdownContext: shutdownContext}, timeoutSeconds, environmentVars)
+	if err != nil {
+		t.Fatalf("failed to create runner: %s", err)
+	}
+
+	return runner
+}
+
+// runScript runs the shell script with the runner
+func runScript(runner *Runner, ctx context.Context, script string) (Execution, error) {
+	return runner.Run(ctx, Command{Action: "test", Path: "sh", Args: []string{"-c", script}})
+}
+
+// TestRun confirms the program's output, exit code, and environment
+func TestRun(t *testing.T) {
+	runner := newTestRunner(t, context.Background(), 10, []string{"TEST_VAR=test-value"})
+
+	execution, err := runScript(runner, context.Background(), `echo "out $TEST_VAR"; echo err >&2`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	if execution.ExitCode != 0 || execution.Stdout != "out test-value\n" || execution.Stderr != "err\n" || execution.Error != "" {
+		t.Errorf("got exit code %d, stdout %q, stderr %q, error %q", execution.ExitCode, execution.Stdout, execution.Stderr, execution.Error)
+	}
+
+	execution, err = runScript(runner, context.Background(), `echo failed; exit 3`)
+	if err == nil {
+		t.Error("non-zero exit did not error")
+	}
+	if execution.ExitCode != 3 || execution.Stdout != "failed\n" || execution.TimedOut {
+		t.Errorf("got exit code %d, stdout %q, timed out %t", execution.ExitCode, execution.Stdout, execution.TimedOut)
+	}
+}
+
+// TestRunOutputTruncated confirms output over the limit is discarded
+func TestRunOutputTruncated(t *testing.T) {
+	runner := newTestRunner(t, context.Background(), 10, nil)
+
+	execution, err := runScript(runner, context.Background(), `head -c 100000 /dev/zero | tr '\0' a`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	want := strings.Repeat("a", maxOutputBytes) + "\n[output truncated]"
+	if execution.Stdout != want {
+		t.Errorf("stdout: got %d bytes, want %d", len(execution.Stdout), len(want))
+	}
+}
+
+// TestRunKilled confirms the program is killed on timeout, cancel, and shutdown
+func TestRunKilled(t *testing.T) {
+	tests := []struct {
+		name         string
+		cancelCtx    bool
+		shutdown     bool
+		wantErr      error
+		wantTimedOut bool
+	}{
+		{"timeout", false, false, ErrTimedOut, true},
+		{"canceled", true, false, ErrCanceled, false},
+		{"shutdown", false, true, ErrCanceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdownContext, shutdown := context.WithCancel(context.Background())
+			defer shutdown()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			runner := newTestRunner(t, shutdownContext, 1, nil)
+			if tt.cancelCtx {
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+			if tt.shutdown {
+				time.AfterFunc(100*time.Millisecond, shutdown)
+			}
+
+			start := time.Now()
+			execution, err := runScript(runner, ctx, `echo started; sleep 30`)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error: got %v, want %v", err, tt.wantErr)
+			}
+			if execution.TimedOut != tt.wantTimedOut || execution.ExitCode != -1 {
+				t.Errorf("got timed out %t, exit code %d, want %t, -1", execution.TimedOut, execution.ExitCode, tt.wantTimedOut)
+			}
+			if execution.Stdout != "started\n" {
+				t.Errorf("stdout: got %q, want %q", execution.Stdout, "started\n")
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("program was not killed promptly (took %s)", elapsed)
+			}
+		})
+	}
+}
+
+// TestRunKillsChildren confirms children started by the program are killed too.
+// The background child holds stdout open, so the run can't finish until it is
+// killed.
+func TestRunKillsChildren(t *testing.T) {
+	runner := newTestRunner(t, context.Background(), 1, nil)
+
+	start := time.Now()
+	_, err := runScript(runner, context.Background(), `sleep 30 &`)
+	if !errors.Is(err, ErrTimedOut) {
+		t.Errorf("error: got %v, want %v", err, ErrTimedOut)
+	}
+	if elapsed := time.Since(start); elapsed > killWait {
+		t.Errorf("child program was not killed (took %s)", elapsed)
+	}
+}
+
+// TestRunJson confirms the request is sent on stdin and the response is parsed
+func TestRunJson(t *testing.T) {
+	runner := newTestRunner(t, context.Background(), 10, nil)
+
+	request := Request{
+		Action:      "create",
+		Domain:      "example.com",
+		RecordName:  "_acme-challenge.example.com",
+		RecordValue: "abc123",
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  error
+	}{
+		{"success", `{"success":true}`, nil},
+		{"success with whitespace", `  {"success": true}  `, nil},
+		{"failure", `{"success":false,"message":"api token is invalid"}`, ErrResponseFailure},
+		{"missing success", `{"message":"done"}`, errResponseNoResult},
+		{"not json", `done`, ErrResponseInvalid},
+		{"empty", ``, ErrResponseInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// echo the request to stderr and respond on stdout
+			script := `cat >&2; printf '%s\n' '` + tt.response + `'`
+			execution, err := runner.RunJson(context.Background(), "sh", []string{"-c", script}, request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && execution.Error == "" {
+				t.Error("execution error is blank")
+			}
+
+			wantStdin := `{"action":"create","domain":"example.com","record_name":"_acme-challenge.example.com","record_value":"abc123"}`
+			if execution.Stderr != wantStdin {
+				t.Errorf("stdin: got %q, want %q", execution.Stderr, wantStdin)
+			}
+		})
+	}
+
+	_, err := runner.RunJson(context.Background(), "sh", []string{"-c", `echo '{"success":false,"message":"api token is invalid"}'`}, request)
+	if err == nil || !strings.Contains(err.Error(), "api token is invalid") {
+		t.Errorf("failure error does not include the message: %v", err)
+	}
+}
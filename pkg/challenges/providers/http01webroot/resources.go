@@ -0,0 +1,100 @@
+package http01webroot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var errBadToken = errors.New("http01webroot token contains invalid characters")
+
+// acmeChallengePath is the path, relative to the webroot, that challenge
+// files are served from (RFC 8555 8.3)
+const acmeChallengePath = ".well-known/acme-challenge"
+
+// tokens are base64url encoded (RFC 8555 8.1), anything else could be used
+// to write outside of the challenge directory
+var tokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tokenFilePath returns the challenge file path for the token within the
+// webroot that serves the identifier
+func (service *Service) tokenFilePath(identifierValue, token string) (string, error) {
+	if !tokenRegex.MatchString(token) {
+		return "", errBadToken
+	}
+
+	webroot, err := service.getWebroot(identifierValue)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(webroot, acmeChallengePath, token), nil
+}
+
+// ProvisionForIdentifier writes the key authorization to the token's file
+// in the webroot that serves the identifier.
+func (service *Service) ProvisionForIdentifier(identifierValue string, token string, keyAuth string) error {
+	filePath, err := service.tokenFilePath(identifierValue, token)
+	if err != nil {
+		return err
+	}
+
+	// make challenge dir if it doesn't exist
+	dir := filepath.Dir(filePath)
+	err = os.MkdirAll(dir, os.FileMode(service.dirMode))
+	if err != nil {
+		return fmt.Errorf("http01webroot failed to make challenge dir (%s)", err)
+	}
+
+	// write file, then explicitly set mode since WriteFile is subject to umask
+	err = os.WriteFile(filePath, []byte(keyAuth), os.FileMode(service.fileMode))
+	if err != nil {
+		return fmt.Errorf("http01webroot failed to write challenge file (%s)", err)
+	}
+	err = os.Chmod(filePath, os.FileMode(service.fileMode))
+	if err != nil {
+		return fmt.Errorf("http01webroot failed to set challenge file mode (%s)", err)
+	}
+
+	// ownership
+	if service.uid >= 0 || service.gid >= 0 {
+		err = os.Chown(filePath, service.uid, service.gid)
+		if err != nil {
+			return fmt.Errorf("http01webroot failed to set challenge file owner (%s)", err)
+		}
+	}
+
+	return nil
+}
+
+// DeprovisionForIdentifier removes the token's file from the webroot that
+// serves the identifier.
+func (service *Service) DeprovisionForIdentifier(identifierValue string, token string, keyAuth string) error {
+	// keyAuth is unused in this function
+
+	filePath, err := service.tokenFilePath(identifierValue, token)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("http01webroot failed to remove challenge file (%s)", err)
+	}
+
+	return nil
+}
+
+// Provision writes the key authorization to the default webroot. The challenges
+// service uses ProvisionForIdentifier so the correct webroot can be selected.
+func (service *Service) Provision(token string, keyAuth string) error {
+	return service.ProvisionForIdentifier("", token, keyAuth)
+}
+
+// Deprovision removes the token's file from the default webroot. The challenges
+// service uses DeprovisionForIdentifier so the correct webroot can be selected.
+func (service *Service) Deprovision(token string, keyAuth string) error {
+	return service.DeprovisionForIdentifier("", token, keyAuth)
+}
@@ -0,0 +1,125 @@
+package http01webroot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestService returns a service with a default webroot, a webroot for
+// example.com, and a webroot for dev.example.com (all in a temp dir)
+func newTestService(t *testing.T) (service *Service, root string) {
+	t.Helper()
+
+	root = t.TempDir()
+	service = &Service{
+		webroots: []webroot{
+			{path: filepath.Join(root, "default")},
+			{path: filepath.Join(root, "example"), suffixes: []string{"example.com"}},
+			{path: filepath.Join(root, "dev"), suffixes: []string{"dev.example.com", "dev.example.net"}},
+		},
+		fileMode: 0644,
+		dirMode:  0755,
+		uid:      -1,
+		gid:      -1,
+	}
+
+	return service, root
+}
+
+// TestProvisionRejectsBadTokens confirms tokens that aren't base64url (e.g. that
+// contain path separators or dots) are rejected and nothing is written
+func TestProvisionRejectsBadTokens(t *testing.T) {
+	service, root := newTestService(t)
+
+	badTokens := []string{
+		"",
+		".",
+		"..",
+		"../token",
+		"../../etc/passwd",
+		"dir/token",
+		"/token",
+		`dir\token`,
+		`..\token`,
+		"token.txt",
+		"token%2F..",
+		"token\x00",
+		"token ",
+	}
+
+	for _, token := range badTokens {
+		err := service.ProvisionForIdentifier("www.example.com", token, "key-auth")
+		if !errors.Is(err, errBadToken) {
+			t.Errorf("provision %q: got %v, want %v", token, err, errBadToken)
+		}
+		err = service.DeprovisionForIdentifier("www.example.com", token, "key-auth")
+		if !errors.Is(err, errBadToken) {
+			t.Errorf("deprovision %q: got %v, want %v", token, err, errBadToken)
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("failed to read root: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("bad tokens wrote %d entries to the root", len(entries))
+	}
+}
+
+// TestProvisionWebroot confirms the challenge file is written to the challenge
+// dir of the webroot that serves the identifier and then removed
+func TestProvisionWebroot(t *testing.T) {
+	service, root := newTestService(t)
+
+	const token = "evaGxfADs6pSRb2LAv9IZf17Dt3juxGJ-PCt92wr-oA"
+
+	tests := []struct {
+		identifier  string
+		wantWebroot string
+	}{
+		{"example.com", "example"},
+		{"WWW.Example.com", "example"},
+		{"dev.example.com", "dev"},
+		{"api.dev.example.com", "dev"},
+		{"dev.example.net", "dev"},
+		{"notexample.com", "default"},
+		{"example.org", "default"},
+		{"192.0.2.1", "default"},
+	}
+
+	for _, tt := range tests {
+		err := service.ProvisionForIdentifier(tt.identifier, token, "key-auth-"+tt.identifier)
+		if err != nil {
+			t.Fatalf("%s: failed to provision: %s", tt.identifier, err)
+		}
+
+		filePath := filepath.Join(root, tt.wantWebroot, ".well-known", "acme-challenge", token)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Errorf("%s: challenge file not in %s webroot: %s", tt.identifier, tt.wantWebroot, err)
+			continue
+		}
+		if string(content) != "key-auth-"+tt.identifier {
+			t.Errorf("%s: content: got %q, want %q", tt.identifier, content, "key-auth-"+tt.identifier)
+		}
+
+		err = service.DeprovisionForIdentifier(tt.identifier, token, "")
+		if err != nil {
+			t.Fatalf("%s: failed to deprovision: %s", tt.identifier, err)
+		}
+		_, err = os.Stat(filePath)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: challenge file not removed: %v", tt.identifier, err)
+		}
+	}
+
+	// without a default webroot, unmatched identifiers have no webroot
+	service.webroots = service.webroots[1:]
+	err := service.ProvisionForIdentifier("example.org", token, "key-auth")
+	if !errors.Is(err, ErrNoWebroot) {
+		t.Errorf("no default webroot: got %v, want %v", err, ErrNoWebroot)
+	}
+}
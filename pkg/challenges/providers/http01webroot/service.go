@@ -0,0 +1,107 @@
+package http01webroot
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errServiceComponent = errors.New("necessary http-01 webroot challenge service component is missing")
+	errNoWebroots       = errors.New("http01webroot config error: no webroots found")
+	errMultipleDefaults = errors.New("http01webroot config error: only one webroot may omit identifier suffixes (the default)")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+}
+
+// Service struct
+type Service struct {
+	logger   *zap.SugaredLogger
+	webroots []webroot
+	fileMode uint32
+	dirMode  uint32
+	uid      int
+	gid      int
+}
+
+// Configuration options
+type Config struct {
+	Enable   *bool `yaml:"enable"`
+	Webroots []struct {
+		Path               string   `yaml:"path"`
+		IdentifierSuffixes []string `yaml:"identifier_suffixes"`
+	} `yaml:"webroots"`
+	FileMode *string `yaml:"file_mode"`
+	DirMode  *string `yaml:"dir_mode"`
+	OwnerUid *int    `yaml:"owner_uid"`
+	OwnerGid *int    `yaml:"owner_gid"`
+}
+
+// NewService creates a new service
+func NewService(app App, cfg *Config) (*Service, error) {
+	// if disabled, return nil and no error
+	if !*cfg.Enable {
+		return nil, nil
+	}
+
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// webroots
+	defaultCount := 0
+	for i := range cfg.Webroots {
+		if !filepath.IsAbs(cfg.Webroots[i].Path) {
+			return nil, fmt.Errorf("http01webroot config error: webroot path (%s) must be absolute", cfg.Webroots[i].Path)
+		}
+
+		wr := webroot{
+			path: filepath.Clean(cfg.Webroots[i].Path),
+		}
+		for _, suffix := range cfg.Webroots[i].IdentifierSuffixes {
+			wr.suffixes = append(wr.suffixes, strings.TrimPrefix(strings.ToLower(suffix), "."))
+		}
+
+		if len(wr.suffixes) == 0 {
+			defaultCount++
+		}
+
+		service.webroots = append(service.webroots, wr)
+	}
+	if len(service.webroots) <= 0 {
+		return nil, errNoWebroots
+	}
+	if defaultCount > 1 {
+		return nil, errMultipleDefaults
+	}
+
+	// permissions (octal strings)
+	fileMode, err := strconv.ParseUint(*cfg.FileMode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("http01webroot config error: invalid file_mode (%s)", err)
+	}
+	service.fileMode = uint32(fileMode)
+
+	dirMode, err := strconv.ParseUint(*cfg.DirMode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("http01webroot config error: invalid dir_mode (%s)", err)
+	}
+	service.dirMode = uint32(dirMode)
+
+	// ownership (-1 leaves ownership unchanged)
+	service.uid = *cfg.OwnerUid
+	service.gid = *cfg.OwnerGid
+
+	return service, nil
+}
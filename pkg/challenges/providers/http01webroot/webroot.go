@@ -0,0 +1,52 @@
+package http01webroot
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNoWebroot = errors.New("http01webroot no webroot configured for identifier")
+
+// webroot is a directory that a web server serves, and the identifier
+// suffixes it serves (if none, it is the default webroot)
+type webroot struct {
+	path     string
+	suffixes []string
+}
+
+// getWebroot returns the webroot path for the specified identifier value.
+// The webroot with the longest matching suffix is used. A suffix matches the
+// identifier itself or any subdomain of it. If no suffix matches, the default
+// webroot is used (if there is one).
+func (service *Service) getWebroot(identifierValue string) (string, error) {
+	identifierValue = strings.ToLower(identifierValue)
+
+	path := ""
+	longestSuffix := ""
+	defaultPath := ""
+
+	for _, wr := range service.webroots {
+		if len(wr.suffixes) == 0 {
+			defaultPath = wr.path
+			continue
+		}
+
+		for _, suffix := range wr.suffixes {
+			if (identifierValue == suffix || strings.HasSuffix(identifierValue, "."+suffix)) &&
+				len(suffix) > len(longestSuffix) {
+				longestSuffix = suffix
+				path = wr.path
+			}
+		}
+	}
+
+	if path != "" {
+		return path, nil
+	}
+
+	if defaultPath != "" {
+		return defaultPath, nil
+	}
+
+	return "", ErrNoWebroot
+}
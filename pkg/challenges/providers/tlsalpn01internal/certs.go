@@ -0,0 +1,36 @@
+package tlsalpn01internal
+
+import (
+	"crypto/tls"
+	"strings"
+)
+
+// Provision adds the validation certificate for the specified domain to the
+// certificates the server will present
+func (service *Service) Provision(domain string, pemCertAndKey string) (err error) {
+	// the pem contains both the certificate and its private key
+	cert, err := tls.X509KeyPair([]byte(pemCertAndKey), []byte(pemCertAndKey))
+	if err != nil {
+		return err
+	}
+
+	// add new entry
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.certs[strings.ToLower(domain)] = &cert
+
+	return nil
+}
+
+// Deprovision removes the validation certificate for the specified domain
+func (service *Service) Deprovision(domain string, pemCertAndKey string) (err error) {
+	// pemCertAndKey is unused in this function
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.certs, strings.ToLower(domain))
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package tlsalpn01internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"legocerthub-backend/pkg/acme"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAcceptDelay is the longest delay after repeated accept errors
+const maxAcceptDelay = 1 * time.Second
+
+var (
+	errNotAcmeTls = errors.New("tls-alpn-01 client did not offer acme-tls/1 protocol")
+	errNoCert     = errors.New("tls-alpn-01 validation certificate not found")
+)
+
+// getCertificate returns the validation certificate for the SNI in the client's
+// hello, but only if the client is negotiating the acme-tls/1 protocol
+func (service *Service) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	acmeTls := false
+	for _, proto := range hello.SupportedProtos {
+		if proto == acme.TlsAlpn01Protocol {
+			acmeTls = true
+			break
+		}
+	}
+	if !acmeTls {
+		return nil, errNotAcmeTls
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	cert, exists := service.certs[strings.ToLower(hello.ServerName)]
+	if !exists {
+		service.logger.Debugf("tls-alpn-01 challenge certificate not found: %s", hello.ServerName)
+		return nil, errNoCert
+	}
+
+	service.logger.Debugf("presenting tls-alpn-01 challenge certificate to client: %s", hello.ServerName)
+	return cert, nil
+}
+
+// tlsConfig returns the tls config of the challenge server, which only
+// negotiates acme-tls/1
+func (service *Service) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{acme.TlsAlpn01Protocol},
+		GetCertificate: service.getCertificate,
+	}
+}
+
+func (service *Service) startServer(port int, ctx context.Context, wg *sync.WaitGroup) (err error) {
+	// TODO: modify to allow specifying specific interface addresses
+	hostName := ""
+
+	servAddr := fmt.Sprintf("%s:%d", hostName, port)
+	listener, err := tls.Listen("tcp", servAddr, service.tlsConfig())
+	if err != nil {
+		return err
+	}
+
+	// launch server
+	service.logger.Infof("starting tls-alpn-01 challenge server on %s.", servAddr)
+	if port != 443 {
+		service.logger.Warnf("tls-alpn-01 challenge server is not running on port 443; internet "+
+			"facing port 443 must be proxied (at the tcp level) to port %d to function.", port)
+	}
+
+	service.serve(listener, ctx, wg)
+
+	return nil
+}
+
+// serve accepts connections on the (tls) listener and completes their handshakes
+// until ctx is canceled
+func (service *Service) serve(listener net.Listener, ctx context.Context, wg *sync.WaitGroup) {
+	// configure server
+	handshakeTimeout := 10 * time.Second
+	// allow longer timeouts when in development
+	if service.devMode {
+		handshakeTimeout = 30 * time.Second
+	}
+
+	wg.Add(1)
+
+	go func() {
+		// delay after accept errors (e.g. too many open files) so they don't spin,
+		// the same as net/http's Server
+		var acceptDelay time.Duration
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					break
+				}
+
+				if acceptDelay == 0 {
+					acceptDelay = 5 * time.Millisecond
+				} else {
+					acceptDelay *= 2
+				}
+				if acceptDelay > maxAcceptDelay {
+					acceptDelay = maxAcceptDelay
+				}
+				service.logger.Errorf("tls-alpn-01 challenge server accept error: %s; retrying in %s", err, acceptDelay)
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(acceptDelay):
+				}
+				continue
+			}
+			acceptDelay = 0
+
+			// the handshake is the entire validation, close the connection once done
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				err := conn.SetDeadline(time.Now().Add(handshakeTimeout))
+				if err != nil {
+					service.logger.Errorf("tls-alpn-01 failed to set deadline: %s", err)
+					return
+				}
+
+				err = conn.(*tls.Conn).Handshake()
+				if err != nil {
+					service.logger.Debugf("tls-alpn-01 handshake failed: %s", err)
+				}
+			}(conn)
+		}
+
+		service.logger.Info("tls-alpn-01 challenge server shutdown complete")
+		wg.Done()
+	}()
+
+	// monitor shutdown context
+	go func() {
+		<-ctx.Done()
+
+		err := listener.Close()
+		if err != nil {
+			service.logger.Errorf("error shutting down tls-alpn-01 challenge server")
+		}
+	}()
+}
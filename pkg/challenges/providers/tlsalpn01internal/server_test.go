@@ -0,0 +1,136 @@
+package tlsalpn01internal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/asn1"
+	"legocerthub-backend/pkg/acme"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// idPeAcmeIdentifier is the object identifier of the acmeIdentifier extension
+// (RFC 8737 6.1)
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// startTestServer starts the challenge server on a random local port and returns
+// the service and the server's address
+func startTestServer(t *testing.T) (*Service, string) {
+	t.Helper()
+
+	service := &Service{
+		logger: zap.NewNop().Sugar(),
+		certs:  make(map[string]*tls.Certificate),
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", service.tlsConfig())
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := new(sync.WaitGroup)
+	service.serve(listener, ctx, wg)
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	return service, listener.Addr().String()
+}
+
+// TestServerPresentsChallengeCert confirms the server negotiates acme-tls/1 and
+// presents the provisioned challenge certificate, which contains the critical
+// acmeIdentifier extension
+func TestServerPresentsChallengeCert(t *testing.T) {
+	service, addr := startTestServer(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate account key: %s", err)
+	}
+	identifier := acme.NewIdentifier("example.com")
+	name, content, err := acme.ChallengeTypeTlsAlpn01.ValidationResource(identifier, acme.AccountKey{Key: key}, "test-token")
+	if err != nil {
+		t.Fatalf("failed to make validation resource: %s", err)
+	}
+
+	err = service.Provision(name, content)
+	if err != nil {
+		t.Fatalf("failed to provision: %s", err)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		ServerName:         "Example.com",
+		NextProtos:         []string{acme.TlsAlpn01Protocol},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	if state.NegotiatedProtocol != acme.TlsAlpn01Protocol {
+		t.Errorf("negotiated protocol: got %q, want %q", state.NegotiatedProtocol, acme.TlsAlpn01Protocol)
+	}
+	if len(state.PeerCertificates) != 1 {
+		t.Fatalf("peer certificates: got %d, want 1", len(state.PeerCertificates))
+	}
+
+	cert := state.PeerCertificates[0]
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "example.com" {
+		t.Errorf("dns names: got %v, want [example.com]", cert.DNSNames)
+	}
+
+	found := false
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPeAcmeIdentifier) {
+			continue
+		}
+		found = true
+		if !ext.Critical {
+			t.Error("acmeIdentifier extension is not critical")
+		}
+		var digest []byte
+		rest, err := asn1.Unmarshal(ext.Value, &digest)
+		if err != nil || len(rest) != 0 || len(digest) != 32 {
+			t.Errorf("acmeIdentifier extension value is not a sha-256 digest octet string: %x", ext.Value)
+		}
+	}
+	if !found {
+		t.Error("acmeIdentifier extension is missing")
+	}
+}
+
+// TestServerRejects confirms the server doesn't complete handshakes that don't
+// offer acme-tls/1 or that are for an identifier without a challenge cert
+func TestServerRejects(t *testing.T) {
+	_, addr := startTestServer(t)
+
+	tests := []struct {
+		name       string
+		serverName string
+		nextProtos []string
+	}{
+		{"no acme-tls/1", "example.com", []string{"h2", "http/1.1"}},
+		{"no challenge cert", "missing.example.com", []string{acme.TlsAlpn01Protocol}},
+	}
+
+	for _, tt := range tests {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{
+			ServerName:         tt.serverName,
+			NextProtos:         tt.nextProtos,
+			InsecureSkipVerify: true,
+		})
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: handshake succeeded", tt.name)
+		}
+	}
+}
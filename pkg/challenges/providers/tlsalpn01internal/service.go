@@ -0,0 +1,70 @@
+package tlsalpn01internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errServiceComponent = errors.New("necessary tls-alpn-01 internal challenge service component is missing")
+	errConfigComponent  = errors.New("necessary tls-alpn-01 config option missing")
+)
+
+// App interface is for connecting to the main app
+type App interface {
+	GetDevMode() bool
+	GetLogger() *zap.SugaredLogger
+	GetShutdownContext() context.Context
+	GetShutdownWaitGroup() *sync.WaitGroup
+}
+
+// Service struct
+type Service struct {
+	devMode bool
+	logger  *zap.SugaredLogger
+	certs   map[string]*tls.Certificate
+	mu      sync.RWMutex
+}
+
+// Configuration options
+type Config struct {
+	Enable *bool `yaml:"enable"`
+	Port   *int  `yaml:"port"`
+}
+
+// NewService creates a new service
+func NewService(app App, config *Config) (*Service, error) {
+	// if disabled, return nil and no error
+	if !*config.Enable {
+		return nil, nil
+	}
+
+	service := new(Service)
+
+	// devmode?
+	service.devMode = app.GetDevMode()
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// allocate cert map
+	service.certs = make(map[string]*tls.Certificate, 50)
+
+	// start tls server for tls-alpn-01 challenges
+	if config.Port == nil {
+		return nil, errConfigComponent
+	}
+	err := service.startServer(*config.Port, app.GetShutdownContext(), app.GetShutdownWaitGroup())
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
@@ -1,31 +1,41 @@
 package challenges
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"legocerthub-backend/pkg/acme"
 	"legocerthub-backend/pkg/challenges/dns_checker"
+	"legocerthub-backend/pkg/challenges/providers/external"
+	"legocerthub-backend/pkg/order_events"
 	"reflect"
 )
 
-var errUnsupportedMethod = errors.New("unsupported or disabled challenge method")
+var (
+	errUnsupportedMethod = errors.New("unsupported or disabled challenge method")
+	errDnsCheckerMissing = errors.New("dns checker is not configured")
+)
 
 // Provision generates the needed ACME challenge resource (to validate
 // the challenge) and then provisions that resource using the Method's
-// provider.
-func (service *Service) Provision(identifier acme.Identifier, method Method, key acme.AccountKey, token string) (err error) {
+// provider. Any external provider executions are logged with the orderId.
+// Canceling ctx kills any external provider programs and stops waiting for dns-01
+// propagation.
+func (service *Service) Provision(ctx context.Context, identifier acme.Identifier, method Method, key acme.AccountKey, token string, orderId int) (err error) {
 	// calculate the needed resource
-	resourceName, resourceContent, err := method.validationResource(identifier, key, token)
+	resourceName, resourceContent, err := service.resource(identifier, method, key, token)
 	if err != nil {
 		return err
 	}
 
-	// confirm provider is available
-	if provider, ok := service.providers[method.Value]; !ok || reflect.ValueOf(provider).IsNil() {
-		return errUnsupportedMethod
+	// dns-01 methods need the dnsChecker
+	dnsChecker := service.getDnsChecker()
+	if method.ChallengeType == acme.ChallengeTypeDns01 && dnsChecker == nil {
+		return errDnsCheckerMissing
 	}
 
 	// Provision with the appropriate provider
-	err = service.providers[method.Value].Provision(resourceName, resourceContent)
+	err = service.provisionResource(ctx, identifier, method, resourceName, resourceContent, orderId)
 	if err != nil {
 		return err
 	}
@@ -33,15 +43,17 @@ func (service *Service) Provision(identifier acme.Identifier, method Method, key
 	// if using dns-01 method, utilize dnsChecker
 	if method.ChallengeType == acme.ChallengeTypeDns01 {
 		// check for propagation
-		propagated, err := service.dnsChecker.CheckTXTWithRetry(resourceName, resourceContent, 10)
+		timing := service.timing(method)
+		propagated, err := dnsChecker.CheckTXTWithRetry(ctx, resourceName, resourceContent, timing.propagation, timing.thresholds)
 		if err != nil {
 			service.logger.Error(err)
-			return err
+		} else if !propagated {
+			// if failed to propagate
+			err = dns_checker.ErrDnsRecordNotFound
 		}
-
-		// if failed to propagate
-		if !propagated {
-			return dns_checker.ErrDnsRecordNotFound
+		service.events.Record(orderId, order_events.TypePropagationConfirmed, identifier.Value, resourceName, err)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -49,23 +61,138 @@ func (service *Service) Provision(identifier acme.Identifier, method Method, key
 }
 
 // Deprovision removes the ACME challenge resource from the Method's provider.
-func (service *Service) Deprovision(identifier acme.Identifier, method Method, key acme.AccountKey, token string) (err error) {
+// Any external provider executions are logged with the orderId.
+func (service *Service) Deprovision(identifier acme.Identifier, method Method, key acme.AccountKey, token string, orderId int) (err error) {
 	// calculate the needed resource
-	resourceName, resourceContent, err := method.validationResource(identifier, key, token)
+	resourceName, resourceContent, err := service.resource(identifier, method, key, token)
 	if err != nil {
 		return err
 	}
 
+	// Deprovision with the appropriate provider
+	return service.deprovisionResource(identifier, method, resourceName, resourceContent, orderId)
+}
+
+// resource calculates the resource name and content for the challenge and
+// confirms the Method's provider is available. dns-01 resource names account
+// for any delegation.
+func (service *Service) resource(identifier acme.Identifier, method Method, key acme.AccountKey, token string) (resourceName string, resourceContent string, err error) {
+	// calculate the needed resource
+	resourceName, resourceContent, err = method.validationResource(identifier, key, token)
+	if err != nil {
+		return "", "", err
+	}
+
 	// confirm provider is available
-	if provider, ok := service.providers[method.Value]; !ok || reflect.ValueOf(provider).IsNil() {
-		return errUnsupportedMethod
+	_, err = service.provider(method.Value)
+	if err != nil {
+		return "", "", err
 	}
 
-	// Deprovision with the appropriate provider
-	err = service.providers[method.Value].Deprovision(resourceName, resourceContent)
+	// dns-01 records may be delegated to another name
+	if method.ChallengeType == acme.ChallengeTypeDns01 {
+		resourceName, err = service.dnsResourceName(method, resourceName)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return resourceName, resourceContent, nil
+}
+
+// provisionResource provisions the resource using the Method's provider. The
+// resource is saved to storage first, so it can be swept if it is never
+// deprovisioned. External provider executions are logged with the orderId and
+// are killed if ctx is canceled.
+func (service *Service) provisionResource(ctx context.Context, identifier acme.Identifier, method Method, resourceName string, resourceContent string, orderId int) error {
+	provider, err := service.provider(method.Value)
 	if err != nil {
 		return err
 	}
 
+	service.trackResource(identifier, method, resourceName, resourceContent)
+
+	if execProvider, ok := provider.(execProviderService); ok {
+		var executions []external.Execution
+		executions, err = execProvider.ProvisionWithLog(ctx, resourceName, resourceContent)
+		service.logExecutions(orderId, identifier, method, executions)
+	} else if identifierProvider, ok := provider.(identifierProviderService); ok {
+		err = identifierProvider.ProvisionForIdentifier(identifier.Value, resourceName, resourceContent)
+	} else {
+		err = provider.Provision(resourceName, resourceContent)
+	}
+	service.events.Record(orderId, order_events.TypeResourceProvisioned, identifier.Value, resourceEventDetail(method, resourceName), err)
+
+	return err
+}
+
+// deprovisionResource deprovisions the resource using the Method's provider and,
+// if successful, removes it from storage. External provider executions are
+// logged with the orderId. Deprovisioning isn't tied to the context the resource
+// was provisioned with (so resources are still cleaned up after an order's job is
+// canceled), external programs are only killed if the app shuts down.
+func (service *Service) deprovisionResource(identifier acme.Identifier, method Method, resourceName string, resourceContent string, orderId int) error {
+	provider, err := service.provider(method.Value)
+	if err != nil {
+		return err
+	}
+
+	if execProvider, ok := provider.(execProviderService); ok {
+		var executions []external.Execution
+		executions, err = execProvider.DeprovisionWithLog(service.shutdownContext, resourceName, resourceContent)
+		service.logExecutions(orderId, identifier, method, executions)
+	} else if identifierProvider, ok := provider.(identifierProviderService); ok {
+		err = identifierProvider.DeprovisionForIdentifier(identifier.Value, resourceName, resourceContent)
+	} else {
+		err = provider.Deprovision(resourceName, resourceContent)
+	}
+	service.events.Record(orderId, order_events.TypeResourceDeprovisioned, identifier.Value, resourceEventDetail(method, resourceName), err)
+	if err != nil {
+		return err
+	}
+
+	service.untrackResource(method, resourceName, resourceContent)
+
 	return nil
 }
+
+// resourceEventDetail is the detail of a resource's order events
+func resourceEventDetail(method Method, resourceName string) string {
+	return fmt.Sprintf("%s: %s", method.Value, resourceName)
+}
+
+// provider returns the provider for the method value, or an error if there
+// is no provider for the method
+func (service *Service) provider(methodValue MethodValue) (providerService, error) {
+	service.providersMu.RLock()
+	provider, ok := service.providers[methodValue]
+	service.providersMu.RUnlock()
+
+	if !ok || reflect.ValueOf(provider).IsNil() {
+		return nil, errUnsupportedMethod
+	}
+
+	return provider, nil
+}
+
+// useProvider marks the method's provider as in use (so it won't be replaced or
+// removed while in use). The returned release func must be called when done.
+func (service *Service) useProvider(methodValue MethodValue) (release func(), err error) {
+	service.providersMu.Lock()
+	defer service.providersMu.Unlock()
+
+	if provider, ok := service.providers[methodValue]; !ok || reflect.ValueOf(provider).IsNil() {
+		return nil, errUnsupportedMethod
+	}
+	service.providersInUse[methodValue]++
+
+	return func() {
+		service.providersMu.Lock()
+		defer service.providersMu.Unlock()
+
+		service.providersInUse[methodValue]--
+		if service.providersInUse[methodValue] <= 0 {
+			delete(service.providersInUse, methodValue)
+		}
+	}, nil
+}
@@ -0,0 +1,355 @@
+package challenges
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/validation"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A self test runs a Method's provider end to end without contacting the ACME
+// server. A throwaway account key and random token are used to generate the
+// resource, which is provisioned, verified the same way the ACME server would
+// (dns query, http fetch, or tls-alpn handshake), and then deprovisioned.
+
+var (
+	errSelfTestNotFound = errors.New("self test not found")
+	errSelfTestUnknown  = errors.New("unknown or disabled challenge method")
+	errSelfTestDomain   = errors.New("self test domain is not a valid domain or ip address")
+)
+
+const (
+	// selfTestRetention is how long completed self test results are kept
+	selfTestRetention = 1 * time.Hour
+	// selfTestVerifyTimeout is the timeout for the http and tls-alpn verification
+	selfTestVerifyTimeout = 10 * time.Second
+)
+
+// self test step names
+const (
+	selfTestStepGenerate    = "generate_resource"
+	selfTestStepProvision   = "provision"
+	selfTestStepVerify      = "verify"
+	selfTestStepDeprovision = "deprovision"
+)
+
+// SelfTestStep is the result of one step of a self test
+type SelfTestStep struct {
+	Name       string `json:"name"`
+	Success    bool   `json:"success"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SelfTestResult is the result of a Method self test
+type SelfTestResult struct {
+	ID         string         `json:"id"`
+	Method     MethodValue    `json:"method"`
+	Identifier string         `json:"identifier"`
+	Complete   bool           `json:"complete"`
+	Success    bool           `json:"success"`
+	Steps      []SelfTestStep `json:"steps"`
+	StartedAt  int            `json:"started_at"`
+	FinishedAt int            `json:"finished_at,omitempty"`
+}
+
+// selfTest is a running or completed self test
+type selfTest struct {
+	mu     sync.RWMutex
+	result SelfTestResult
+	done   chan struct{}
+}
+
+// snapshot returns a copy of the test's current result
+func (st *selfTest) snapshot() SelfTestResult {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	result := st.result
+	result.Steps = append([]SelfTestStep{}, st.result.Steps...)
+
+	return result
+}
+
+// runStep runs f and records it as a step of the test. The result of f is
+// returned.
+func (st *selfTest) runStep(name string, f func() (detail string, err error)) error {
+	start := time.Now()
+	detail, err := f()
+
+	step := SelfTestStep{
+		Name:       name,
+		Success:    err == nil,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+
+	st.mu.Lock()
+	st.result.Steps = append(st.result.Steps, step)
+	st.mu.Unlock()
+
+	return err
+}
+
+// selfTests holds the service's running and recent self tests
+type selfTests struct {
+	mu    sync.Mutex
+	tests map[string]*selfTest
+}
+
+// StartSelfTest starts a self test of the method (specified by value) using the
+// identifier value. The test runs in the background; the returned channel is
+// closed when the test completes.
+func (service *Service) StartSelfTest(methodValue MethodValue, identifierValue string) (testId string, done <-chan struct{}, err error) {
+	method := service.MethodByStorageValue(methodValue)
+	if method == UnknownMethod || !method.Enabled {
+		return "", nil, errSelfTestUnknown
+	}
+
+	// confirm the identifier is valid and the method can validate it
+	if !validation.DomainOrIPValid(identifierValue, true) {
+		return "", nil, errSelfTestDomain
+	}
+	err = method.IdentifierError(identifierValue)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// hold the provider until the test is done
+	release, err := service.useProvider(method.Value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// random id
+	idBytes := make([]byte, 12)
+	_, err = rand.Read(idBytes)
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+
+	test := &selfTest{
+		result: SelfTestResult{
+			ID:         base64.RawURLEncoding.EncodeToString(idBytes),
+			Method:     method.Value,
+			Identifier: identifierValue,
+			Steps:      []SelfTestStep{},
+			StartedAt:  int(time.Now().Unix()),
+		},
+		done: make(chan struct{}),
+	}
+
+	// store test (and drop any old ones)
+	service.selfTests.mu.Lock()
+	for id, t := range service.selfTests.tests {
+		t.mu.RLock()
+		expired := t.result.Complete && time.Since(time.Unix(int64(t.result.FinishedAt), 0)) > selfTestRetention
+		t.mu.RUnlock()
+		if expired {
+			delete(service.selfTests.tests, id)
+		}
+	}
+	service.selfTests.tests[test.result.ID] = test
+	service.selfTests.mu.Unlock()
+
+	// wildcard authorizations use the identifier without the wildcard
+	go func() {
+		defer release()
+		service.runSelfTest(test, method, acme.NewIdentifier(strings.TrimPrefix(identifierValue, "*.")))
+	}()
+
+	return test.result.ID, test.done, nil
+}
+
+// SelfTestResult returns the current result of the specified self test
+func (service *Service) SelfTestResult(testId string) (SelfTestResult, error) {
+	service.selfTests.mu.Lock()
+	test, ok := service.selfTests.tests[testId]
+	service.selfTests.mu.Unlock()
+
+	if !ok {
+		return SelfTestResult{}, errSelfTestNotFound
+	}
+
+	return test.snapshot(), nil
+}
+
+// runSelfTest runs all of the steps of the self test
+func (service *Service) runSelfTest(test *selfTest, method Method, identifier acme.Identifier) {
+	success := false
+
+	defer func() {
+		test.mu.Lock()
+		test.result.Complete = true
+		test.result.Success = success
+		test.result.FinishedAt = int(time.Now().Unix())
+		test.mu.Unlock()
+
+		close(test.done)
+	}()
+
+	// throwaway key and token
+	var key acme.AccountKey
+	var token, resourceName, resourceContent string
+	err := test.runStep(selfTestStepGenerate, func() (string, error) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		key = acme.AccountKey{Key: privateKey}
+
+		tokenBytes := make([]byte, 32)
+		_, err = rand.Read(tokenBytes)
+		if err != nil {
+			return "", err
+		}
+		token = base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+		resourceName, resourceContent, err = service.resource(identifier, method, key, token)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("resource name: %s", resourceName), nil
+	})
+	if err != nil {
+		return
+	}
+
+	// provision
+	err = test.runStep(selfTestStepProvision, func() (string, error) {
+		return "", service.provisionResource(service.shutdownContext, identifier, method, resourceName, resourceContent, noOrder)
+	})
+
+	// always deprovision, even if provisioning failed
+	defer func() {
+		_ = test.runStep(selfTestStepDeprovision, func() (string, error) {
+			return "", service.deprovisionResource(identifier, method, resourceName, resourceContent, noOrder)
+		})
+	}()
+
+	if err != nil {
+		return
+	}
+
+	// verify
+	err = test.runStep(selfTestStepVerify, func() (string, error) {
+		switch method.ChallengeType {
+		case acme.ChallengeTypeDns01:
+			return service.selfTestVerifyDns(method, resourceName, resourceContent)
+		case acme.ChallengeTypeHttp01:
+			return service.selfTestVerifyHttp(identifier, token, resourceContent)
+		case acme.ChallengeTypeTlsAlpn01:
+			return service.selfTestVerifyTlsAlpn(identifier, resourceName, resourceContent)
+		default:
+			return "", errUnsupportedMethod
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	success = true
+}
+
+// selfTestVerifyDns uses the dns checker to confirm the record propagated (using
+// the method's timing)
+func (service *Service) selfTestVerifyDns(method Method, resourceName string, resourceContent string) (detail string, err error) {
+	dnsChecker := service.getDnsChecker()
+	if dnsChecker == nil {
+		return "", errDnsCheckerMissing
+	}
+
+	timing := service.timing(method)
+	propagated, err := dnsChecker.CheckTXTWithRetry(service.shutdownContext, resourceName, resourceContent, timing.propagation, timing.thresholds)
+	if err != nil {
+		return "", err
+	}
+	if !propagated {
+		return "", fmt.Errorf("txt record %s did not propagate (waited %s)", resourceName, timing.propagation.MaxWait)
+	}
+
+	return fmt.Sprintf("txt record %s found", resourceName), nil
+}
+
+// selfTestVerifyHttp fetches the http-01 resource the same way the ACME server would
+func (service *Service) selfTestVerifyHttp(identifier acme.Identifier, token string, keyAuth string) (detail string, err error) {
+	host := identifier.Value
+	if strings.Contains(host, ":") {
+		// ipv6
+		host = "[" + host + "]"
+	}
+	url := "http://" + host + "/.well-known/acme-challenge/" + token
+
+	response, err := service.httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s returned status %d", url, response.StatusCode)
+	}
+
+	// ACME servers ignore surrounding whitespace
+	if strings.TrimSpace(string(bodyBytes)) != keyAuth {
+		return "", fmt.Errorf("fetching %s returned unexpected content", url)
+	}
+
+	return fmt.Sprintf("fetched %s", url), nil
+}
+
+// selfTestVerifyTlsAlpn connects to the identifier on port 443 using the acme-tls/1
+// protocol and confirms the validation certificate is served
+func (service *Service) selfTestVerifyTlsAlpn(identifier acme.Identifier, sni string, certAndKeyPem string) (detail string, err error) {
+	// expected cert is the first pem block
+	expectedBlock, _ := pem.Decode([]byte(certAndKeyPem))
+	if expectedBlock == nil {
+		return "", errors.New("failed to decode validation certificate")
+	}
+
+	address := net.JoinHostPort(identifier.Value, "443")
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: selfTestVerifyTimeout}, "tcp", address, &tls.Config{
+		ServerName: sni,
+		NextProtos: []string{acme.TlsAlpn01Protocol},
+		// validation certs are self signed, they're checked below instead
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	if state.NegotiatedProtocol != acme.TlsAlpn01Protocol {
+		return "", fmt.Errorf("%s did not negotiate the %s protocol", address, acme.TlsAlpn01Protocol)
+	}
+
+	if len(state.PeerCertificates) == 0 || string(state.PeerCertificates[0].Raw) != string(expectedBlock.Bytes) {
+		return "", fmt.Errorf("%s did not serve the expected validation certificate", address)
+	}
+
+	return fmt.Sprintf("%s served the validation certificate", address), nil
+}
@@ -9,8 +9,16 @@ import (
 	"legocerthub-backend/pkg/challenges/providers/dns01acmesh"
 	"legocerthub-backend/pkg/challenges/providers/dns01cloudflare"
 	"legocerthub-backend/pkg/challenges/providers/dns01manual"
+	"legocerthub-backend/pkg/challenges/providers/dns01rfc2136"
+	"legocerthub-backend/pkg/challenges/providers/dns01webhook"
+	"legocerthub-backend/pkg/challenges/providers/external"
 	"legocerthub-backend/pkg/challenges/providers/http01internal"
+	"legocerthub-backend/pkg/challenges/providers/http01webroot"
+	"legocerthub-backend/pkg/challenges/providers/tlsalpn01internal"
+	"legocerthub-backend/pkg/encryption"
 	"legocerthub-backend/pkg/httpclient"
+	"legocerthub-backend/pkg/order_events"
+	"legocerthub-backend/pkg/output"
 	"sync"
 
 	"go.uber.org/zap"
@@ -24,6 +32,9 @@ var (
 type App interface {
 	GetLogger() *zap.SugaredLogger
 	GetHttpClient() *httpclient.Client
+	GetCipher() *encryption.Cipher
+	GetOrderEventRecorder() *order_events.Recorder
+	GetOutputter() *output.Service
 	GetAcmeProdService() *acme.Service
 	GetAcmeStagingService() *acme.Service
 	GetDevMode() bool
@@ -31,41 +42,103 @@ type App interface {
 	GetShutdownWaitGroup() *sync.WaitGroup
 }
 
+// Storage interface for storage functions
+type Storage interface {
+	GetAllProviderConfigs() (providerConfigs []ProviderConfig, err error)
+	GetOneProviderConfig(id int) (providerConfig ProviderConfig, err error)
+
+	PostNewProviderConfig(providerConfig ProviderConfig) (id int, err error)
+	PutProviderConfig(providerConfig ProviderConfig) (err error)
+
+	DeleteProviderConfig(id int) (err error)
+
+	GetAllChallengeResources() (resources []ChallengeResource, err error)
+	PostNewChallengeResource(resource ChallengeResource) (id int, err error)
+	DeleteChallengeResource(resource ChallengeResource) (err error)
+
+	GetAllAcmeDnsRegistrations() (registrations []AcmeDnsRegistration, err error)
+	GetAcmeDnsRegistration(acmeDnsAddress string, realDomain string) (registration AcmeDnsRegistration, err error)
+	PostNewAcmeDnsRegistration(registration AcmeDnsRegistration) (id int, err error)
+	DeleteAcmeDnsRegistration(id int) (err error)
+
+	PostNewProviderExecLog(execLog ProviderExecLog) (id int, err error)
+}
+
 // interface for any provider service
 type providerService interface {
 	Provision(resourceName string, resourceContent string) (err error)
 	Deprovision(resourceName string, resourceContent string) (err error)
 }
 
+// interface for provider services that run external programs and return the
+// executions (so the output can be logged with the order). The programs are
+// killed if ctx is canceled.
+type execProviderService interface {
+	ProvisionWithLog(ctx context.Context, resourceName string, resourceContent string) (executions []external.Execution, err error)
+	DeprovisionWithLog(ctx context.Context, resourceName string, resourceContent string) (executions []external.Execution, err error)
+}
+
+// interface for provider services that also need the identifier value
+// (e.g. to decide where to provision the resource)
+type identifierProviderService interface {
+	ProvisionForIdentifier(identifierValue string, resourceName string, resourceContent string) (err error)
+	DeprovisionForIdentifier(identifierValue string, resourceName string, resourceContent string) (err error)
+}
+
 // ConfigProviders holds the challenge provider configs
 type ConfigProviders struct {
-	Http01InternalConfig  http01internal.Config  `yaml:"http_01_internal"`
-	Dns01ManualConfig     dns01manual.Config     `yaml:"dns_01_manual"`
-	Dns01AcmeDnsConfig    dns01acmedns.Config    `yaml:"dns_01_acme_dns"`
-	Dns01AcmeShConfig     dns01acmesh.Config     `yaml:"dns_01_acme_sh"`
-	Dns01CloudflareConfig dns01cloudflare.Config `yaml:"dns_01_cloudflare"`
+	Http01InternalConfig    http01internal.Config    `yaml:"http_01_internal"`
+	Dns01ManualConfig       dns01manual.Config       `yaml:"dns_01_manual"`
+	Dns01AcmeDnsConfig      dns01acmedns.Config      `yaml:"dns_01_acme_dns"`
+	Dns01AcmeShConfig       dns01acmesh.Config       `yaml:"dns_01_acme_sh"`
+	Dns01CloudflareConfig   dns01cloudflare.Config   `yaml:"dns_01_cloudflare"`
+	TlsAlpn01InternalConfig tlsalpn01internal.Config `yaml:"tls_alpn_01_internal"`
+	Dns01Rfc2136Config      dns01rfc2136.Config      `yaml:"dns_01_rfc2136"`
+	Dns01WebhookConfig      dns01webhook.Config      `yaml:"dns_01_webhook"`
+	Http01WebrootConfig     http01webroot.Config     `yaml:"http_01_webroot"`
+	Instances               []ConfigProviderInstance `yaml:"instances"`
 }
 
 // Config holds all of the challenge config
 type Config struct {
 	DnsCheckerConfig dns_checker.Config `yaml:"dns_checker"`
 	ProviderConfigs  ConfigProviders    `yaml:"providers"`
+	DnsAliasConfig   DnsAliasConfig     `yaml:"dns_alias"`
+	BatchDns01       *bool              `yaml:"batch_dns_01"`
+	TimingConfig     TimingConfig       `yaml:"timing"`
 }
 
 // service struct
 type Service struct {
+	app             App
 	shutdownContext context.Context
 	logger          *zap.SugaredLogger
+	httpClient      *httpclient.Client
+	cipher          *encryption.Cipher
+	events          *order_events.Recorder
+	output          *output.Service
 	acmeProd        *acme.Service
 	acmeStaging     *acme.Service
+	dnsCheckerCfg   dns_checker.Config
 	dnsChecker      *dns_checker.Service
+	fileProviders   *ConfigProviders
+	storage         Storage
+	providersMu     sync.RWMutex // protects providers, methods, providersInUse, and dnsChecker
 	providers       map[MethodValue]providerService
 	methods         []Method
+	providersInUse  map[MethodValue]int
+	followCNAME     bool
+	dnsAliases      []DnsAlias
+	batchDns01      bool
+	defaultTiming   methodTiming
+	methodTimings   map[MethodValue]methodTiming
+	selfTests       selfTests
 }
 
 // NewService creates a new service
 func NewService(app App, cfg *Config) (service *Service, err error) {
 	service = new(Service)
+	service.app = app
 
 	// logger
 	service.logger = app.GetLogger()
@@ -73,6 +146,30 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 		return nil, errServiceComponent
 	}
 
+	// http client
+	service.httpClient = app.GetHttpClient()
+	if service.httpClient == nil {
+		return nil, errServiceComponent
+	}
+
+	// cipher for secrets in storage
+	service.cipher = app.GetCipher()
+	if service.cipher == nil {
+		return nil, errServiceComponent
+	}
+
+	// order event recorder
+	service.events = app.GetOrderEventRecorder()
+	if service.events == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
 	// shutdown context
 	service.shutdownContext = app.GetShutdownContext()
 
@@ -86,11 +183,30 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 		return nil, errServiceComponent
 	}
 
+	// dns-01 delegation
+	service.followCNAME = cfg.DnsAliasConfig.FollowCNAME != nil && *cfg.DnsAliasConfig.FollowCNAME
+	service.dnsAliases = cfg.DnsAliasConfig.Aliases
+
+	// order level dns-01 solving
+	service.batchDns01 = cfg.BatchDns01 != nil && *cfg.BatchDns01
+
+	// propagation and polling timing
+	err = service.configureTiming(cfg.TimingConfig)
+	if err != nil {
+		service.logger.Errorf("failed to configure challenge timing (%s)", err)
+		return nil, err
+	}
+
 	// challenge providers
+	service.fileProviders = &cfg.ProviderConfigs
 	service.providers = make(map[MethodValue]providerService)
+	service.providersInUse = make(map[MethodValue]int)
+
+	// app passed to the providers (includes the acme-dns registrar)
+	pApp := service.providerApp()
 
 	// http-01 internal challenge server
-	http01Internal, err := http01internal.NewService(app, &cfg.ProviderConfigs.Http01InternalConfig)
+	http01Internal, err := http01internal.NewService(pApp, &cfg.ProviderConfigs.Http01InternalConfig)
 	if err != nil {
 		service.logger.Errorf("failed to configure http 01 internal (%s)", err)
 		return nil, err
@@ -100,7 +216,7 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 	}
 
 	// dns-01 manual external scripts
-	dns01Manual, err := dns01manual.NewService(app, &cfg.ProviderConfigs.Dns01ManualConfig)
+	dns01Manual, err := dns01manual.NewService(pApp, &cfg.ProviderConfigs.Dns01ManualConfig)
 	if err != nil {
 		service.logger.Errorf("failed to configure dns 01 manual (%s)", err)
 		return nil, err
@@ -110,7 +226,7 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 	}
 
 	// dns-01 acme-dns challenge service
-	dns01AcmeDns, err := dns01acmedns.NewService(app, &cfg.ProviderConfigs.Dns01AcmeDnsConfig)
+	dns01AcmeDns, err := dns01acmedns.NewService(pApp, &cfg.ProviderConfigs.Dns01AcmeDnsConfig)
 	if err != nil {
 		service.logger.Errorf("failed to configure dns 01 acme-dns (%s)", err)
 		return nil, err
@@ -120,7 +236,7 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 	}
 
 	// dns-01 acme.sh script service
-	dns01AcmeSh, err := dns01acmesh.NewService(app, &cfg.ProviderConfigs.Dns01AcmeShConfig)
+	dns01AcmeSh, err := dns01acmesh.NewService(pApp, &cfg.ProviderConfigs.Dns01AcmeShConfig)
 	if err != nil {
 		service.logger.Errorf("failed to configure dns 01 acme.sh (%s)", err)
 		return nil, err
@@ -130,7 +246,7 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 	}
 
 	// dns-01 cloudflare challenge service
-	dns01Cloudflare, err := dns01cloudflare.NewService(app, &cfg.ProviderConfigs.Dns01CloudflareConfig)
+	dns01Cloudflare, err := dns01cloudflare.NewService(pApp, &cfg.ProviderConfigs.Dns01CloudflareConfig)
 	if err != nil {
 		service.logger.Errorf("failed to configure dns 01 cloudflare (%s)", err)
 		return nil, err
@@ -139,29 +255,111 @@ func NewService(app App, cfg *Config) (service *Service, err error) {
 		service.providers[methodValueDns01Cloudflare] = dns01Cloudflare
 	}
 
+	// tls-alpn-01 internal challenge server
+	tlsAlpn01Internal, err := tlsalpn01internal.NewService(pApp, &cfg.ProviderConfigs.TlsAlpn01InternalConfig)
+	if err != nil {
+		service.logger.Errorf("failed to configure tls-alpn 01 internal (%s)", err)
+		return nil, err
+	}
+	if tlsAlpn01Internal != nil {
+		service.providers[methodValueTlsAlpn01Internal] = tlsAlpn01Internal
+	}
+
+	// dns-01 rfc2136 dynamic update service
+	dns01Rfc2136, err := dns01rfc2136.NewService(pApp, &cfg.ProviderConfigs.Dns01Rfc2136Config)
+	if err != nil {
+		service.logger.Errorf("failed to configure dns 01 rfc2136 (%s)", err)
+		return nil, err
+	}
+	if dns01Rfc2136 != nil {
+		service.providers[methodValueDns01Rfc2136] = dns01Rfc2136
+	}
+
+	// dns-01 webhook service
+	dns01Webhook, err := dns01webhook.NewService(pApp, &cfg.ProviderConfigs.Dns01WebhookConfig)
+	if err != nil {
+		service.logger.Errorf("failed to configure dns 01 webhook (%s)", err)
+		return nil, err
+	}
+	if dns01Webhook != nil {
+		service.providers[methodValueDns01Webhook] = dns01Webhook
+	}
+
+	// http-01 webroot files
+	http01Webroot, err := http01webroot.NewService(pApp, &cfg.ProviderConfigs.Http01WebrootConfig)
+	if err != nil {
+		service.logger.Errorf("failed to configure http 01 webroot (%s)", err)
+		return nil, err
+	}
+	if http01Webroot != nil {
+		service.providers[methodValueHttp01Webroot] = http01Webroot
+	}
+
+	// named provider instances
+	err = service.configureFileInstances()
+	if err != nil {
+		service.logger.Errorf("failed to configure challenge provider instances (%s)", err)
+		return nil, err
+	}
+
 	// end challenge providers
 
 	// configure methods (list of all, properly flagged as enabled or not)
-	err = service.configureMethods()
+	// Note: at least one enabled method is required, but that isn't checked until
+	// stored providers are loaded (see: ConfigureStorage)
+	service.methods = buildMethods(service.providers)
+
+	// configure dns checker service (if any enabled Method is a DNS method)
+	service.dnsCheckerCfg = cfg.DnsCheckerConfig
+	err = service.configureDnsChecker()
 	if err != nil {
-		service.logger.Errorf("failed to configure challenge methods (%s)", err)
 		return nil, err
 	}
 
-	// configure dns checker service (if any enabled Method is a DNS method)
-	// Fixes https://github.com/gregtwallace/legocerthub/issues/6
-	for i := range service.methods {
-		if service.methods[i].Enabled && service.methods[i].ChallengeType == acme.ChallengeTypeDns01 {
+	// provider self tests
+	service.selfTests.tests = make(map[string]*selfTest)
+
+	return service, nil
+}
+
+// configureDnsChecker configures the dns checker service if any enabled Method is
+// a DNS method and the checker is not already configured
+// Fixes https://github.com/gregtwallace/legocerthub/issues/6
+func (service *Service) configureDnsChecker() (err error) {
+	service.providersMu.Lock()
+	defer service.providersMu.Unlock()
+
+	return service.configureDnsCheckerLocked(service.methods)
+}
+
+// configureDnsCheckerLocked configures the dns checker service if any of the
+// enabled methods is a DNS method and the checker is not already configured. The
+// caller must hold providersMu for writing.
+func (service *Service) configureDnsCheckerLocked(methods []Method) (err error) {
+	if service.dnsChecker != nil {
+		return nil
+	}
+
+	for i := range methods {
+		if methods[i].Enabled && methods[i].ChallengeType == acme.ChallengeTypeDns01 {
 			// enable checker
-			service.dnsChecker, err = dns_checker.NewService(app, cfg.DnsCheckerConfig)
+			service.dnsChecker, err = dns_checker.NewService(service.app, service.dnsCheckerCfg)
 			if err != nil {
 				service.logger.Errorf("failed to configure dns checker (%s)", err)
-				return nil, err
+				return err
 			}
 			// once found one Enabled + DNS, done
 			break
 		}
 	}
 
-	return service, nil
+	return nil
+}
+
+// getDnsChecker returns the dns checker service, or nil if it isn't configured
+func (service *Service) getDnsChecker() *dns_checker.Service {
+	service.providersMu.RLock()
+	defer service.providersMu.RUnlock()
+
+	return service.dnsChecker
 }
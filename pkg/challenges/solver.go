@@ -1,20 +1,34 @@
 package challenges
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"legocerthub-backend/pkg/acme"
-	"time"
+	"legocerthub-backend/pkg/order_events"
 )
 
 var (
 	errChallengeRetriesExhausted = errors.New("challenge failed (out of retries)")
 	errChallengeTypeNotFound     = errors.New("intended challenge type not found")
+	errSolveShutdown             = errors.New("challenge solving canceled due to shutdown")
+	errSolveCanceled             = errors.New("challenge solving canceled")
 )
 
+// canceledErr returns the error for solving that was canceled (ctx is done),
+// depending on if the app is shutting down
+func (service *Service) canceledErr() error {
+	if service.shutdownContext.Err() != nil {
+		return errSolveShutdown
+	}
+	return errSolveCanceled
+}
+
 // Solve accepts a slice of challenges from an authorization and solves the specific challenge
 // specified by the method. Valid or invalid status is returned.  An error is returned if can't resolve
-// a valid or invalid state.
-func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Challenge, method Method, key acme.AccountKey, isStaging bool) (status string, err error) {
+// a valid or invalid state. Any external provider executions are logged with the orderId.
+// Canceling ctx stops solving, but the resource is still deprovisioned.
+func (service *Service) Solve(ctx context.Context, identifier acme.Identifier, challenges []acme.Challenge, method Method, key acme.AccountKey, isStaging bool, orderId int) (status string, err error) {
 	var challenge acme.Challenge
 	found := false
 
@@ -29,13 +43,20 @@ func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Chal
 		return "", errChallengeTypeNotFound
 	}
 
+	// hold the provider until done (so it isn't replaced mid challenge)
+	release, err := service.useProvider(method.Value)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	// provision the needed resource for validation and defer deprovisioning
-	err = service.Provision(identifier, method, key, challenge.Token)
+	err = service.Provision(ctx, identifier, method, key, challenge.Token, orderId)
 	// do error check after Deprovision to ensure any records that were created
 	// get cleaned up, even if Provisioning errored.
 
 	defer func() {
-		err := service.Deprovision(identifier, method, key, challenge.Token)
+		err := service.Deprovision(identifier, method, key, challenge.Token, orderId)
 		if err != nil {
 			service.logger.Error(err)
 		}
@@ -58,41 +79,55 @@ func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Chal
 		acmeService = service.acmeProd
 	}
 
+	// don't answer the challenge if canceled while provisioning
+	if ctx.Err() != nil {
+		return "", service.canceledErr()
+	}
+
 	// inform ACME that the challenge is ready
 	_, err = acmeService.ValidateChallenge(challenge.Url, key)
+	service.events.Record(orderId, order_events.TypeChallengeAnswered, identifier.Value, string(method.ChallengeType), err)
 	if err != nil {
 		return "", err
 	}
 
-	// monitor for processing to complete (max 5 tries, 20 seconds apart each)
-	for i := 1; i <= 5; i++ {
-		// sleep to allow ACME time to process
-		// cancel/error if shutdown is called
-		select {
-		case <-service.shutdownContext.Done():
-			// cancel/error if shutting down
-			return "", errors.New("cloudflare dns provisioning canceled due to shutdown")
-
-		case <-time.After(20 * time.Second):
-			// sleep and retry
-		}
-
+	// monitor for processing to complete
+	done, err := service.timing(method).poll.Run(ctx, func() (bool, error) {
 		// get challenge and check for error or final Statuses
 		challenge, err = acmeService.GetChallenge(challenge.Url, key)
 		if err != nil {
-			return "", err
+			return false, err
 		}
 
-		// return Status if it has reached a final status
-		if challenge.Status == "valid" {
-			return challenge.Status, nil
-		} else if challenge.Status == "invalid" {
+		// done if it has reached a final status
+		if challenge.Status == "invalid" {
 			service.logger.Debug(challenge.Error)
-			return challenge.Status, nil
 		}
-		// else repeat loop
+		return challenge.Status == "valid" || challenge.Status == "invalid", nil
+	})
+	if err != nil {
+		// cancel/error if canceled or shutting down
+		if ctx.Err() != nil {
+			err = service.canceledErr()
+		}
+	} else if !done {
+		// polling ended without reaching valid or invalid Status
+		err = errChallengeRetriesExhausted
+	}
+	service.events.Record(orderId, order_events.TypeChallengeCompleted, identifier.Value, challengeEventDetail(challenge), err)
+	if err != nil {
+		return "", err
+	}
+
+	return challenge.Status, nil
+}
+
+// challengeEventDetail is the detail of a challenge's completed order event (its
+// status and, if it failed, the acme error)
+func challengeEventDetail(challenge acme.Challenge) string {
+	if challenge.Error != nil {
+		return fmt.Sprintf("%s (%s)", challenge.Status, challenge.Error)
 	}
 
-	// loop ended without reaching valid or invalid Status
-	return "", errChallengeRetriesExhausted
+	return challenge.Status
 }
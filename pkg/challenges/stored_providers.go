@@ -0,0 +1,206 @@
+package challenges
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/challenges/providers/dns01acmedns"
+	"legocerthub-backend/pkg/challenges/providers/dns01acmesh"
+	"legocerthub-backend/pkg/challenges/providers/dns01cloudflare"
+	"legocerthub-backend/pkg/challenges/providers/dns01manual"
+	"legocerthub-backend/pkg/challenges/providers/dns01rfc2136"
+	"legocerthub-backend/pkg/challenges/providers/dns01webhook"
+	"legocerthub-backend/pkg/challenges/providers/http01webroot"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errProviderTypeNotManageable = errors.New("challenge provider type can not be managed at runtime (configure it in config.yaml)")
+
+// storedProviderType describes a provider type that can be configured at runtime
+// (i.e. stored in the db). Providers that run their own servers (e.g. http-01
+// internal) can only be configured in the config file.
+type storedProviderType struct {
+	// newService creates the provider from a yaml (or json) config. If the config
+	// disables the provider, nil is returned.
+	newService func(app providerApp, config []byte) (providerService, error)
+	// fileConfig returns the provider's config from the config file
+	fileConfig func(cfg *ConfigProviders) any
+}
+
+// setDefault sets the pointer to a pointer to def if it is nil (i.e. the config
+// did not specify a value). Stored providers are enabled unless their config
+// explicitly disables them.
+func setDefault[T any](p **T, def T) {
+	if *p == nil {
+		*p = &def
+	}
+}
+
+// storedProviderTypes are the provider types that can be configured at runtime
+var storedProviderTypes = map[MethodValue]storedProviderType{
+	methodValueDns01Manual: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01manual.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			setDefault(&cfg.TimeoutSeconds, 120)
+			setDefault(&cfg.Protocol, "args")
+			p, err := dns01manual.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01ManualConfig },
+	},
+	methodValueDns01AcmeDns: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01acmedns.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			setDefault(&cfg.HostAddress, "")
+			setDefault(&cfg.AutoRegister, false)
+			p, err := dns01acmedns.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01AcmeDnsConfig },
+	},
+	methodValueDns01AcmeSh: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01acmesh.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			setDefault(&cfg.TimeoutSeconds, 120)
+			p, err := dns01acmesh.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01AcmeShConfig },
+	},
+	methodValueDns01Cloudflare: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01cloudflare.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			p, err := dns01cloudflare.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01CloudflareConfig },
+	},
+	methodValueDns01Rfc2136: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01rfc2136.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			p, err := dns01rfc2136.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01Rfc2136Config },
+	},
+	methodValueDns01Webhook: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := dns01webhook.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			setDefault(&cfg.Url, "")
+			setDefault(&cfg.TimeoutSeconds, 10)
+			setDefault(&cfg.Retries, 2)
+			p, err := dns01webhook.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Dns01WebhookConfig },
+	},
+	methodValueHttp01Webroot: {
+		newService: func(app providerApp, config []byte) (providerService, error) {
+			cfg := http01webroot.Config{}
+			if err := yaml.Unmarshal(config, &cfg); err != nil {
+				return nil, err
+			}
+			setDefault(&cfg.Enable, true)
+			setDefault(&cfg.FileMode, "0644")
+			setDefault(&cfg.DirMode, "0755")
+			setDefault(&cfg.OwnerUid, -1)
+			setDefault(&cfg.OwnerGid, -1)
+			p, err := http01webroot.NewService(app, &cfg)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			return p, nil
+		},
+		fileConfig: func(cfg *ConfigProviders) any { return cfg.Http01WebrootConfig },
+	},
+}
+
+// newStoredProvider creates a provider of the specified type (or instance of the
+// type) using the config (decrypting any encrypted secrets)
+func (service *Service) newStoredProvider(methodValue MethodValue, config []byte) (providerService, error) {
+	providerType, ok := storedProviderTypes[methodValue.providerType()]
+	if !ok {
+		return nil, errProviderTypeNotManageable
+	}
+
+	config, err := decryptConfigSecrets(service.cipher, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerType.newService(service.providerApp(), config)
+}
+
+// newFileProvider creates a provider of the specified type (or instance of the
+// type) using the config file. If a named instance is not in the config file, nil
+// is returned.
+func (service *Service) newFileProvider(methodValue MethodValue) (providerService, error) {
+	providerType, ok := storedProviderTypes[methodValue.providerType()]
+	if !ok {
+		return nil, errProviderTypeNotManageable
+	}
+
+	// round trip the file config through yaml to reuse newService
+	var fileConfig any
+	if methodValue.instanceName() == "" {
+		fileConfig = providerType.fileConfig(service.fileProviders)
+	} else {
+		for i := range service.fileProviders.Instances {
+			if service.fileProviders.Instances[i].value() == methodValue {
+				fileConfig = &service.fileProviders.Instances[i].Config
+				break
+			}
+		}
+		if fileConfig == nil {
+			return nil, nil
+		}
+	}
+
+	config, err := yaml.Marshal(fileConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerType.newService(service.providerApp(), config)
+}
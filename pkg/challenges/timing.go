@@ -0,0 +1,169 @@
+package challenges
+
+import (
+	"errors"
+	"fmt"
+	"legocerthub-backend/pkg/challenges/dns_checker"
+	"time"
+)
+
+var errTimingInvalid = errors.New("challenge timing config is invalid")
+
+// TimingConfig holds the challenge timing config. Default applies to all methods
+// and Methods overrides any of the settings for specific methods. Named instances
+// use their own override if there is one, otherwise their provider type's.
+type TimingConfig struct {
+	Default Timing                 `yaml:"default"`
+	Methods map[MethodValue]Timing `yaml:"methods"`
+}
+
+// Timing configures waiting for dns-01 records to propagate and for the ACME
+// server to process challenges
+type Timing struct {
+	// dns-01 propagation checking
+	PropagationInitialDelaySeconds      *int     `yaml:"propagation_initial_delay_seconds"`
+	PropagationIntervalSeconds          *int     `yaml:"propagation_interval_seconds"`
+	PropagationBackoffFactor            *float64 `yaml:"propagation_backoff_factor"`
+	PropagationIntervalIncrementSeconds *int     `yaml:"propagation_interval_increment_seconds"`
+	PropagationMaxWaitSeconds           *int     `yaml:"propagation_max_wait_seconds"`
+	PropagationRequirement              *float64 `yaml:"propagation_requirement"`
+	FunctioningRequirement              *float64 `yaml:"functioning_requirement"`
+	// polling the ACME server for the challenge's status
+	PollInitialDelaySeconds      *int     `yaml:"poll_initial_delay_seconds"`
+	PollIntervalSeconds          *int     `yaml:"poll_interval_seconds"`
+	PollBackoffFactor            *float64 `yaml:"poll_backoff_factor"`
+	PollIntervalIncrementSeconds *int     `yaml:"poll_interval_increment_seconds"`
+	PollMaxWaitSeconds           *int     `yaml:"poll_max_wait_seconds"`
+}
+
+// defaultTiming is used for any value that isn't configured
+var defaultTiming = methodTiming{
+	// 10 tries, waiting 15 seconds longer before each retry (i.e. 15s, 30s,
+	// 45s, ...), the last try at 675 seconds
+	propagation: dns_checker.Schedule{
+		InitialDelay:      0,
+		Interval:          15 * time.Second,
+		BackoffFactor:     1.0,
+		IntervalIncrement: 15 * time.Second,
+		MaxWait:           675 * time.Second,
+	},
+	thresholds: dns_checker.Thresholds{
+		Propagation: 1.0,
+		Functioning: 0.5,
+	},
+	poll: dns_checker.Schedule{
+		InitialDelay:  20 * time.Second,
+		Interval:      20 * time.Second,
+		BackoffFactor: 1.0,
+		MaxWait:       100 * time.Second,
+	},
+}
+
+// methodTiming is a method's resolved timing
+type methodTiming struct {
+	propagation dns_checker.Schedule
+	thresholds  dns_checker.Thresholds
+	poll        dns_checker.Schedule
+}
+
+// apply returns a copy of mt with any values set in timing replacing mt's values
+func (mt methodTiming) apply(timing Timing) methodTiming {
+	seconds := func(d *time.Duration, s *int) {
+		if s != nil {
+			*d = time.Duration(*s) * time.Second
+		}
+	}
+	float := func(f *float64, v *float64) {
+		if v != nil {
+			*f = *v
+		}
+	}
+
+	seconds(&mt.propagation.InitialDelay, timing.PropagationInitialDelaySeconds)
+	seconds(&mt.propagation.Interval, timing.PropagationIntervalSeconds)
+	float(&mt.propagation.BackoffFactor, timing.PropagationBackoffFactor)
+	seconds(&mt.propagation.IntervalIncrement, timing.PropagationIntervalIncrementSeconds)
+	seconds(&mt.propagation.MaxWait, timing.PropagationMaxWaitSeconds)
+	float(&mt.thresholds.Propagation, timing.PropagationRequirement)
+	float(&mt.thresholds.Functioning, timing.FunctioningRequirement)
+	seconds(&mt.poll.InitialDelay, timing.PollInitialDelaySeconds)
+	seconds(&mt.poll.Interval, timing.PollIntervalSeconds)
+	float(&mt.poll.BackoffFactor, timing.PollBackoffFactor)
+	seconds(&mt.poll.IntervalIncrement, timing.PollIntervalIncrementSeconds)
+	seconds(&mt.poll.MaxWait, timing.PollMaxWaitSeconds)
+
+	return mt
+}
+
+// validate returns an error if any of the timing values are unusable
+func (mt methodTiming) validate() error {
+	for _, schedule := range []dns_checker.Schedule{mt.propagation, mt.poll} {
+		if schedule.InitialDelay < 0 || schedule.Interval <= 0 || schedule.BackoffFactor < 1 || schedule.IntervalIncrement < 0 || schedule.MaxWait < schedule.InitialDelay {
+			return errors.New("delays and increments can't be negative, intervals must be positive, backoff factors must be at least 1, and max wait can't be less than the initial delay")
+		}
+	}
+
+	if mt.thresholds.Propagation <= 0 || mt.thresholds.Propagation > 1 || mt.thresholds.Functioning <= 0 || mt.thresholds.Functioning > 1 {
+		return errors.New("requirements must be greater than 0 and no more than 1")
+	}
+
+	return nil
+}
+
+// configureTiming resolves and validates the default timing and each method's
+// timing
+func (service *Service) configureTiming(cfg TimingConfig) error {
+	service.defaultTiming = defaultTiming.apply(cfg.Default)
+	err := service.defaultTiming.validate()
+	if err != nil {
+		return fmt.Errorf("%w: default (%s)", errTimingInvalid, err)
+	}
+
+	service.methodTimings = make(map[MethodValue]methodTiming, len(cfg.Methods))
+	for value := range cfg.Methods {
+		// instances are based on their type's timing
+		base := service.defaultTiming
+		if typeTiming, ok := cfg.Methods[value.providerType()]; ok && value.instanceName() != "" {
+			base = base.apply(typeTiming)
+		}
+
+		mt := base.apply(cfg.Methods[value])
+		err = mt.validate()
+		if err != nil {
+			return fmt.Errorf("%w: %s (%s)", errTimingInvalid, value, err)
+		}
+		service.methodTimings[value] = mt
+	}
+
+	return nil
+}
+
+// timing returns the method's timing
+func (service *Service) timing(method Method) methodTiming {
+	if mt, ok := service.methodTimings[method.Value]; ok {
+		return mt
+	}
+	if mt, ok := service.methodTimings[method.Value.providerType()]; ok {
+		return mt
+	}
+
+	return service.defaultTiming
+}
+
+// batchTiming returns the timing to use for a batch of methods. The slowest
+// method's (i.e. longest max wait) propagation and poll timings are used.
+func (service *Service) batchTiming(methods []Method) methodTiming {
+	var batch methodTiming
+	for i, method := range methods {
+		mt := service.timing(method)
+		if i == 0 || mt.propagation.MaxWait > batch.propagation.MaxWait {
+			batch.propagation = mt.propagation
+			batch.thresholds = mt.thresholds
+		}
+		if i == 0 || mt.poll.MaxWait > batch.poll.MaxWait {
+			batch.poll = mt.poll
+		}
+	}
+
+	return batch
+}
@@ -0,0 +1,120 @@
+package challenges
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/storage"
+	"time"
+)
+
+// Provisioned challenge resources are saved in storage until they are
+// deprovisioned. If the app stops (e.g. crashes) in between, the resources are
+// left behind with the provider. On start, any resources still in storage are
+// swept (deprovisioned with the provider that provisioned them).
+
+// ChallengeResource is a provisioned challenge resource that is saved in
+// storage until it is deprovisioned
+type ChallengeResource struct {
+	ID              int
+	MethodValue     MethodValue
+	IdentifierType  string
+	IdentifierValue string
+	ResourceName    string
+	ResourceContent string
+	CreatedAt       int
+}
+
+// isTrackedMethod returns false for methods whose resources can't outlive the
+// app (the internal servers only hold resources in memory)
+func isTrackedMethod(method Method) bool {
+	providerType := method.Value.providerType()
+	return providerType != methodValueHttp01Internal && providerType != methodValueTlsAlpn01Internal
+}
+
+// trackResource saves the provisioned resource to storage. If saving fails, the
+// error is logged but provisioning continues (the resource just won't be swept).
+func (service *Service) trackResource(identifier acme.Identifier, method Method, resourceName string, resourceContent string) {
+	// storage isn't configured until the app's storage is open
+	if service.storage == nil || !isTrackedMethod(method) {
+		return
+	}
+
+	_, err := service.storage.PostNewChallengeResource(ChallengeResource{
+		MethodValue:     method.Value,
+		IdentifierType:  string(identifier.Type),
+		IdentifierValue: identifier.Value,
+		ResourceName:    resourceName,
+		ResourceContent: resourceContent,
+		CreatedAt:       int(time.Now().Unix()),
+	})
+	if err != nil {
+		service.logger.Errorf("failed to save challenge resource %s to storage (%s)", resourceName, err)
+	}
+}
+
+// untrackResource removes the deprovisioned resource from storage
+func (service *Service) untrackResource(method Method, resourceName string, resourceContent string) {
+	if service.storage == nil || !isTrackedMethod(method) {
+		return
+	}
+
+	err := service.storage.DeleteChallengeResource(ChallengeResource{
+		MethodValue:     method.Value,
+		ResourceName:    resourceName,
+		ResourceContent: resourceContent,
+	})
+	// no record is fine (e.g. saving failed or the record predates storage)
+	if err != nil && !errors.Is(err, storage.ErrNoRecord) {
+		service.logger.Errorf("failed to remove challenge resource %s from storage (%s)", resourceName, err)
+	}
+}
+
+// sweepResources deprovisions any resources that were left in storage (i.e.
+// they were provisioned but never deprovisioned). The sweep finishes before
+// returning, so it completes before any orders are worked (and can't remove a
+// resource a new order just provisioned). Resources that fail to deprovision
+// remain in storage to be tried again on the next start.
+func (service *Service) sweepResources() error {
+	resources, err := service.storage.GetAllChallengeResources()
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	service.logger.Infof("found %d orphaned challenge resource(s), deprovisioning", len(resources))
+
+	for _, resource := range resources {
+		// stop if shutting down
+		if service.shutdownContext.Err() != nil {
+			return nil
+		}
+
+		service.sweepResource(resource)
+	}
+
+	return nil
+}
+
+// sweepResource deprovisions one orphaned resource with its method's provider
+func (service *Service) sweepResource(resource ChallengeResource) {
+	method := service.MethodByStorageValue(resource.MethodValue)
+
+	// hold the provider while deprovisioning
+	release, err := service.useProvider(resource.MethodValue)
+	if err != nil {
+		service.logger.Errorf("can't deprovision orphaned challenge resource %s (method %s is not available, will retry on next start)", resource.ResourceName, resource.MethodValue)
+		return
+	}
+	defer release()
+
+	identifier := acme.ParseIdentifier(resource.IdentifierType, resource.IdentifierValue)
+	err = service.deprovisionResource(identifier, method, resource.ResourceName, resource.ResourceContent, noOrder)
+	if err != nil {
+		service.logger.Errorf("failed to deprovision orphaned challenge resource %s with %s (will retry on next start) (%s)", resource.ResourceName, resource.MethodValue, err)
+		return
+	}
+
+	service.logger.Infof("deprovisioned orphaned challenge resource %s (content: %s) with %s", resource.ResourceName, resource.ResourceContent, resource.MethodValue)
+}
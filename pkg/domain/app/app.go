@@ -13,7 +13,9 @@ import (
 	"legocerthub-backend/pkg/domain/download"
 	"legocerthub-backend/pkg/domain/orders"
 	"legocerthub-backend/pkg/domain/private_keys"
+	"legocerthub-backend/pkg/encryption"
 	"legocerthub-backend/pkg/httpclient"
+	"legocerthub-backend/pkg/order_events"
 	"legocerthub-backend/pkg/output"
 	"legocerthub-backend/pkg/storage/sqlite"
 	"sync"
@@ -32,6 +34,9 @@ const configVersion = 0
 // data storage root
 const dataStoragePath = "./data"
 
+// key file for encrypting secrets saved in storage
+const encryptionKeyFile = dataStoragePath + "/encryption.key"
+
 // api path
 const apiUrlPath = "/api"
 
@@ -43,6 +48,8 @@ type Application struct {
 	shutdownWaitgroup *sync.WaitGroup
 	httpsCert         *datatypes.SafeCert
 	httpClient        *httpclient.Client
+	cipher            *encryption.Cipher
+	orderEvents       *order_events.Recorder
 	output            *output.Service
 	router            *httprouter.Router
 	acmeProd          *acme.Service
@@ -102,6 +109,14 @@ func (app *Application) GetHttpClient() *httpclient.Client {
 	return app.httpClient
 }
 
+func (app *Application) GetCipher() *encryption.Cipher {
+	return app.cipher
+}
+
+func (app *Application) GetOrderEventRecorder() *order_events.Recorder {
+	return app.orderEvents
+}
+
 func (app *Application) GetOutputter() *output.Service {
 	return app.output
 }
@@ -111,6 +126,9 @@ func (app *Application) GetChallengesService() *challenges.Service {
 }
 
 // hacky workaround for storage since can't just combine into one interface
+func (app *Application) GetOrderEventStorage() order_events.Storage {
+	return app.storage
+}
 func (app *Application) GetAuthStorage() auth.Storage {
 	return app.storage
 }
@@ -123,6 +141,9 @@ func (app *Application) GetAccountStorage() acme_accounts.Storage {
 func (app *Application) GetCertificatesStorage() certificates.Storage {
 	return app.storage
 }
+func (app *Application) GetChallengesStorage() challenges.Storage {
+	return app.storage
+}
 func (app *Application) GetOrderStorage() orders.Storage {
 	return app.storage
 }
@@ -8,7 +8,11 @@ import (
 	"legocerthub-backend/pkg/challenges/providers/dns01acmesh"
 	"legocerthub-backend/pkg/challenges/providers/dns01cloudflare"
 	"legocerthub-backend/pkg/challenges/providers/dns01manual"
+	"legocerthub-backend/pkg/challenges/providers/dns01rfc2136"
+	"legocerthub-backend/pkg/challenges/providers/dns01webhook"
 	"legocerthub-backend/pkg/challenges/providers/http01internal"
+	"legocerthub-backend/pkg/challenges/providers/http01webroot"
+	"legocerthub-backend/pkg/challenges/providers/tlsalpn01internal"
 	"legocerthub-backend/pkg/domain/app/updater"
 	"legocerthub-backend/pkg/domain/orders"
 	"os"
@@ -112,11 +116,37 @@ func defaultConfig() (cfg *config) {
 			ValidRemainingDaysThreshold: new(int),
 			RefreshTimeHour:             new(int),
 			RefreshTimeMinute:           new(int),
+			JobRetryMinSeconds:          new(int),
+			JobRetryMaxSeconds:          new(int),
+			JobMaxAttempts:              new(int),
 		},
 		Challenges: challenges.Config{
 			DnsCheckerConfig: dns_checker.Config{
 				// skip_check_wait_seconds defaults to nil
 				// servers are a slice, no need to call new()
+				CheckAuthoritative: new(bool),
+			},
+			DnsAliasConfig: challenges.DnsAliasConfig{
+				FollowCNAME: new(bool),
+				// aliases are a slice, no need to call new()
+			},
+			BatchDns01: new(bool),
+			TimingConfig: challenges.TimingConfig{
+				Default: challenges.Timing{
+					PropagationInitialDelaySeconds:      new(int),
+					PropagationIntervalSeconds:          new(int),
+					PropagationBackoffFactor:            new(float64),
+					PropagationIntervalIncrementSeconds: new(int),
+					PropagationMaxWaitSeconds:           new(int),
+					PropagationRequirement:              new(float64),
+					FunctioningRequirement:              new(float64),
+					PollInitialDelaySeconds:             new(int),
+					PollIntervalSeconds:                 new(int),
+					PollBackoffFactor:                   new(float64),
+					PollIntervalIncrementSeconds:        new(int),
+					PollMaxWaitSeconds:                  new(int),
+				},
+				// methods are a map, no need to call new()
 			},
 			ProviderConfigs: challenges.ConfigProviders{
 				Http01InternalConfig: http01internal.Config{
@@ -126,17 +156,41 @@ func defaultConfig() (cfg *config) {
 				Dns01ManualConfig: dns01manual.Config{
 					Enable: new(bool),
 					// script paths don't have a default
+					TimeoutSeconds: new(int),
+					Protocol:       new(string),
 				},
 				Dns01AcmeDnsConfig: dns01acmedns.Config{
-					Enable:      new(bool),
-					HostAddress: new(string),
+					Enable:       new(bool),
+					HostAddress:  new(string),
+					AutoRegister: new(bool),
 				},
 				Dns01AcmeShConfig: dns01acmesh.Config{
-					Enable: new(bool),
+					Enable:         new(bool),
+					TimeoutSeconds: new(int),
 				},
 				Dns01CloudflareConfig: dns01cloudflare.Config{
 					Enable: new(bool),
 				},
+				TlsAlpn01InternalConfig: tlsalpn01internal.Config{
+					Enable: new(bool),
+					Port:   new(int),
+				},
+				Dns01Rfc2136Config: dns01rfc2136.Config{
+					Enable: new(bool),
+				},
+				Dns01WebhookConfig: dns01webhook.Config{
+					Enable:         new(bool),
+					Url:            new(string),
+					TimeoutSeconds: new(int),
+					Retries:        new(int),
+				},
+				Http01WebrootConfig: http01webroot.Config{
+					Enable:   new(bool),
+					FileMode: new(string),
+					DirMode:  new(string),
+					OwnerUid: new(int),
+					OwnerGid: new(int),
+				},
 			},
 		},
 	}
@@ -174,6 +228,9 @@ func defaultConfig() (cfg *config) {
 	*cfg.Orders.ValidRemainingDaysThreshold = 40
 	*cfg.Orders.RefreshTimeHour = 3
 	*cfg.Orders.RefreshTimeMinute = 12
+	*cfg.Orders.JobRetryMinSeconds = 60
+	*cfg.Orders.JobRetryMaxSeconds = 6 * 60 * 60
+	*cfg.Orders.JobMaxAttempts = 10
 
 	// challenge dns checker services
 	cfg.Challenges.DnsCheckerConfig.DnsServices = []dns_checker.DnsServiceIPPair{
@@ -194,6 +251,29 @@ func defaultConfig() (cfg *config) {
 		},
 	}
 
+	// check authoritative servers instead of the dns services
+	*cfg.Challenges.DnsCheckerConfig.CheckAuthoritative = false
+
+	// dns-01 delegation
+	*cfg.Challenges.DnsAliasConfig.FollowCNAME = false
+
+	// order level dns-01 solving
+	*cfg.Challenges.BatchDns01 = false
+
+	// challenge timing (defaults for all methods)
+	*cfg.Challenges.TimingConfig.Default.PropagationInitialDelaySeconds = 0
+	*cfg.Challenges.TimingConfig.Default.PropagationIntervalSeconds = 15
+	*cfg.Challenges.TimingConfig.Default.PropagationBackoffFactor = 1.0
+	*cfg.Challenges.TimingConfig.Default.PropagationIntervalIncrementSeconds = 15
+	*cfg.Challenges.TimingConfig.Default.PropagationMaxWaitSeconds = 675
+	*cfg.Challenges.TimingConfig.Default.PropagationRequirement = 1.0
+	*cfg.Challenges.TimingConfig.Default.FunctioningRequirement = 0.5
+	*cfg.Challenges.TimingConfig.Default.PollInitialDelaySeconds = 20
+	*cfg.Challenges.TimingConfig.Default.PollIntervalSeconds = 20
+	*cfg.Challenges.TimingConfig.Default.PollBackoffFactor = 1.0
+	*cfg.Challenges.TimingConfig.Default.PollIntervalIncrementSeconds = 0
+	*cfg.Challenges.TimingConfig.Default.PollMaxWaitSeconds = 100
+
 	// challenge providers
 	// http-01-internal
 	*cfg.Challenges.ProviderConfigs.Http01InternalConfig.Enable = true
@@ -201,12 +281,37 @@ func defaultConfig() (cfg *config) {
 
 	// dns-01-manual
 	*cfg.Challenges.ProviderConfigs.Dns01ManualConfig.Enable = false
+	*cfg.Challenges.ProviderConfigs.Dns01ManualConfig.TimeoutSeconds = 120
+	*cfg.Challenges.ProviderConfigs.Dns01ManualConfig.Protocol = "args"
+
+	// dns-01-acmesh
+	*cfg.Challenges.ProviderConfigs.Dns01AcmeShConfig.TimeoutSeconds = 120
 
 	// dns-01-cloudflare
 	*cfg.Challenges.ProviderConfigs.Dns01CloudflareConfig.Enable = false
 
 	// dns-01-acmedns
 	*cfg.Challenges.ProviderConfigs.Dns01AcmeDnsConfig.Enable = false
+	*cfg.Challenges.ProviderConfigs.Dns01AcmeDnsConfig.AutoRegister = false
+
+	// tls-alpn-01-internal
+	*cfg.Challenges.ProviderConfigs.TlsAlpn01InternalConfig.Enable = false
+	*cfg.Challenges.ProviderConfigs.TlsAlpn01InternalConfig.Port = 4070
+
+	// dns-01-rfc2136
+	*cfg.Challenges.ProviderConfigs.Dns01Rfc2136Config.Enable = false
+
+	// dns-01-webhook
+	*cfg.Challenges.ProviderConfigs.Dns01WebhookConfig.Enable = false
+	*cfg.Challenges.ProviderConfigs.Dns01WebhookConfig.TimeoutSeconds = 10
+	*cfg.Challenges.ProviderConfigs.Dns01WebhookConfig.Retries = 2
+
+	// http-01-webroot
+	*cfg.Challenges.ProviderConfigs.Http01WebrootConfig.Enable = false
+	*cfg.Challenges.ProviderConfigs.Http01WebrootConfig.FileMode = "0644"
+	*cfg.Challenges.ProviderConfigs.Http01WebrootConfig.DirMode = "0755"
+	*cfg.Challenges.ProviderConfigs.Http01WebrootConfig.OwnerUid = -1
+	*cfg.Challenges.ProviderConfigs.Http01WebrootConfig.OwnerGid = -1
 
 	// end challenge providers
 
@@ -69,13 +69,31 @@ func (app *Application) routes() http.Handler {
 
 	// orders (for certificates)
 	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/orders/currentvalid", app.orders.GetAllValidCurrentOrders)
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/orders/jobs", app.orders.GetOrderJobs)
+	app.makeSecureHandle(http.MethodDelete, apiUrlPath+"/v1/orders/jobs/:id", app.orders.CancelOrderJob)
 	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.GetCertOrders)
 	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.NewOrder)
 
 	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/download", app.orders.DownloadOneOrder)
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/providerlogs", app.orders.GetOrderProviderLogs)
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/events", app.orders.GetOrderEvents)
 	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid", app.orders.FulfillExistingOrder)
 	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/revoke", app.orders.RevokeOrder)
 
+	// challenges
+	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/challenges/methods/:value/test", app.challenges.TestMethod)
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/challenges/methods/:value/test/:testid", app.challenges.GetMethodTest)
+
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/challenges/providers", app.challenges.GetAllProviderConfigs)
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/challenges/providers/:id", app.challenges.GetOneProviderConfig)
+	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/challenges/providers", app.challenges.PostNewProviderConfig)
+	app.makeSecureHandle(http.MethodPut, apiUrlPath+"/v1/challenges/providers/:id", app.challenges.PutProviderConfig)
+	app.makeSecureHandle(http.MethodDelete, apiUrlPath+"/v1/challenges/providers/:id", app.challenges.DeleteProviderConfig)
+
+	app.makeSecureHandle(http.MethodGet, apiUrlPath+"/v1/challenges/acmedns/registrations", app.challenges.GetAllAcmeDnsRegistrations)
+	app.makeSecureHandle(http.MethodPost, apiUrlPath+"/v1/challenges/acmedns/registrations", app.challenges.PostNewAcmeDnsRegistration)
+	app.makeSecureHandle(http.MethodDelete, apiUrlPath+"/v1/challenges/acmedns/registrations/:id", app.challenges.DeleteAcmeDnsRegistration)
+
 	// download keys and certs
 	app.makeDownloadHandle(http.MethodGet, apiUrlPath+"/v1/download/privatekeys/:name", app.download.DownloadKeyViaHeader)
 	app.makeDownloadHandle(http.MethodGet, apiUrlPath+"/v1/download/certificates/:name", app.download.DownloadCertViaHeader)
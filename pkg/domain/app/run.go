@@ -14,7 +14,9 @@ import (
 	"legocerthub-backend/pkg/domain/download"
 	"legocerthub-backend/pkg/domain/orders"
 	"legocerthub-backend/pkg/domain/private_keys"
+	"legocerthub-backend/pkg/encryption"
 	"legocerthub-backend/pkg/httpclient"
+	"legocerthub-backend/pkg/order_events"
 	"legocerthub-backend/pkg/output"
 	"legocerthub-backend/pkg/storage/sqlite"
 	"net/http"
@@ -235,6 +237,25 @@ func create(ctx context.Context) (*Application, error) {
 	userAgent := fmt.Sprintf("LeGoCertHub/%s (%s; %s)", appVersion, runtime.GOOS, runtime.GOARCH)
 	app.httpClient = httpclient.New(userAgent, *app.config.DevMode)
 
+	// cipher for secrets saved in storage
+	encryptionKey, err := encryption.LoadOrCreateKey(encryptionKeyFile)
+	if err != nil {
+		app.logger.Errorf("failed to load or create encryption key (%s)", err)
+		return app, err
+	}
+	app.cipher, err = encryption.New(encryptionKey)
+	if err != nil {
+		app.logger.Errorf("failed to configure app encryption (%s)", err)
+		return app, err
+	}
+
+	// order event recorder
+	app.orderEvents, err = order_events.NewRecorder(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app order events (%s)", err)
+		return app, err
+	}
+
 	// output service
 	app.output, err = output.NewService(app)
 	if err != nil {
@@ -292,6 +313,20 @@ func create(ctx context.Context) (*Application, error) {
 		return app, err
 	}
 
+	// order events storage (requires storage)
+	err = app.orderEvents.ConfigureStorage(app.GetOrderEventStorage())
+	if err != nil {
+		app.logger.Errorf("failed to configure app order events storage (%s)", err)
+		return app, err
+	}
+
+	// challenges stored provider configs and orphaned resource sweep (requires storage)
+	err = app.challenges.ConfigureStorage(app.GetChallengesStorage())
+	if err != nil {
+		app.logger.Errorf("failed to configure app challenges storage (%s)", err)
+		return app, err
+	}
+
 	// get app's tls cert
 	// if fails, set to nil (will disable https)
 	app.httpsCert, err = app.newAppCert()
@@ -0,0 +1,92 @@
+package authorizations
+
+import (
+	"context"
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/order_events"
+)
+
+// dns01Batch is the pending dns-01 auths of an order that are solved together
+type dns01Batch struct {
+	authUrls         []string
+	identifierValues []string
+	challenges       []challenges.BatchChallenge
+}
+
+// prepareDns01Batch gets each auth and adds the auths that are pending and use
+// a dns-01 method to the batch. Batched auths are added to working and remain
+// there until the batch is solved. All other auths (including ones already
+// being worked or that couldn't be fetched) are returned to be fulfilled
+// individually.
+func (service *Service) prepareDns01Batch(authUrls []string, methods MethodResolver, key acme.AccountKey, isStaging bool, orderId int) (batch dns01Batch, remaining []string) {
+	for _, authUrl := range authUrls {
+		// if already being worked, fulfill individually (which waits on the result)
+		exists, _ := service.working.add(authUrl)
+		if exists {
+			remaining = append(remaining, authUrl)
+			continue
+		}
+
+		var auth acme.Authorization
+		var err error
+		if isStaging {
+			auth, err = service.acmeStaging.GetAuth(authUrl, key)
+		} else {
+			auth, err = service.acmeProd.GetAuth(authUrl, key)
+		}
+		service.events.Record(orderId, order_events.TypeAuthorizationFetched, authIdentifierValue(auth), authEventDetail(authUrl, auth.Status), err)
+
+		// resolve the method for pending auths
+		var method challenges.Method
+		if err == nil && auth.Status == "pending" {
+			method = methods.ChallengeMethodFor(authIdentifierValue(auth))
+		}
+
+		// anything that isn't a pending dns-01 auth is released and fulfilled
+		// individually
+		if err != nil || auth.Status != "pending" || method.ChallengeType != acme.ChallengeTypeDns01 {
+			err = service.working.remove(authUrl)
+			if err != nil {
+				service.logger.Error(err)
+			}
+			remaining = append(remaining, authUrl)
+			continue
+		}
+
+		batch.authUrls = append(batch.authUrls, authUrl)
+		batch.identifierValues = append(batch.identifierValues, authIdentifierValue(auth))
+		batch.challenges = append(batch.challenges, challenges.BatchChallenge{
+			Identifier: auth.Identifier,
+			Challenges: auth.Challenges,
+			Method:     method,
+		})
+	}
+
+	return batch, remaining
+}
+
+// solveDns01Batch solves the batch, caches each auth's result, and removes the
+// auths from working. Statuses are returned in the same order as the batch.
+func (service *Service) solveDns01Batch(ctx context.Context, batch dns01Batch, key acme.AccountKey, isStaging bool, orderId int) (statuses []string, err error) {
+	statuses, err = service.challenges.SolveDns01Batch(ctx, batch.challenges, key, isStaging, orderId)
+
+	for i, authUrl := range batch.authUrls {
+		// cache result
+		if err != nil {
+			service.events.Record(orderId, order_events.TypeAuthorizationCompleted, batch.identifierValues[i], authEventDetail(authUrl, ""), err)
+			service.cache.add(authUrl, "", err)
+		} else {
+			service.events.Record(orderId, order_events.TypeAuthorizationCompleted, batch.identifierValues[i], authEventDetail(authUrl, statuses[i]), nil)
+			service.cache.add(authUrl, statuses[i], nil)
+		}
+
+		// done working
+		removeErr := service.working.remove(authUrl)
+		if removeErr != nil {
+			service.logger.Error(removeErr)
+		}
+	}
+
+	return statuses, err
+}
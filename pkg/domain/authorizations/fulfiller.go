@@ -1,35 +1,65 @@
 package authorizations
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"legocerthub-backend/pkg/acme"
 	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/order_events"
 	"sync"
 )
 
 var errAuthPending = errors.New("one or more auths are still in 'pending' status")
 
-// FulfillAuths attempts to validate each of the auth URLs in the slice of auth URLs. It returns 'valid' Status if all auths were
-// determined to be 'valid'. It returns 'invalid' if any of the auths were determined to be in any state other than valid or pending.
+// MethodResolver returns the challenge method to use to validate an identifier. For
+// wildcard authorizations, the identifier value includes the "*." prefix.
+type MethodResolver interface {
+	ChallengeMethodFor(identifierValue string) challenges.Method
+}
+
+// FulfillAuths attempts to validate each of the auth URLs in the slice of auth URLs. Each auth is validated using the method the
+// methods resolver returns for the auth's identifier. It returns 'valid' Status if all auths were determined to be 'valid'. It
+// returns 'invalid' if any of the auths were determined to be in any state other than valid or pending.
 // It returns an error if any of the auth Statuses could not be determined or if any are still in pending.
-func (service *Service) FulfillAuths(authUrls []string, method challenges.Method, key acme.AccountKey, isStaging bool) (status string, err error) {
+// If dns-01 batching is enabled, the pending dns-01 auths are solved together as a batch.
+// orderId is the order the auths are for (challenge provider executions are logged with it).
+// Canceling ctx cancels solving any of the auths' challenges.
+func (service *Service) FulfillAuths(ctx context.Context, authUrls []string, methods MethodResolver, key acme.AccountKey, isStaging bool, orderId int) (status string, err error) {
 	// aysnc checking the authz for validity
 	var wg sync.WaitGroup
-	wgSize := len(authUrls)
+	wgStatuses := make(chan string, len(authUrls))
+	wgErrors := make(chan error, len(authUrls))
+
+	// solve the batch concurrently with the other auths
+	if service.challenges.BatchDns01() {
+		var batch dns01Batch
+		batch, authUrls = service.prepareDns01Batch(authUrls, methods, key, isStaging, orderId)
+
+		if len(batch.authUrls) > 0 {
+			wg.Add(1)
+			go func(batch dns01Batch, key acme.AccountKey, isStaging bool) {
+				defer wg.Done()
+				statuses, err := service.solveDns01Batch(ctx, batch, key, isStaging, orderId)
+				for i := range statuses {
+					wgStatuses <- statuses[i]
+				}
+				wgErrors <- err
+			}(batch, key, isStaging)
+		}
+	}
 
-	wg.Add(wgSize)
-	wgStatuses := make(chan string, wgSize)
-	wgErrors := make(chan error, wgSize)
+	wg.Add(len(authUrls))
 
 	// fulfill each auth concurrently
 	// TODO: Add context to cancel everything if any auth fails / invalid?
 	for i := range authUrls {
-		go func(authUrl string, method challenges.Method, key acme.AccountKey, isStaging bool) {
+		go func(authUrl string, methods MethodResolver, key acme.AccountKey, isStaging bool) {
 			defer wg.Done()
-			status, err = service.fulfillAuth(authUrl, method, key, isStaging)
+			status, err := service.fulfillAuth(ctx, authUrl, methods, key, isStaging, orderId)
 			wgStatuses <- status
 			wgErrors <- err
-		}(authUrls[i], method, key, isStaging)
+		}(authUrls[i], methods, key, isStaging)
 	}
 
 	// wait for all auths to do their thing
@@ -59,9 +89,9 @@ func (service *Service) FulfillAuths(authUrls []string, method challenges.Method
 	return "valid", nil
 }
 
-// fulfillAuth attempts to validate an auth URL using the specified method. It will either respond from cache
-// or call an authWorker.  An error is returned if the auth status could not be determined.
-func (service *Service) fulfillAuth(authUrl string, method challenges.Method, key acme.AccountKey, isStaging bool) (status string, err error) {
+// fulfillAuth attempts to validate an auth URL using the method resolved for its identifier. It will either respond
+// from cache or call an authWorker.  An error is returned if the auth status could not be determined.
+func (service *Service) fulfillAuth(ctx context.Context, authUrl string, methods MethodResolver, key acme.AccountKey, isStaging bool, orderId int) (status string, err error) {
 	// add authUrl to working and call a worker, if the authUrl is already being worked,
 	// block and return the cached result. If the cached result is an error, try to work
 	// the auth again.
@@ -95,7 +125,7 @@ func (service *Service) fulfillAuth(authUrl string, method challenges.Method, ke
 	}(authUrl, service)
 
 	// work the auth
-	status, err = service.authWorker(authUrl, method, key, isStaging)
+	status, err = service.authWorker(ctx, authUrl, methods, key, isStaging, orderId)
 
 	// cache result &
 	// error check
@@ -110,15 +140,16 @@ func (service *Service) fulfillAuth(authUrl string, method challenges.Method, ke
 
 // authWorker returns the Status of an authorization URL. If the authorization Status is currently 'pending', authWorker attempts to
 // move the authorization to the 'valid' Status.  An error is returned if the Status can't be determined.
-func (service *Service) authWorker(authUrl string, method challenges.Method, key acme.AccountKey, isStaging bool) (status string, err error) {
+func (service *Service) authWorker(ctx context.Context, authUrl string, methods MethodResolver, key acme.AccountKey, isStaging bool, orderId int) (status string, err error) {
 	var auth acme.Authorization
 
 	// PaG the authorization
 	if isStaging {
 		auth, err = service.acmeStaging.GetAuth(authUrl, key)
 	} else {
-		auth, err = service.acmeStaging.GetAuth(authUrl, key)
+		auth, err = service.acmeProd.GetAuth(authUrl, key)
 	}
+	service.events.Record(orderId, order_events.TypeAuthorizationFetched, authIdentifierValue(auth), authEventDetail(authUrl, auth.Status), err)
 	if err != nil {
 		return "", err
 	}
@@ -127,7 +158,11 @@ func (service *Service) authWorker(authUrl string, method challenges.Method, key
 	switch auth.Status {
 	// try to solve a challenge if auth is pending
 	case "pending":
-		auth.Status, err = service.challenges.Solve(auth.Identifier, auth.Challenges, method, key, isStaging)
+		// resolve the method for this auth's identifier
+		method := methods.ChallengeMethodFor(authIdentifierValue(auth))
+
+		auth.Status, err = service.challenges.Solve(ctx, auth.Identifier, auth.Challenges, method, key, isStaging, orderId)
+		service.events.Record(orderId, order_events.TypeAuthorizationCompleted, authIdentifierValue(auth), authEventDetail(authUrl, auth.Status), err)
 		// return error if couldn't solve
 		if err != nil {
 			return "", err
@@ -146,3 +181,23 @@ func (service *Service) authWorker(authUrl string, method challenges.Method, key
 
 	return auth.Status, nil
 }
+
+// authIdentifierValue returns the auth's identifier value (wildcard auths omit the
+// wildcard prefix from the identifier value, so it is added back)
+func authIdentifierValue(auth acme.Authorization) string {
+	if auth.Wildcard {
+		return "*." + auth.Identifier.Value
+	}
+
+	return auth.Identifier.Value
+}
+
+// authEventDetail is the detail of an auth's order events (status is blank if
+// it couldn't be determined)
+func authEventDetail(authUrl string, status string) string {
+	if status == "" {
+		return authUrl
+	}
+
+	return fmt.Sprintf("%s (%s)", status, authUrl)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"legocerthub-backend/pkg/acme"
 	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/order_events"
 
 	"go.uber.org/zap"
 )
@@ -14,6 +15,7 @@ var errServiceComponent = errors.New("necessary authorizations service component
 type App interface {
 	GetLogger() *zap.SugaredLogger
 	GetChallengesService() *challenges.Service
+	GetOrderEventRecorder() *order_events.Recorder
 	GetAcmeProdService() *acme.Service
 	GetAcmeStagingService() *acme.Service
 	GetDevMode() bool
@@ -25,6 +27,7 @@ type Service struct {
 	acmeProd    *acme.Service
 	acmeStaging *acme.Service
 	challenges  *challenges.Service
+	events      *order_events.Recorder
 	working     *working // tracks auths being worked
 	cache       *cache   // tracks results of auths after worked
 }
@@ -55,6 +58,12 @@ func NewService(app App) (service *Service, err error) {
 		return nil, errServiceComponent
 	}
 
+	// order event recorder
+	service.events = app.GetOrderEventRecorder()
+	if service.events == nil {
+		return nil, errServiceComponent
+	}
+
 	// initialize working
 	service.working = newWorking()
 
@@ -17,6 +17,7 @@ type Certificate struct {
 	Subject            string
 	SubjectAltNames    []string
 	ChallengeMethod    challenges.Method
+	ChallengeMethodMap map[string]challenges.Method
 	Organization       string
 	OrganizationalUnit string
 	Country            string
@@ -27,6 +28,9 @@ type Certificate struct {
 	ApiKey             string
 	ApiKeyNew          string
 	ApiKeyViaUrl       bool
+	PreferredRootCN    string
+	RenewalPolicy      RenewalPolicy
+	KeyRotation        KeyRotation
 }
 
 // certificateSummaryResponse is a JSON response containing only
@@ -40,6 +44,7 @@ type certificateSummaryResponse struct {
 	Subject            string                            `json:"subject"`
 	SubjectAltNames    []string                          `json:"subject_alts"`
 	ChallengeMethod    challenges.Method                 `json:"challenge_method"`
+	ChallengeMethodMap map[string]challenges.Method      `json:"challenge_method_map"`
 	ApiKeyViaUrl       bool                              `json:"api_key_via_url"`
 }
 
@@ -68,10 +73,11 @@ func (cert Certificate) summaryResponse() certificateSummaryResponse {
 			Name:      cert.CertificateAccount.Name,
 			IsStaging: cert.CertificateAccount.IsStaging,
 		},
-		Subject:         cert.Subject,
-		SubjectAltNames: cert.SubjectAltNames,
-		ChallengeMethod: cert.ChallengeMethod,
-		ApiKeyViaUrl:    cert.ApiKeyViaUrl,
+		Subject:            cert.Subject,
+		SubjectAltNames:    cert.SubjectAltNames,
+		ChallengeMethod:    cert.ChallengeMethod,
+		ChallengeMethodMap: cert.ChallengeMethodMap,
+		ApiKeyViaUrl:       cert.ApiKeyViaUrl,
 	}
 }
 
@@ -79,15 +85,18 @@ func (cert Certificate) summaryResponse() certificateSummaryResponse {
 // fields that can be returned as JSON
 type certificateDetailedResponse struct {
 	certificateSummaryResponse
-	Organization       string `json:"organization"`
-	OrganizationalUnit string `json:"organizational_unit"`
-	Country            string `json:"country"`
-	State              string `json:"state"`
-	City               string `json:"city"`
-	CreatedAt          int    `json:"created_at"`
-	UpdatedAt          int    `json:"updated_at"`
-	ApiKey             string `json:"api_key"`
-	ApiKeyNew          string `json:"api_key_new,omitempty"`
+	Organization       string        `json:"organization"`
+	OrganizationalUnit string        `json:"organizational_unit"`
+	Country            string        `json:"country"`
+	State              string        `json:"state"`
+	City               string        `json:"city"`
+	CreatedAt          int           `json:"created_at"`
+	UpdatedAt          int           `json:"updated_at"`
+	ApiKey             string        `json:"api_key"`
+	ApiKeyNew          string        `json:"api_key_new,omitempty"`
+	PreferredRootCN    string        `json:"preferred_root_cn"`
+	RenewalPolicy      RenewalPolicy `json:"renewal_policy"`
+	KeyRotation        KeyRotation   `json:"key_rotation"`
 }
 
 func (cert Certificate) detailedResponse(withSensitive bool) certificateDetailedResponse {
@@ -113,6 +122,9 @@ func (cert Certificate) detailedResponse(withSensitive bool) certificateDetailed
 		UpdatedAt:                  cert.UpdatedAt,
 		ApiKey:                     apiKey,
 		ApiKeyNew:                  apiKeyNew,
+		PreferredRootCN:            cert.PreferredRootCN,
+		RenewalPolicy:              cert.RenewalPolicy,
+		KeyRotation:                cert.KeyRotation,
 	}
 }
 
@@ -121,13 +133,13 @@ func (cert *Certificate) NewOrderPayload() acme.NewOrderPayload {
 	var identifiers []acme.Identifier
 
 	// subject is always required and should be first
-	// dns is the only supported type and is hardcoded
-	identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: cert.Subject})
+	// type (dns or ip) is determined by the value
+	identifiers = append(identifiers, acme.NewIdentifier(cert.Subject))
 
 	// add alt names if they exist
 	if cert.SubjectAltNames != nil {
 		for _, name := range cert.SubjectAltNames {
-			identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: name})
+			identifiers = append(identifiers, acme.NewIdentifier(name))
 		}
 	}
 
@@ -136,10 +148,19 @@ func (cert *Certificate) NewOrderPayload() acme.NewOrderPayload {
 	}
 }
 
-// new account info
-// used to return info about valid options when making a new account
+// new cert info
+// used to return info about valid options when making a new cert
 type newCertOptions struct {
 	AvailableKeys             []private_keys.KeySummaryResponse      `json:"private_keys"`
 	UsableAccounts            []acme_accounts.AccountSummaryResponse `json:"acme_accounts"`
 	AvailableChallengeMethods []challenges.Method                    `json:"challenge_methods"`
+	IdentifierMethods         map[string][]challenges.MethodValue    `json:"identifier_challenge_methods"`
+}
+
+// identifierKindExamples maps each kind of identifier to an example value of that
+// kind. These are used to determine which methods can validate each kind.
+var identifierKindExamples = map[string]string{
+	"dns":          "example.com",
+	"dns_wildcard": "*.example.com",
+	"ip":           "192.0.2.1",
 }
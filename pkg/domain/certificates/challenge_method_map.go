@@ -0,0 +1,86 @@
+package certificates
+
+import (
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/validation"
+	"strings"
+)
+
+// A certificate's challenge method map allows identifiers to use a challenge method
+// other than the certificate's (default) ChallengeMethod. Map keys are either an
+// exact identifier (e.g. "www.example.com", "*.example.com", or "192.0.2.1") or a
+// suffix beginning with a period (e.g. ".example.com") which matches any name ending
+// with the suffix. An exact match is always used first, then the longest matching
+// suffix, and finally the certificate's default method.
+
+// methodForIdentifier returns the method to use to validate the specified identifier
+// value, based on the method map and default method
+func methodForIdentifier(value string, defaultMethod challenges.Method, methodMap map[string]challenges.Method) challenges.Method {
+	value = strings.ToLower(value)
+
+	// exact
+	if method, ok := methodMap[value]; ok {
+		return method
+	}
+
+	// longest suffix
+	longestSuffix := ""
+	for key := range methodMap {
+		if strings.HasPrefix(key, ".") && strings.HasSuffix(value, key) && len(key) > len(longestSuffix) {
+			longestSuffix = key
+		}
+	}
+	if longestSuffix != "" {
+		return methodMap[longestSuffix]
+	}
+
+	return defaultMethod
+}
+
+// ChallengeMethodFor returns the challenge method the certificate uses to validate
+// the specified identifier value. Wildcard values should include the "*." prefix.
+func (cert Certificate) ChallengeMethodFor(identifierValue string) challenges.Method {
+	return methodForIdentifier(identifierValue, cert.ChallengeMethod, cert.ChallengeMethodMap)
+}
+
+// methodMapKeyValid returns true if the key is a valid identifier (including
+// wildcard) or a valid suffix
+func methodMapKeyValid(key string) bool {
+	// suffix
+	if strings.HasPrefix(key, ".") {
+		return validation.DomainValid(strings.TrimPrefix(key, "."), false)
+	}
+
+	return validation.DomainOrIPValid(key, true)
+}
+
+// methodMapFromPayload validates a payload's method map and converts it to a map of
+// Methods. If any key or method value is invalid, ok is false.
+func (service *Service) methodMapFromPayload(payloadMap map[string]challenges.MethodValue) (methodMap map[string]challenges.Method, ok bool) {
+	methodMap = make(map[string]challenges.Method, len(payloadMap))
+
+	for key, methodValue := range payloadMap {
+		if !methodMapKeyValid(key) {
+			return nil, false
+		}
+
+		method := service.challenges.MethodByStorageValue(methodValue)
+		if method == challenges.UnknownMethod {
+			return nil, false
+		}
+
+		methodMap[strings.ToLower(key)] = method
+	}
+
+	return methodMap, true
+}
+
+// methodMapValues returns the method map as MethodValues (e.g. for storage)
+func methodMapValues(methodMap map[string]challenges.Method) map[string]challenges.MethodValue {
+	values := make(map[string]challenges.MethodValue, len(methodMap))
+	for key, method := range methodMap {
+		values[key] = method.Value
+	}
+
+	return values
+}
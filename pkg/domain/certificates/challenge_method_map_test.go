@@ -0,0 +1,57 @@
+package certificates
+
+import (
+	"legocerthub-backend/pkg/challenges"
+	"testing"
+)
+
+// TestMethodForIdentifier confirms an exact match is used first, then the longest
+// matching suffix, and then the default method
+func TestMethodForIdentifier(t *testing.T) {
+	defaultMethod := challenges.Method{Value: "default"}
+	methodMap := map[string]challenges.Method{
+		"www.example.com":  {Value: "exact-www"},
+		"*.example.com":    {Value: "exact-wildcard"},
+		"192.0.2.1":        {Value: "exact-ip"},
+		".example.com":     {Value: "suffix-example"},
+		".dev.example.com": {Value: "suffix-dev"},
+	}
+
+	tests := []struct {
+		value string
+		want  challenges.MethodValue
+	}{
+		// exact is used over any suffix
+		{"www.example.com", "exact-www"},
+		{"WWW.Example.COM", "exact-www"},
+		{"*.example.com", "exact-wildcard"},
+		{"192.0.2.1", "exact-ip"},
+		// suffix
+		{"mail.example.com", "suffix-example"},
+		{"a.b.example.com", "suffix-example"},
+		// longest suffix wins
+		{"api.dev.example.com", "suffix-dev"},
+		{"*.dev.example.com", "suffix-dev"},
+		// wildcard without an exact key uses a suffix
+		{"*.mail.example.com", "suffix-example"},
+		// suffixes only match whole labels (and not the suffix's own apex)
+		{"notexample.com", "default"},
+		{"example.com", "default"},
+		// no match
+		{"*.example.net", "default"},
+		{"192.0.2.2", "default"},
+	}
+
+	for _, tt := range tests {
+		got := methodForIdentifier(tt.value, defaultMethod, methodMap)
+		if got.Value != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.value, got.Value, tt.want)
+		}
+	}
+
+	// no map
+	got := methodForIdentifier("www.example.com", defaultMethod, nil)
+	if got.Value != defaultMethod.Value {
+		t.Errorf("nil map: got %s, want %s", got.Value, defaultMethod.Value)
+	}
+}
@@ -4,14 +4,34 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"legocerthub-backend/pkg/domain/private_keys"
 	"legocerthub-backend/pkg/domain/private_keys/key_crypto"
+	"net"
 )
 
-// MakeCsrDer generates the CSR bytes for ACME to POST To a Finalize URL
-func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
+// MakeCsrDer generates the CSR bytes for ACME to POST To a Finalize URL. The CSR
+// is signed with key (usually the cert's key, unless the cert is rotating keys).
+func (cert *Certificate) MakeCsrDer(key private_keys.Key) (csr []byte, err error) {
+	// split names into dns names and ip addresses
+	var dnsNames []string
+	var ipAddresses []net.IP
+	for _, name := range append([]string{cert.Subject}, cert.SubjectAltNames...) {
+		if ip := net.ParseIP(name); ip != nil {
+			ipAddresses = append(ipAddresses, ip)
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+
+	// common name is only set for dns subjects
+	commonName := cert.Subject
+	if net.ParseIP(cert.Subject) != nil {
+		commonName = ""
+	}
+
 	// create Subject
 	subj := pkix.Name{
-		CommonName:         cert.Subject,
+		CommonName:         commonName,
 		Organization:       []string{cert.Organization},
 		OrganizationalUnit: []string{cert.OrganizationalUnit},
 		Country:            []string{cert.Country},
@@ -24,14 +44,15 @@ func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 
 	// CSR template to create CSR from
 	template := x509.CertificateRequest{
-		SignatureAlgorithm: cert.CertificateKey.Algorithm.CsrSigningAlg(),
+		SignatureAlgorithm: key.Algorithm.CsrSigningAlg(),
 		Subject:            subj,
-		DNSNames:           append([]string{cert.Subject}, cert.SubjectAltNames...),
-		// unused: EmailAddresses, IPAddresses, URIs, Attributes (deprecated), ExtraExtensions
+		DNSNames:           dnsNames,
+		IPAddresses:        ipAddresses,
+		// unused: EmailAddresses, URIs, Attributes (deprecated), ExtraExtensions
 	}
 
-	// cert's private key for signing
-	certKey, err := key_crypto.PemStringToKey(cert.CertificateKey.Pem, cert.CertificateKey.Algorithm)
+	// private key for signing
+	certKey, err := key_crypto.PemStringToKey(key.Pem, key.Algorithm)
 	if err != nil {
 		return nil, err
 	}
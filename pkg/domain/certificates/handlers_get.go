@@ -2,6 +2,7 @@ package certificates
 
 import (
 	"fmt"
+	"legocerthub-backend/pkg/challenges"
 	"legocerthub-backend/pkg/output"
 	"legocerthub-backend/pkg/pagination_sort"
 	"legocerthub-backend/pkg/validation"
@@ -144,6 +145,12 @@ func (service *Service) GetNewCertOptions(w http.ResponseWriter, r *http.Request
 	// available challenge methods
 	newCertOptions.AvailableChallengeMethods = service.challenges.ListOfMethods()
 
+	// enabled challenge methods that can validate each kind of identifier
+	newCertOptions.IdentifierMethods = make(map[string][]challenges.MethodValue)
+	for kind, example := range identifierKindExamples {
+		newCertOptions.IdentifierMethods[kind] = service.challenges.EnabledMethodsForIdentifier(example)
+	}
+
 	// return response to client
 	_, err = service.output.WriteJSON(w, http.StatusOK, newCertOptions, "certificate_options")
 	if err != nil {
@@ -15,22 +15,26 @@ import (
 
 // NewPayload is the struct for creating a new certificate
 type NewPayload struct {
-	Name                 *string                 `json:"name"`
-	Description          *string                 `json:"description"`
-	PrivateKeyID         *int                    `json:"private_key_id"`
-	AcmeAccountID        *int                    `json:"acme_account_id"`
-	ChallengeMethodValue *challenges.MethodValue `json:"challenge_method_value"`
-	Subject              *string                 `json:"subject"`
-	SubjectAltNames      []string                `json:"subject_alts"`
-	Organization         *string                 `json:"organization"`
-	OrganizationalUnit   *string                 `json:"organizational_unit"`
-	Country              *string                 `json:"country"`
-	State                *string                 `json:"state"`
-	City                 *string                 `json:"city"`
-	ApiKey               string                  `json:"-"`
-	ApiKeyViaUrl         bool                    `json:"-"`
-	CreatedAt            int                     `json:"-"`
-	UpdatedAt            int                     `json:"-"`
+	Name                 *string                           `json:"name"`
+	Description          *string                           `json:"description"`
+	PrivateKeyID         *int                              `json:"private_key_id"`
+	AcmeAccountID        *int                              `json:"acme_account_id"`
+	ChallengeMethodValue *challenges.MethodValue           `json:"challenge_method_value"`
+	ChallengeMethodMap   map[string]challenges.MethodValue `json:"challenge_method_map"`
+	Subject              *string                           `json:"subject"`
+	SubjectAltNames      []string                          `json:"subject_alts"`
+	Organization         *string                           `json:"organization"`
+	OrganizationalUnit   *string                           `json:"organizational_unit"`
+	Country              *string                           `json:"country"`
+	State                *string                           `json:"state"`
+	City                 *string                           `json:"city"`
+	PreferredRootCN      *string                           `json:"preferred_root_cn"`
+	RenewalPolicy        *RenewalPolicy                    `json:"renewal_policy"`
+	KeyRotation          *KeyRotation                      `json:"key_rotation"`
+	ApiKey               string                            `json:"-"`
+	ApiKeyViaUrl         bool                              `json:"-"`
+	CreatedAt            int                               `json:"-"`
+	UpdatedAt            int                               `json:"-"`
 }
 
 // PostNewCert creates a new certificate object in storage. No actual encryption certificate
@@ -67,22 +71,39 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) (err
 		return output.ErrValidationFailed
 	}
 	// challenge method
+	if payload.ChallengeMethodValue == nil {
+		service.logger.Debug("missing challenge method")
+		return output.ErrValidationFailed
+	}
 	challMethod := service.challenges.MethodByStorageValue(*payload.ChallengeMethodValue)
-	if payload.ChallengeMethodValue == nil || challMethod == challenges.UnknownMethod {
+	if challMethod == challenges.UnknownMethod {
 		service.logger.Debug("unknown challenge method")
 		return output.ErrValidationFailed
 	}
 	// subject
-	if payload.Subject == nil || !subjectValid(*payload.Subject, challMethod) {
+	if payload.Subject == nil || !subjectValid(*payload.Subject) {
 		service.logger.Debug(ErrDomainBad)
 		return output.ErrValidationFailed
 	}
 	// subject alts
 	// blank is okay, skip validation if not specified
-	if payload.SubjectAltNames != nil && !subjectAltsValid(payload.SubjectAltNames, challMethod) {
+	if payload.SubjectAltNames != nil && !subjectAltsValid(payload.SubjectAltNames) {
 		service.logger.Debug(ErrDomainBad)
 		return output.ErrValidationFailed
 	}
+	// challenge method map (optional)
+	methodMap, ok := service.methodMapFromPayload(payload.ChallengeMethodMap)
+	if !ok {
+		service.logger.Debug("invalid challenge method map")
+		return output.ErrValidationFailed
+	}
+	payload.ChallengeMethodMap = methodMapValues(methodMap)
+	// all identifiers must be compatible with their challenge method
+	err = identifiersMethodErr(append([]string{*payload.Subject}, payload.SubjectAltNames...), challMethod, methodMap)
+	if err != nil {
+		service.logger.Debug(err)
+		return err
+	}
 	// CSR
 	// set to blank if don't exist
 	// TODO: Do any validation of CSR components?
@@ -101,6 +122,25 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) (err
 	if payload.City == nil {
 		payload.City = new(string)
 	}
+	// preferred root CN (if none, set to blank which means use the default chain)
+	if payload.PreferredRootCN == nil {
+		payload.PreferredRootCN = new(string)
+	}
+	// renewal policy (if none, use the default)
+	if payload.RenewalPolicy == nil {
+		payload.RenewalPolicy = new(RenewalPolicy)
+		*payload.RenewalPolicy = DefaultRenewalPolicy
+	} else if !renewalPolicyValid(*payload.RenewalPolicy) {
+		service.logger.Debug(ErrRenewalPolicyBad)
+		return output.ErrValidationFailed
+	}
+	// key rotation (if none, disabled)
+	if payload.KeyRotation == nil {
+		payload.KeyRotation = new(KeyRotation)
+	} else if !keyRotationValid(*payload.KeyRotation) {
+		service.logger.Debug(ErrKeyRotationBad)
+		return output.ErrValidationFailed
+	}
 	// end validation
 
 	// add additional details to the payload before saving
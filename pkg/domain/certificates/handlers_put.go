@@ -14,19 +14,23 @@ import (
 // DetailsUpdatePayload is the struct for editing an existing cert. A number of
 // fields can be updated by the client on the fly (without ACME interaction).
 type DetailsUpdatePayload struct {
-	ID                   int                     `json:"-"`
-	Name                 *string                 `json:"name"`
-	Description          *string                 `json:"description"`
-	PrivateKeyId         *int                    `json:"private_key_id"`
-	ChallengeMethodValue *challenges.MethodValue `json:"challenge_method_value"`
-	SubjectAltNames      []string                `json:"subject_alts"`
-	Organization         *string                 `json:"organization"`
-	OrganizationalUnit   *string                 `json:"organizational_unit"`
-	Country              *string                 `json:"country"`
-	State                *string                 `json:"state"`
-	City                 *string                 `json:"city"`
-	ApiKeyViaUrl         *bool                   `json:"api_key_via_url"`
-	UpdatedAt            int                     `json:"-"`
+	ID                   int                               `json:"-"`
+	Name                 *string                           `json:"name"`
+	Description          *string                           `json:"description"`
+	PrivateKeyId         *int                              `json:"private_key_id"`
+	ChallengeMethodValue *challenges.MethodValue           `json:"challenge_method_value"`
+	ChallengeMethodMap   map[string]challenges.MethodValue `json:"challenge_method_map"`
+	SubjectAltNames      []string                          `json:"subject_alts"`
+	Organization         *string                           `json:"organization"`
+	OrganizationalUnit   *string                           `json:"organizational_unit"`
+	Country              *string                           `json:"country"`
+	State                *string                           `json:"state"`
+	City                 *string                           `json:"city"`
+	ApiKeyViaUrl         *bool                             `json:"api_key_via_url"`
+	PreferredRootCN      *string                           `json:"preferred_root_cn"`
+	RenewalPolicy        *RenewalPolicy                    `json:"renewal_policy"`
+	KeyRotation          *KeyRotation                      `json:"key_rotation"`
+	UpdatedAt            int                               `json:"-"`
 }
 
 // PutDetailsCert is a handler that sets various details about a cert and saves
@@ -77,29 +81,47 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) (
 			service.logger.Debug("unknown challenge method")
 			return output.ErrValidationFailed
 		}
-		// verify subject is compatible with new method selection
-		// i.e. make sure if wildcard it is using a dns method
-		if !subjectValid(cert.Subject, challengeMethod) {
-			service.logger.Debug("challenge method does not support subject")
-			return output.ErrValidationFailed
-		}
-		// do not check subject alts here, they are checked below
 	}
 	// subject alts (optional)
+	// current alts
+	subjectAltNames := cert.SubjectAltNames
 	// if new alts are being specified
 	if payload.SubjectAltNames != nil {
-		if !subjectAltsValid(payload.SubjectAltNames, challengeMethod) {
+		if !subjectAltsValid(payload.SubjectAltNames) {
 			service.logger.Debug(ErrDomainBad)
 			return output.ErrValidationFailed
 		}
-
-	} else if len(cert.SubjectAltNames) > 0 {
-		// if keeping old alts and they exist (more than 0)
-		// verify against the challenge method
-		if !subjectAltsValid(cert.SubjectAltNames, challengeMethod) {
-			service.logger.Debug(ErrDomainBad)
+		subjectAltNames = payload.SubjectAltNames
+	}
+	// challenge method map (optional)
+	// current map
+	methodMap := cert.ChallengeMethodMap
+	// if new map is specified, check it (an empty map removes the current map)
+	if payload.ChallengeMethodMap != nil {
+		var ok bool
+		methodMap, ok = service.methodMapFromPayload(payload.ChallengeMethodMap)
+		if !ok {
+			service.logger.Debug("invalid challenge method map")
 			return output.ErrValidationFailed
 		}
+		payload.ChallengeMethodMap = methodMapValues(methodMap)
+	}
+	// verify all identifiers (subject and resulting alts) are compatible with the
+	// resulting methods (i.e. make sure if wildcard it is using a dns method)
+	err = identifiersMethodErr(append([]string{cert.Subject}, subjectAltNames...), challengeMethod, methodMap)
+	if err != nil {
+		service.logger.Debug(err)
+		return err
+	}
+	// renewal policy (optional)
+	if payload.RenewalPolicy != nil && !renewalPolicyValid(*payload.RenewalPolicy) {
+		service.logger.Debug(ErrRenewalPolicyBad)
+		return output.ErrValidationFailed
+	}
+	// key rotation (optional)
+	if payload.KeyRotation != nil && !keyRotationValid(*payload.KeyRotation) {
+		service.logger.Debug(ErrKeyRotationBad)
+		return output.ErrValidationFailed
 	}
 	// TODO: Do any validation of CSR components?
 	// end validation
@@ -0,0 +1,38 @@
+package certificates
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/domain/private_keys/key_crypto"
+)
+
+var ErrKeyRotationBad = errors.New("certificate key rotation is not valid")
+
+// KeyRotation is if a certificate gets a new private key each time a new order is
+// placed. The new key is used for the order's CSR and the certificate switches to
+// it once the order is valid (the previous key is archived, not deleted).
+type KeyRotation struct {
+	Enabled bool `json:"enabled"`
+	// AlgorithmValue is the storage value of the algorithm for new keys (blank to
+	// use the algorithm of the certificate's current key)
+	AlgorithmValue string `json:"algorithm_value"`
+}
+
+// keyRotationValid returns if the rotation's algorithm (if specified) is a known
+// algorithm
+func keyRotationValid(rotation KeyRotation) bool {
+	if rotation.AlgorithmValue == "" {
+		return true
+	}
+
+	return key_crypto.AlgorithmByStorageValue(rotation.AlgorithmValue) != key_crypto.UnknownAlgorithm
+}
+
+// RotationAlgorithm returns the algorithm to generate the certificate's next key
+// with when rotating keys
+func (cert Certificate) RotationAlgorithm() key_crypto.Algorithm {
+	if cert.KeyRotation.AlgorithmValue == "" {
+		return cert.CertificateKey.Algorithm
+	}
+
+	return key_crypto.AlgorithmByStorageValue(cert.KeyRotation.AlgorithmValue)
+}
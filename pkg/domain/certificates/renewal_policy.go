@@ -0,0 +1,54 @@
+package certificates
+
+import (
+	"errors"
+)
+
+var ErrRenewalPolicyBad = errors.New("certificate renewal policy is not valid")
+
+// RenewalPolicyType is how a certificate decides when to automatically renew
+type RenewalPolicyType string
+
+const (
+	// use the app's configured valid_remaining_days_threshold
+	RenewalPolicyDefault RenewalPolicyType = "default"
+	// renew when Value or fewer days of validity remain
+	RenewalPolicyDaysRemaining RenewalPolicyType = "days_remaining"
+	// renew when Value percent or less of the certificate's total lifetime remains
+	RenewalPolicyPercentRemaining RenewalPolicyType = "percent_remaining"
+	// never automatically renew (the certificate can still be ordered manually)
+	RenewalPolicyNever RenewalPolicyType = "never"
+)
+
+// maxRenewalPolicyDays caps days_remaining (certs aren't issued for longer)
+const maxRenewalPolicyDays = 3650
+
+// RenewalPolicy is when a certificate is automatically renewed. Value is only
+// used by the days and percent policies.
+type RenewalPolicy struct {
+	Type  RenewalPolicyType `json:"type"`
+	Value int               `json:"value"`
+}
+
+// DefaultRenewalPolicy is the policy of a certificate that doesn't specify one
+var DefaultRenewalPolicy = RenewalPolicy{Type: RenewalPolicyDefault}
+
+// AutoRenew returns false if the certificate should never be automatically renewed
+func (policy RenewalPolicy) AutoRenew() bool {
+	return policy.Type != RenewalPolicyNever
+}
+
+// renewalPolicyValid returns if the policy is a known type with a value in range
+// for that type. Policies that don't use a value must not specify one.
+func renewalPolicyValid(policy RenewalPolicy) bool {
+	switch policy.Type {
+	case RenewalPolicyDefault, RenewalPolicyNever:
+		return policy.Value == 0
+	case RenewalPolicyDaysRemaining:
+		return policy.Value >= 1 && policy.Value <= maxRenewalPolicyDays
+	case RenewalPolicyPercentRemaining:
+		return policy.Value >= 1 && policy.Value <= 99
+	default:
+		return false
+	}
+}
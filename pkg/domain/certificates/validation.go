@@ -2,11 +2,11 @@ package certificates
 
 import (
 	"errors"
-	"legocerthub-backend/pkg/acme"
 	"legocerthub-backend/pkg/challenges"
 	"legocerthub-backend/pkg/output"
 	"legocerthub-backend/pkg/storage"
 	"legocerthub-backend/pkg/validation"
+	"net/http"
 )
 
 var (
@@ -97,24 +97,68 @@ func (service *Service) privateKeyIdValid(keyId int, certId *int) bool {
 	return false
 }
 
-// subjectValid validates domain name and if it is a wildcard
-// domain name it also verifies the method is dns-01
-func subjectValid(domain string, challMethod challenges.Method) bool {
-	// wild is only valid for Dns challenges
-	wildOk := challMethod.ChallengeType == acme.ChallengeTypeDns01
-
-	// check domain is valid
-	return validation.DomainValid(domain, wildOk)
+// subjectValid validates domain name (which may be a wildcard) or ip address. It
+// does not verify the name is compatible with any challenge method.
+func subjectValid(domain string) bool {
+	// wildcard is allowed, method compatibility is checked separately
+	return validation.DomainOrIPValid(domain, true)
 }
 
 // subjectAltsValid validates each domain contained in the slice
 // of subject alt domain names
-func subjectAltsValid(alts []string, challMethod challenges.Method) bool {
+func subjectAltsValid(alts []string) bool {
 	for _, altName := range alts {
-		if !subjectValid(altName, challMethod) {
+		if !subjectValid(altName) {
 			return false
 		}
 	}
 
 	return true
 }
+
+// identifierMethodError is the error for a single identifier that can not be
+// validated using the selected challenge method
+type identifierMethodError struct {
+	Identifier string                 `json:"identifier"`
+	Method     challenges.MethodValue `json:"challenge_method_value"`
+	Error      string                 `json:"error"`
+}
+
+// identifiersMethodMessage is the message returned to the client when one or
+// more identifiers can not be validated using the selected challenge method
+type identifiersMethodMessage struct {
+	Detail      string                  `json:"detail"`
+	Identifiers []identifierMethodError `json:"identifiers"`
+}
+
+// identifiersMethodErr checks each identifier against the challenge method it will
+// use (based on the default method and method map). If any identifier can't be
+// validated using its method, an output.Error is returned that lists each
+// incompatible identifier and the reason it is incompatible.
+func identifiersMethodErr(identifiers []string, defaultMethod challenges.Method, methodMap map[string]challenges.Method) error {
+	var identifierErrs []identifierMethodError
+
+	for _, identifier := range identifiers {
+		challMethod := methodForIdentifier(identifier, defaultMethod, methodMap)
+		err := challMethod.IdentifierError(identifier)
+		if err != nil {
+			identifierErrs = append(identifierErrs, identifierMethodError{
+				Identifier: identifier,
+				Method:     challMethod.Value,
+				Error:      err.Error(),
+			})
+		}
+	}
+
+	if len(identifierErrs) == 0 {
+		return nil
+	}
+
+	return output.Error{
+		Status: http.StatusBadRequest,
+		Message: identifiersMethodMessage{
+			Detail:      "one or more identifiers can not be validated using their challenge method",
+			Identifiers: identifierErrs,
+		},
+	}
+}
@@ -24,7 +24,7 @@ func (service *Service) startAutoOrderService(cfg *Config, ctx context.Context,
 	refreshMinute := *cfg.RefreshTimeMinute
 
 	// log start and update wg
-	service.logger.Infof("starting automatic certificate ordering service; %d day expiration threshold; "+
+	service.logger.Infof("starting automatic certificate ordering service; %d day default expiration threshold; "+
 		"orders will be placed every day at %d:%d", *cfg.ValidRemainingDaysThreshold, refreshHour, refreshMinute)
 	wg.Add(1)
 
@@ -74,8 +74,8 @@ func (service *Service) startAutoOrderService(cfg *Config, ctx context.Context,
 				service.logger.Errorf("error retying incomplete orders: %s", err)
 			}
 
-			// order expiring certificates
-			err = service.orderExpiringCerts(remainingDaysThreshold)
+			// order expiring certificates (next check is the next day's run)
+			err = service.orderExpiringCerts(remainingDaysThreshold, nextRunTime.Add(24*time.Hour))
 			if err != nil {
 				service.logger.Errorf("error ordering expiring certs: %s", err)
 			}
@@ -107,9 +107,11 @@ func (service *Service) retryIncompleteOrders() (err error) {
 	return nil
 }
 
-// orderExpiringCerts automatically orders any certficates that are valid but have a valid_to
-// timestamp within the specified threshold
-func (service *Service) orderExpiringCerts(remainingDaysThreshold time.Duration) (err error) {
+// orderExpiringCerts automatically orders any certficates that are valid but should be renewed
+// according to their renewal policy (certificates using the default policy are renewed if their
+// valid_to timestamp is within the specified threshold). Certificates that ACME's renewal info
+// (ARI) suggests renewing before nextCheck are also ordered, unless they are never renewed.
+func (service *Service) orderExpiringCerts(remainingDaysThreshold time.Duration, nextCheck time.Time) (err error) {
 	service.logger.Info("adding expiring certificates to order queue")
 
 	// get slice of all expiring certificate ids
@@ -118,6 +120,26 @@ func (service *Service) orderExpiringCerts(remainingDaysThreshold time.Duration)
 		return err
 	}
 
+	// add any certs ARI suggests renewing (that aren't already expiring)
+	ariCertIds, err := service.ariRenewalCertIds(nextCheck)
+	if err != nil {
+		// log error, but still order the expiring certs
+		service.logger.Errorf("failed to check acme renewal info (%s)", err)
+	}
+	for _, ariCertId := range ariCertIds {
+		alreadyExpiring := false
+		for _, certId := range expiringCertIds {
+			if certId == ariCertId {
+				alreadyExpiring = true
+				break
+			}
+		}
+
+		if !alreadyExpiring {
+			expiringCertIds = append(expiringCertIds, ariCertId)
+		}
+	}
+
 	// address each expiring cert
 	for _, certId := range expiringCertIds {
 		// check for an existing incomplete order
@@ -0,0 +1,27 @@
+package orders
+
+import (
+	"legocerthub-backend/pkg/order_events"
+)
+
+// orderEventResponse is the JSON response for one step of processing the order
+type orderEventResponse struct {
+	ID         int    `json:"id"`
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+	Detail     string `json:"detail"`
+	Error      string `json:"error"`
+	CreatedAt  int    `json:"created_at"`
+}
+
+// orderEventToResponse returns the response for the order event
+func orderEventToResponse(event order_events.Event) orderEventResponse {
+	return orderEventResponse{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		Identifier: event.Identifier,
+		Detail:     event.Detail,
+		Error:      event.Error,
+		CreatedAt:  event.CreatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package orders
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/output"
+	"legocerthub-backend/pkg/storage"
+	"legocerthub-backend/pkg/validation"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var errOrderJobStarting = output.Error{Status: http.StatusConflict, Message: "order job is starting, try again"}
+
+// CancelOrderJob cancels a job in the order job queue. A queued job is removed
+// right away. A running job is canceled and then removed once its worker stops
+// (after deprovisioning any challenge resources it provisioned). The order itself
+// is not changed (other than dropping its unused rotation key, if any) and can be
+// fulfilled again later.
+func (service *Service) CancelOrderJob(w http.ResponseWriter, r *http.Request) (err error) {
+	// get id param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	jobId, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(jobId) {
+		service.logger.Debug(err)
+		return output.ErrValidationFailed
+	}
+
+	response := output.JsonResponse{
+		Status:  http.StatusOK,
+		Message: "canceled",
+		ID:      jobId,
+	}
+
+	// cancel if running, otherwise remove from the queue
+	if service.cancelRunningJob(jobId) {
+		response.Message = "canceling"
+	} else {
+		var orderId int
+		orderId, err = service.storage.DeleteUnclaimedOrderJob(jobId)
+		if err == nil {
+			service.deleteAbandonedRotationKey(orderId)
+		} else {
+			if errors.Is(err, storage.ErrNoRecord) {
+				service.logger.Debug(err)
+				return output.ErrNotFound
+			} else if errors.Is(err, storage.ErrInUse) {
+				// claimed by a worker since checking if running
+				if !service.cancelRunningJob(jobId) {
+					service.logger.Debugf("order job %d is claimed but not yet running", jobId)
+					return errOrderJobStarting
+				}
+				response.Message = "canceling"
+			} else {
+				service.logger.Error(err)
+				return output.ErrStorageGeneric
+			}
+		}
+	}
+
+	// return response to client
+	_, err = service.output.WriteJSON(w, response.Status, response, "response")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
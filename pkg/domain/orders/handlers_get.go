@@ -166,3 +166,138 @@ func (service *Service) DownloadOneOrder(w http.ResponseWriter, r *http.Request)
 
 	return nil
 }
+
+// certOrderFromParams returns the order specified by the request's certid and
+// orderid params. An output error is returned if either param is invalid or the
+// order doesn't belong to the cert.
+func (service *Service) certOrderFromParams(r *http.Request) (Order, error) {
+	// get params
+	params := httprouter.ParamsFromContext(r.Context())
+
+	certIdParam := params.ByName("certid")
+	certId, err := strconv.Atoi(certIdParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return Order{}, output.ErrValidationFailed
+	}
+
+	orderIdParam := params.ByName("orderid")
+	orderId, err := strconv.Atoi(orderIdParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return Order{}, output.ErrValidationFailed
+	}
+
+	// basic check
+	if !validation.IsIdExistingValidRange(certId) {
+		service.logger.Debug(ErrCertIdBad)
+		return Order{}, output.ErrValidationFailed
+	}
+	if !validation.IsIdExistingValidRange(orderId) {
+		service.logger.Debug(ErrOrderIdBad)
+		return Order{}, output.ErrValidationFailed
+	}
+
+	// confirm the order belongs to the cert
+	order, err := service.storage.GetOneOrder(orderId)
+	if err != nil {
+		// special error case for no record found
+		if err == storage.ErrNoRecord {
+			service.logger.Debug(err)
+			return Order{}, output.ErrNotFound
+		} else {
+			service.logger.Error(err)
+			return Order{}, output.ErrStorageGeneric
+		}
+	}
+	if order.Certificate.ID != certId {
+		service.logger.Debug(ErrOrderIdBad)
+		return Order{}, output.ErrNotFound
+	}
+
+	return order, nil
+}
+
+// GetOrderProviderLogs returns the output of the external challenge provider
+// programs (e.g. dns scripts) that were run while processing the order
+func (service *Service) GetOrderProviderLogs(w http.ResponseWriter, r *http.Request) (err error) {
+	order, err := service.certOrderFromParams(r)
+	if err != nil {
+		return err
+	}
+
+	// get logs
+	execLogs, err := service.storage.GetProviderExecLogsByOrder(order.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	response := []providerExecLogResponse{}
+	for i := range execLogs {
+		response = append(response, providerExecLogToResponse(execLogs[i]))
+	}
+
+	// return response to client
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "provider_logs")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// GetOrderEvents returns the timeline of steps taken (and any errors) while
+// processing the order, in the order they occurred
+func (service *Service) GetOrderEvents(w http.ResponseWriter, r *http.Request) (err error) {
+	order, err := service.certOrderFromParams(r)
+	if err != nil {
+		return err
+	}
+
+	// get events
+	events, err := service.storage.GetOrderEventsByOrder(order.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	response := []orderEventResponse{}
+	for i := range events {
+		response = append(response, orderEventToResponse(events[i]))
+	}
+
+	// return response to client
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "order_events")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
+
+// GetOrderJobs returns all of the jobs in the order job queue (both queued and
+// running), each with its order's current step
+func (service *Service) GetOrderJobs(w http.ResponseWriter, r *http.Request) (err error) {
+	jobs, err := service.storage.GetAllOrderJobs()
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrStorageGeneric
+	}
+
+	response := []orderJobResponse{}
+	for i := range jobs {
+		response = append(response, service.orderJobToResponse(jobs[i]))
+	}
+
+	// return response to client
+	_, err = service.output.WriteJSON(w, http.StatusOK, response, "order_jobs")
+	if err != nil {
+		service.logger.Error(err)
+		return output.ErrWriteJsonFailed
+	}
+
+	return nil
+}
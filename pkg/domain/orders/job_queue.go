@@ -0,0 +1,121 @@
+package orders
+
+import (
+	"legocerthub-backend/pkg/order_events"
+	"time"
+)
+
+// Orders are fulfilled by workers that claim jobs from a queue kept in storage,
+// so queued orders survive a restart. A job that doesn't complete the order (e.g.
+// the order is still processing or an error occurred) is released back to the
+// queue to be attempted again after a delay. The delay increases exponentially
+// with each attempt, unless the ACME server asked for a longer wait (Retry-After).
+// Jobs can be canceled, whether queued or running (see: running_jobs.go).
+
+const (
+	// orderWorkerCount is the number of workers claiming jobs from the queue
+	orderWorkerCount = 3
+	// orderJobPollInterval is the longest a worker waits before checking the
+	// queue for due jobs (workers are also woken when a job is queued)
+	orderJobPollInterval = 10 * time.Second
+
+	// retry defaults (used if configured values are not positive)
+	defaultJobRetryMinDelay = 1 * time.Minute
+	defaultJobRetryMaxDelay = 6 * time.Hour
+	defaultJobMaxAttempts   = 10
+)
+
+// OrderJob is a queued job to fulfill an order
+type OrderJob struct {
+	ID           int
+	OrderID      int
+	HighPriority bool
+	// Attempts is how many times the job has been attempted without finishing
+	Attempts int
+	// NextAttemptAt is the unix time the job is due
+	NextAttemptAt int
+	// ClaimedAt is the unix time a worker claimed the job (0 if unclaimed)
+	ClaimedAt int
+	LastError string
+	CreatedAt int
+	UpdatedAt int
+	// CurrentStep is the most recent event of the job's order (only populated
+	// when listing jobs, nil if the order has no events)
+	CurrentStep *order_events.Event
+}
+
+// wakeOrderWorkers signals the workers to check the queue for due jobs without
+// waiting for the next poll. If the workers already have a pending signal, this
+// is a no-op.
+func (service *Service) wakeOrderWorkers() {
+	select {
+	case service.jobWake <- struct{}{}:
+	default:
+	}
+}
+
+// jobRetryDelay returns how long to wait before the next attempt of a job that
+// has been attempted the specified number of times (doubling with each attempt,
+// up to the maximum delay)
+func (service *Service) jobRetryDelay(attempts int) time.Duration {
+	delay := service.jobRetryMinDelay
+	for i := 1; i < attempts && delay < service.jobRetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > service.jobRetryMaxDelay {
+		delay = service.jobRetryMaxDelay
+	}
+
+	return delay
+}
+
+// orderJobResponse is the JSON response for a queued or running order job
+type orderJobResponse struct {
+	ID            int    `json:"id"`
+	OrderID       int    `json:"order_id"`
+	Status        string `json:"status"`
+	HighPriority  bool   `json:"high_priority"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt int    `json:"next_attempt_at"`
+	LastError     string `json:"last_error"`
+	CreatedAt     int    `json:"created_at"`
+	// worker and start time are only included if the job is running
+	WorkerID    *int                `json:"worker_id,omitempty"`
+	StartedAt   *int                `json:"started_at,omitempty"`
+	CurrentStep *orderEventResponse `json:"current_step"`
+}
+
+// orderJobToResponse returns the response for the job. A job that has been
+// claimed by a worker is 'running', otherwise it is 'queued'.
+func (service *Service) orderJobToResponse(job OrderJob) orderJobResponse {
+	response := orderJobResponse{
+		ID:            job.ID,
+		OrderID:       job.OrderID,
+		Status:        "queued",
+		HighPriority:  job.HighPriority,
+		Attempts:      job.Attempts,
+		NextAttemptAt: job.NextAttemptAt,
+		LastError:     job.LastError,
+		CreatedAt:     job.CreatedAt,
+	}
+
+	if job.ClaimedAt != 0 {
+		response.Status = "running"
+		startedAt := job.ClaimedAt
+		response.StartedAt = &startedAt
+
+		// worker isn't known if the job was just claimed (or just finished)
+		workerId, ok := service.runningJobWorker(job.ID)
+		if ok {
+			response.WorkerID = &workerId
+		}
+	}
+
+	if job.CurrentStep != nil {
+		currentStep := orderEventToResponse(*job.CurrentStep)
+		response.CurrentStep = &currentStep
+	}
+
+	return response
+}
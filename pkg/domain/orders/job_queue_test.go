@@ -0,0 +1,42 @@
+package orders
+
+import (
+	"testing"
+	"time"
+)
+
+// TestJobRetryDelay confirms the retry delay doubles with each attempt and is
+// capped at the max delay
+func TestJobRetryDelay(t *testing.T) {
+	service := &Service{
+		jobRetryMinDelay: 30 * time.Second,
+		jobRetryMaxDelay: 10 * time.Minute,
+	}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 60 * time.Second},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{1000, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		got := service.jobRetryDelay(tt.attempts)
+		if got != tt.want {
+			t.Errorf("attempts %d: got %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+
+	// min delay larger than max delay is capped
+	service.jobRetryMinDelay = 20 * time.Minute
+	got := service.jobRetryDelay(1)
+	if got != 10*time.Minute {
+		t.Errorf("min over max: got %s, want %s", got, 10*time.Minute)
+	}
+}
@@ -0,0 +1,95 @@
+package orders
+
+import (
+	"fmt"
+	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/domain/private_keys"
+	"time"
+)
+
+// If a cert is rotating keys, a new key is generated each time a new order is
+// placed and the order is finalized with it. Once the order is valid, the cert
+// switches to the new key and the key it replaced is archived (not deleted, as
+// the cert's previous orders were issued for it). The new key takes over the
+// old key's name and api key so clients downloading the key don't need to be
+// reconfigured. If the order ends without a cert for the new key (e.g. it is
+// invalid, or its job is canceled before finalizing), the new key is deleted.
+
+// rotationKeyPayload generates the cert's next key and returns the payload to
+// save it to storage. The key is given a temporary name until the cert switches
+// to it.
+func rotationKeyPayload(cert certificates.Certificate) (private_keys.NewPayload, error) {
+	alg := cert.RotationAlgorithm()
+	pem, err := alg.GeneratePrivateKeyPem()
+	if err != nil {
+		return private_keys.NewPayload{}, err
+	}
+
+	now := int(time.Now().Unix())
+	name := fmt.Sprintf("%s_next_%d", cert.CertificateKey.Name, now)
+	description := fmt.Sprintf("rotated key for certificate %s", cert.Name)
+	algValue := alg.StorageValue()
+	apiKeyDisabled := cert.CertificateKey.ApiKeyDisabled
+
+	return private_keys.NewPayload{
+		Name:           &name,
+		Description:    &description,
+		AlgorithmValue: &algValue,
+		PemContent:     &pem,
+		ApiKey:         cert.CertificateKey.ApiKey,
+		ApiKeyDisabled: &apiKeyDisabled,
+		ApiKeyViaUrl:   cert.CertificateKey.ApiKeyViaUrl,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, nil
+}
+
+// orderKey returns the key to finalize the order with (the order's new key if the
+// cert is rotating keys, otherwise the cert's key)
+func (service *Service) orderKey(order Order) (private_keys.Key, error) {
+	if order.NewKeyID == nil {
+		return order.Certificate.CertificateKey, nil
+	}
+
+	return service.storage.GetOneKeyById(*order.NewKeyID)
+}
+
+// deleteAbandonedRotationKey deletes the order's new key (if any) when a cert will
+// never be issued for it. That is, the order is invalid or the order's job stopped
+// (e.g. it was canceled or ran out of attempts) before the order was finalized with
+// the key. If the order is fulfilled later, it is finalized with the cert's current
+// key instead.
+func (service *Service) deleteAbandonedRotationKey(orderId int) {
+	order, err := service.storage.GetOneOrder(orderId)
+	if err != nil {
+		service.logger.Errorf("failed to fetch order %d to check for an abandoned rotation key (%s)", orderId, err)
+		return
+	}
+
+	if order.NewKeyID == nil {
+		return
+	}
+
+	// finalized with the new key, a cert may still be issued for it
+	if order.Status != "invalid" && order.FinalizedKey != nil && order.FinalizedKey.ID == *order.NewKeyID {
+		return
+	}
+
+	err = service.storage.DeleteOrderNewKey(order.ID, *order.NewKeyID)
+	if err != nil {
+		service.logger.Errorf("failed to delete abandoned rotation key %d of order %d (%s)", *order.NewKeyID, order.ID, err)
+	}
+}
+
+// deleteUnusedRotationKey deletes a new key that was saved for an order that
+// wasn't saved (keyId is nil if the cert isn't rotating keys)
+func (service *Service) deleteUnusedRotationKey(keyId *int) {
+	if keyId == nil {
+		return
+	}
+
+	err := service.storage.DeleteKey(*keyId)
+	if err != nil {
+		service.logger.Errorf("failed to delete unused rotation key %d (%s)", *keyId, err)
+	}
+}
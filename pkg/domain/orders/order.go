@@ -9,6 +9,8 @@ import (
 
 // Order is a single ACME order object
 // Finalized key is included as the cert may change keys after an order is finalized.
+// NewKeyID is the key generated for the order if the cert is rotating keys (the
+// order is finalized with it and the cert switches to it once the order is valid).
 type Order struct {
 	ID             int
 	Certificate    certificates.Certificate
@@ -18,9 +20,11 @@ type Order struct {
 	Error          *acme.Error
 	Expires        *int
 	DnsIdentifiers []string
+	IpIdentifiers  []string
 	Authorizations []string
 	Finalize       string
 	FinalizedKey   *private_keys.Key
+	NewKeyID       *int
 	CertificateUrl *string
 	Pem            *string
 	ValidFrom      *int
@@ -38,6 +42,7 @@ type orderSummaryResponse struct {
 	KnownRevoked   bool                            `json:"known_revoked"`
 	Error          *acme.Error                     `json:"error"`
 	DnsIdentifiers []string                        `json:"dns_identifiers"`
+	IpIdentifiers  []string                        `json:"ip_identifiers"`
 	FinalizedKey   *orderKeySummaryResponse        `json:"finalized_key"`
 	ValidFrom      *int                            `json:"valid_from"`
 	ValidTo        *int                            `json:"valid_to"`
@@ -95,6 +100,7 @@ func (order Order) summaryResponse() orderSummaryResponse {
 		KnownRevoked:   order.KnownRevoked,
 		Error:          order.Error,
 		DnsIdentifiers: order.DnsIdentifiers,
+		IpIdentifiers:  order.IpIdentifiers,
 		FinalizedKey:   finalKey,
 		ValidFrom:      order.ValidFrom,
 		ValidTo:        order.ValidTo,
@@ -1,32 +1,32 @@
 package orders
 
-// orderFromAcme updates inProcess to indicate the specified order is being
-// processed and launches a go routine that sends the order job to a worker.
-// Priority allows high priority orders to always be processed before low priority
-// orders. The intent is for automated tasks to be low priority, vs manual user
-// initiated tasks being high priority.
+import (
+	"errors"
+	"legocerthub-backend/pkg/storage"
+	"time"
+)
+
+var ErrOrderAlreadyProcessing = errors.New("order is already being processed")
+
+// orderFromAcme adds a job to the order job queue to fulfill the specified order
+// and wakes the workers to claim it. Priority allows high priority orders to always
+// be processed before low priority orders. The intent is for automated tasks to be
+// low priority, vs manual user initiated tasks being high priority. If the order
+// already has a queued job, a high priority request makes that job due now (and
+// raises its priority), while a low priority request leaves the job's backoff as
+// is. If a worker is currently working the order, ErrOrderAlreadyProcessing is
+// returned.
 func (service *Service) orderFromAcme(orderId int, highPriority bool) (err error) {
-	// add to working to indicate order is being worked
-	err = service.inProcess.add(orderId)
-	// error indicates already inProcess
+	err = service.storage.PostOrderJob(orderId, highPriority, int(time.Now().Unix()))
 	if err != nil {
+		// in use indicates a worker has claimed the job
+		if errors.Is(err, storage.ErrInUse) {
+			return ErrOrderAlreadyProcessing
+		}
 		return err
 	}
 
-	// send the order job to a worker which will try and fulfill order and then remove the order
-	// from inProcess after it is complete
-	go func(service *Service, orderId int, highPriority bool) {
-		job := orderJob{
-			orderId: orderId,
-		}
-
-		// add job, based on priority
-		if highPriority {
-			service.highJobs <- job
-		} else {
-			service.lowJobs <- job
-		}
-	}(service, orderId, highPriority)
+	service.wakeOrderWorkers()
 
 	return nil
 }
@@ -18,10 +18,12 @@ type NewOrderAcmePayload struct {
 	KnownRevoked   bool
 	Expires        int
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
 	Location       string
+	NewKeyID       *int
 	CreatedAt      int
 	UpdatedAt      int
 }
@@ -41,6 +43,7 @@ func makeNewOrderAcmePayload(cert certificates.Certificate, acmeResponse acme.Or
 		KnownRevoked:   false,
 		Expires:        acmeResponse.Expires.ToUnixTime(),
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		Finalize:       acmeResponse.Finalize,
@@ -57,6 +60,7 @@ type UpdateAcmeOrderPayload struct {
 	Status         string
 	Expires        *int
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
@@ -75,6 +79,7 @@ func makeUpdateOrderAcmePayload(orderId int, acmeResponse acme.Order) UpdateAcme
 	return UpdateAcmeOrderPayload{
 		Status:         acmeResponse.Status,
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		UpdatedAt:      int(time.Now().Unix()),
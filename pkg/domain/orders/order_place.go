@@ -3,6 +3,7 @@ package orders
 import (
 	"errors"
 	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/domain/private_keys"
 	"legocerthub-backend/pkg/output"
 )
 
@@ -22,12 +23,30 @@ func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (
 		return -2, output.ErrInternal
 	}
 
+	// if rotating keys, generate the new key before ordering (so an order is never
+	// placed without one)
+	var newKeyPayload private_keys.NewPayload
+	if cert.KeyRotation.Enabled {
+		newKeyPayload, err = rotationKeyPayload(cert)
+		if err != nil {
+			service.logger.Error(err)
+			return -2, output.ErrInternal
+		}
+	}
+
+	// new-order payload, including the cert being replaced (if ARI is supported)
+	acmeService := service.acmeService(cert.CertificateAccount.IsStaging)
+	orderPayload := cert.NewOrderPayload()
+	orderPayload.Replaces = service.replacesCertId(acmeService, cert.ID)
+
 	// send the new-order to ACME
-	var acmeResponse acme.Order
-	if cert.CertificateAccount.IsStaging {
-		acmeResponse, err = service.acmeStaging.NewOrder(cert.NewOrderPayload(), key)
-	} else {
-		acmeResponse, err = service.acmeProd.NewOrder(cert.NewOrderPayload(), key)
+	acmeResponse, err := acmeService.NewOrder(orderPayload, key)
+	// if ACME rejected replaces because the cert was already replaced by a different
+	// order, try again without replaces
+	if orderPayload.Replaces != "" && acme.IsAlreadyReplacedError(err) {
+		service.logger.Debugf("new order with replaces failed, retrying without replaces (%s)", err)
+		orderPayload.Replaces = ""
+		acmeResponse, err = acmeService.NewOrder(orderPayload, key)
 	}
 	if err != nil {
 		service.logger.Error(err)
@@ -38,10 +57,23 @@ func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (
 	// populate new order payload
 	payload := makeNewOrderAcmePayload(cert, acmeResponse)
 
+	// save the new key, to finalize the order with
+	if cert.KeyRotation.Enabled {
+		newKeyId, err := service.storage.PostNewKey(newKeyPayload)
+		if err != nil {
+			service.logger.Error(err)
+			return -2, output.ErrStorageGeneric
+		}
+		payload.NewKeyID = &newKeyId
+	}
+
 	// save ACME response to order storage
 	orderId, err = service.storage.PostNewOrder(payload)
 	// if exists error, try to update an existing order
 	if errors.Is(err, ErrOrderExists) {
+		// existing order keeps its own key (if any)
+		service.deleteUnusedRotationKey(payload.NewKeyID)
+
 		err = service.storage.PutOrderAcme(makeUpdateOrderAcmePayload(orderId, acmeResponse))
 		if err != nil {
 			service.logger.Error(err)
@@ -49,6 +81,7 @@ func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (
 		}
 	} else if err != nil {
 		service.logger.Error(err)
+		service.deleteUnusedRotationKey(payload.NewKeyID)
 		return -2, output.ErrStorageGeneric
 	}
 
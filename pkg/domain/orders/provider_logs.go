@@ -0,0 +1,40 @@
+package orders
+
+import (
+	"legocerthub-backend/pkg/challenges"
+)
+
+// providerExecLogResponse is the JSON response for one external challenge
+// provider execution (e.g. a dns script) while processing the order
+type providerExecLogResponse struct {
+	ID              int    `json:"id"`
+	MethodValue     string `json:"method_value"`
+	IdentifierValue string `json:"identifier_value"`
+	Action          string `json:"action"`
+	Command         string `json:"command"`
+	ExitCode        int    `json:"exit_code"`
+	TimedOut        bool   `json:"timed_out"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	Error           string `json:"error"`
+	StartedAt       int    `json:"started_at"`
+	DurationMs      int    `json:"duration_ms"`
+}
+
+// providerExecLogToResponse returns the response for the execution log
+func providerExecLogToResponse(execLog challenges.ProviderExecLog) providerExecLogResponse {
+	return providerExecLogResponse{
+		ID:              execLog.ID,
+		MethodValue:     string(execLog.MethodValue),
+		IdentifierValue: execLog.IdentifierValue,
+		Action:          execLog.Action,
+		Command:         execLog.Command,
+		ExitCode:        execLog.ExitCode,
+		TimedOut:        execLog.TimedOut,
+		Stdout:          execLog.Stdout,
+		Stderr:          execLog.Stderr,
+		Error:           execLog.Error,
+		StartedAt:       execLog.StartedAt,
+		DurationMs:      execLog.DurationMs,
+	}
+}
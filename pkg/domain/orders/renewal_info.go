@@ -0,0 +1,118 @@
+package orders
+
+import (
+	"database/sql"
+	"errors"
+	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/pagination_sort"
+	"legocerthub-backend/pkg/randomness"
+	"time"
+)
+
+// acmeService returns the appropriate ACME service for staging or prod
+func (service *Service) acmeService(isStaging bool) *acme.Service {
+	if isStaging {
+		return service.acmeStaging
+	}
+	return service.acmeProd
+}
+
+// replacesCertId returns the ARI certificate id of the specified cert's newest
+// valid order, for use in the 'replaces' field of a new order. If ARI is not
+// supported or there is no valid order to replace, a blank string is returned.
+func (service *Service) replacesCertId(acmeService *acme.Service, certId int) string {
+	if !acmeService.SupportsRenewalInfo() {
+		return ""
+	}
+
+	orderPem, err := service.storage.GetNewestValidCertOrderPem(certId)
+	if err != nil {
+		// no rows is not an error, the cert simply has nothing to replace
+		if !errors.Is(err, sql.ErrNoRows) {
+			service.logger.Errorf("failed to fetch newest valid order pem for cert %d (%s)", certId, err)
+		}
+		return ""
+	}
+
+	ariCertId, err := acme.AriCertificateId(orderPem)
+	if err != nil {
+		service.logger.Errorf("failed to calculate ari cert id for cert %d (%s)", certId, err)
+		return ""
+	}
+
+	return ariCertId
+}
+
+// ariRenewalCertIds returns the ids of all certificates (that are automatically
+// renewed) whose current valid order should be renewed now, based on the ACME server's suggested renewal window. A
+// renewal time is randomly selected within the window, and the cert is renewed if
+// that time is before nextCheck. If the window has already passed (e.g. the CA is
+// signaling a mass revocation), the cert is always renewed.
+func (service *Service) ariRenewalCertIds(nextCheck time.Time) (certIds []int, err error) {
+	currentOrders, _, err := service.storage.GetAllValidCurrentOrders(pagination_sort.QueryAll)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range currentOrders {
+		// skip certs without a pem or that are never automatically renewed
+		if order.Pem == nil || !order.Certificate.RenewalPolicy.AutoRenew() {
+			continue
+		}
+
+		acmeService := service.acmeService(order.Certificate.CertificateAccount.IsStaging)
+		if !acmeService.SupportsRenewalInfo() {
+			continue
+		}
+
+		renewalInfo, err := acmeService.GetRenewalInfo(*order.Pem)
+		if err != nil {
+			// log error, but keep going through remaining range
+			service.logger.Errorf("failed to fetch renewal info for order %d (cert %d) (%s)", order.ID, order.Certificate.ID, err)
+			continue
+		}
+
+		// unparsable window, ignore it
+		windowStart, err := renewalInfo.WindowStart()
+		if err != nil {
+			service.logger.Errorf("acme returned invalid renewal window start for order %d (cert %d) (%s)", order.ID, order.Certificate.ID, err)
+			continue
+		}
+		windowEnd, err := renewalInfo.WindowEnd()
+		if err != nil {
+			service.logger.Errorf("acme returned invalid renewal window end for order %d (cert %d) (%s)", order.ID, order.Certificate.ID, err)
+			continue
+		}
+
+		// invalid window, ignore it
+		if !windowEnd.After(windowStart) {
+			service.logger.Errorf("acme returned invalid renewal window for order %d (cert %d)", order.ID, order.Certificate.ID)
+			continue
+		}
+
+		// window already passed, CA wants the cert renewed asap
+		if !time.Now().Before(windowEnd) {
+			service.logger.Infof("renewal window for cert %d has passed, renewing early (explanation: %s)",
+				order.Certificate.ID, renewalInfo.ExplanationURL)
+			certIds = append(certIds, order.Certificate.ID)
+			continue
+		}
+
+		// select random time within the window
+		windowSeconds := int(windowEnd.Sub(windowStart).Seconds())
+		randomSeconds, err := randomness.GenerateRandomInt(windowSeconds)
+		if err != nil {
+			// if error, use the middle of the window
+			service.logger.Errorf("failed to generate ari random renewal time (%s)", err)
+			randomSeconds = windowSeconds / 2
+		}
+		renewalTime := windowStart.Add(time.Duration(randomSeconds) * time.Second)
+
+		if renewalTime.Before(nextCheck) {
+			service.logger.Debugf("cert %d selected renewal time %s is before next check, renewing", order.Certificate.ID, renewalTime)
+			certIds = append(certIds, order.Certificate.ID)
+		}
+	}
+
+	return certIds, nil
+}
@@ -0,0 +1,63 @@
+package orders
+
+import (
+	"context"
+	"errors"
+)
+
+// While a worker works a job, the job is tracked so it can be listed (with the
+// worker working it) and canceled. Canceling a running job cancels its context,
+// which stops solving the order's authorizations (challenge resources are still
+// deprovisioned). The job is then removed from the queue.
+
+var errOrderJobCanceled = errors.New("order job canceled")
+
+// runningJob is a job that a worker is currently working
+type runningJob struct {
+	workerId int
+	cancel   context.CancelFunc
+}
+
+// addRunningJob tracks the job as being worked by the worker
+func (service *Service) addRunningJob(jobId int, workerId int, cancel context.CancelFunc) {
+	service.runningMu.Lock()
+	defer service.runningMu.Unlock()
+
+	service.running[jobId] = runningJob{
+		workerId: workerId,
+		cancel:   cancel,
+	}
+}
+
+// removeRunningJob stops tracking the job (once the worker is done with it)
+func (service *Service) removeRunningJob(jobId int) {
+	service.runningMu.Lock()
+	defer service.runningMu.Unlock()
+
+	delete(service.running, jobId)
+}
+
+// runningJobWorker returns the id of the worker working the job. If the job isn't
+// running, ok is false.
+func (service *Service) runningJobWorker(jobId int) (workerId int, ok bool) {
+	service.runningMu.Lock()
+	defer service.runningMu.Unlock()
+
+	job, ok := service.running[jobId]
+	return job.workerId, ok
+}
+
+// cancelRunningJob cancels the job if it is running. If the job isn't running,
+// false is returned.
+func (service *Service) cancelRunningJob(jobId int) bool {
+	service.runningMu.Lock()
+	defer service.runningMu.Unlock()
+
+	job, ok := service.running[jobId]
+	if !ok {
+		return false
+	}
+
+	job.cancel()
+	return true
+}
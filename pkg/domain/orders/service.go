@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/challenges"
 	"legocerthub-backend/pkg/domain/authorizations"
 	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/domain/private_keys"
+	"legocerthub-backend/pkg/order_events"
 	"legocerthub-backend/pkg/output"
 	"legocerthub-backend/pkg/pagination_sort"
 	"sync"
@@ -25,6 +28,7 @@ type App interface {
 	GetAcmeStagingService() *acme.Service
 	GetCertificatesService() *certificates.Service
 	GetAuthsService() *authorizations.Service
+	GetOrderEventRecorder() *order_events.Recorder
 	GetShutdownContext() context.Context
 	GetShutdownWaitGroup() *sync.WaitGroup
 }
@@ -48,9 +52,32 @@ type Storage interface {
 	GetAllIncompleteOrderIds() (orderIds []int, err error)
 	GetExpiringCertIds(maxTimeRemaining time.Duration) (certIds []int, err error)
 	GetNewestIncompleteCertOrderId(certId int) (orderId int, err error)
+	GetNewestValidCertOrderPem(certId int) (orderPem string, err error)
+
+	// order job queue
+	PostOrderJob(orderId int, highPriority bool, nextAttemptAt int) (err error)
+	ClaimNextOrderJob(now int) (job OrderJob, err error)
+	PutOrderJobRetry(jobId int, attempts int, nextAttemptAt int, lastError string) (err error)
+	ResetOrderJobClaims() (err error)
+	DeleteOrderJob(jobId int) (err error)
+	GetAllOrderJobs() (jobs []OrderJob, err error)
+	DeleteUnclaimedOrderJob(jobId int) (orderId int, err error)
+
+	// challenge provider execution logs
+	GetProviderExecLogsByOrder(orderId int) (execLogs []challenges.ProviderExecLog, err error)
+
+	// order events
+	GetOrderEventsByOrder(orderId int) (events []order_events.Event, err error)
 
 	// certs
 	UpdateCertUpdatedTime(certId int) (err error)
+	PutCertRotatedKey(certId int, newKeyId int) (err error)
+
+	// keys (for cert key rotation)
+	GetOneKeyById(id int) (private_keys.Key, error)
+	PostNewKey(payload private_keys.NewPayload) (keyId int, err error)
+	DeleteKey(id int) (err error)
+	DeleteOrderNewKey(orderId int, keyId int) (err error)
 }
 
 // Configuration options
@@ -59,21 +86,28 @@ type Config struct {
 	ValidRemainingDaysThreshold *int  `yaml:"valid_remaining_days_threshold"`
 	RefreshTimeHour             *int  `yaml:"refresh_time_hour"`
 	RefreshTimeMinute           *int  `yaml:"refresh_time_minute"`
+	JobRetryMinSeconds          *int  `yaml:"job_retry_min_seconds"`
+	JobRetryMaxSeconds          *int  `yaml:"job_retry_max_seconds"`
+	JobMaxAttempts              *int  `yaml:"job_max_attempts"`
 }
 
 // Keys service struct
 type Service struct {
-	shutdownContext context.Context
-	logger          *zap.SugaredLogger
-	output          *output.Service
-	storage         Storage
-	acmeProd        *acme.Service
-	acmeStaging     *acme.Service
-	certificates    *certificates.Service
-	authorizations  *authorizations.Service
-	inProcess       *inProcess
-	highJobs        chan orderJob
-	lowJobs         chan orderJob
+	shutdownContext  context.Context
+	logger           *zap.SugaredLogger
+	output           *output.Service
+	storage          Storage
+	acmeProd         *acme.Service
+	acmeStaging      *acme.Service
+	certificates     *certificates.Service
+	authorizations   *authorizations.Service
+	events           *order_events.Recorder
+	jobWake          chan struct{}
+	jobRetryMinDelay time.Duration
+	jobRetryMaxDelay time.Duration
+	jobMaxAttempts   int
+	runningMu        sync.Mutex
+	running          map[int]runningJob
 }
 
 // NewService creates a new private_key service
@@ -123,18 +157,42 @@ func NewService(app App, cfg *Config) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
-	// initialize inProcess (tracker)
-	service.inProcess = newInProcess()
+	// order event recorder
+	service.events = app.GetOrderEventRecorder()
+	if service.events == nil {
+		return nil, errServiceComponent
+	}
+
+	// job retry timing
+	service.jobRetryMinDelay = defaultJobRetryMinDelay
+	if cfg.JobRetryMinSeconds != nil && *cfg.JobRetryMinSeconds > 0 {
+		service.jobRetryMinDelay = time.Duration(*cfg.JobRetryMinSeconds) * time.Second
+	}
+	service.jobRetryMaxDelay = defaultJobRetryMaxDelay
+	if cfg.JobRetryMaxSeconds != nil && *cfg.JobRetryMaxSeconds > 0 {
+		service.jobRetryMaxDelay = time.Duration(*cfg.JobRetryMaxSeconds) * time.Second
+	}
+	if service.jobRetryMaxDelay < service.jobRetryMinDelay {
+		service.jobRetryMaxDelay = service.jobRetryMinDelay
+	}
+	service.jobMaxAttempts = defaultJobMaxAttempts
+	if cfg.JobMaxAttempts != nil && *cfg.JobMaxAttempts > 0 {
+		service.jobMaxAttempts = *cfg.JobMaxAttempts
+	}
 
 	// workers
-	// make job channels for order workers
-	service.highJobs = make(chan orderJob)
-	service.lowJobs = make(chan orderJob)
-	workerCount := 3
-
-	// make workers
-	for i := 0; i < workerCount; i++ {
-		go service.makeOrderWorker(i, service.highJobs, service.lowJobs, app.GetShutdownWaitGroup())
+	// any claimed jobs were interrupted (e.g. by a crash), release them so they
+	// are resumed
+	err := service.storage.ResetOrderJobClaims()
+	if err != nil {
+		return nil, err
+	}
+
+	// make workers (wake is buffered so each worker can be woken)
+	service.running = make(map[int]runningJob)
+	service.jobWake = make(chan struct{}, orderWorkerCount)
+	for i := 0; i < orderWorkerCount; i++ {
+		go service.makeOrderWorker(i, app.GetShutdownWaitGroup())
 	}
 
 	// start service to automatically place and complete orders
@@ -1,95 +1,180 @@
 package orders
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"legocerthub-backend/pkg/acme"
+	"legocerthub-backend/pkg/order_events"
+	"legocerthub-backend/pkg/storage"
 	"net/http"
 	"sync"
 	"time"
 )
 
-// orderJob contains the info the worker needs to do a job
-type orderJob struct {
-	orderId int
-}
-
-// makeOrderWorker creates a indefinite thread to process incoming orderJobs
-func (service *Service) makeOrderWorker(id int, highPriorityJobs <-chan orderJob, lowPriorityJobs <-chan orderJob, wg *sync.WaitGroup) {
+// makeOrderWorker creates a indefinite thread that claims due jobs from the order
+// job queue and works them. When no jobs are due, the worker waits until it is
+// woken (a job was queued) or the poll interval elapses.
+func (service *Service) makeOrderWorker(id int, wg *sync.WaitGroup) {
 	service.logger.Debugf("starting order worker (%d)", id)
 	// acme directory refresh service shutdown complete
 	wg.Add(1)
 	defer wg.Done()
 
-doingWork:
 	for {
-		select {
-		case <-service.shutdownContext.Done():
-			// break to shutdown
-			break doingWork
-		case highJob := <-highPriorityJobs:
-			service.doOrderJob(highJob)
-			service.logger.Debugf("worker %d: end of high priority order fulfiller (orderId: %d)", id, highJob.orderId)
-		case lowJob := <-lowPriorityJobs:
-		lower:
-			for {
-				select {
-				case <-service.shutdownContext.Done():
-					// break to shutdown
-					break doingWork
-				case highJob := <-highPriorityJobs:
-					service.doOrderJob(highJob)
-					service.logger.Debugf("worker %d: end of high priority order fulfiller (orderId: %d)", id, highJob.orderId)
-				default:
-					break lower
+		// work jobs until none are due
+		for service.shutdownContext.Err() == nil {
+			job, err := service.storage.ClaimNextOrderJob(int(time.Now().Unix()))
+			if err != nil {
+				if !errors.Is(err, storage.ErrNoRecord) {
+					service.logger.Errorf("worker %d: failed to claim order job (%s)", id, err)
 				}
+				break
 			}
-			service.doOrderJob(lowJob)
-			service.logger.Debugf("worker %d: end of low priority order fulfiller (orderId: %d)", id, lowJob.orderId)
+
+			service.doOrderJob(job, id)
+			service.logger.Debugf("worker %d: end of order job (orderId: %d, high priority: %t)", id, job.OrderID, job.HighPriority)
 		}
-	}
 
-	service.logger.Debugf("order worker (%d) shutdown complete", id)
+		select {
+		case <-service.shutdownContext.Done():
+			service.logger.Debugf("order worker (%d) shutdown complete", id)
+			return
+		case <-service.jobWake:
+		case <-time.After(orderJobPollInterval):
+		}
+	}
 }
 
-// doOrderJob works the orderJob specified. Once complete, the job is removed from the inProcess
-// list to indicate completion. No results are returned as results are saved directly to storage
-// as part of doing the job.
-func (service *Service) doOrderJob(job orderJob) {
-	// remove id from inProcess when goroutine is done
-	defer func(orderId int, service *Service) {
-		err := service.inProcess.remove(orderId)
+// doOrderJob works the claimed job. If the order is finished (or can't be worked),
+// the job is removed from the queue. Otherwise, the job is released to be attempted
+// again after the retry delay, unless it has run out of attempts or was canceled.
+// No results are returned as results are saved directly to storage as part of
+// doing the job.
+func (service *Service) doOrderJob(job OrderJob, workerId int) {
+	// track the job while it runs, so it can be canceled
+	ctx, cancel := context.WithCancel(service.shutdownContext)
+	defer cancel()
+	service.addRunningJob(job.ID, workerId, cancel)
+	defer service.removeRunningJob(job.ID)
+
+	service.events.Record(job.OrderID, order_events.TypeJobStarted, "", fmt.Sprintf("attempt %d (worker %d, high priority: %t)", job.Attempts+1, workerId, job.HighPriority), nil)
+
+	retry, retryAfter, err := service.fulfillOrder(ctx, job.OrderID)
+	if err != nil {
+		service.logger.Errorf("order %d: %s", job.OrderID, err)
+	}
+
+	if !retry {
+		service.events.Record(job.OrderID, order_events.TypeJobFinished, "", "", err)
+		err = service.storage.DeleteOrderJob(job.ID)
 		if err != nil {
-			service.logger.Error(err)
+			service.logger.Errorf("failed to remove order job %d from queue (%s)", job.ID, err)
+		}
+		service.deleteAbandonedRotationKey(job.OrderID)
+		return
+	}
+
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+
+		// use the ACME server's Retry-After (e.g. rate limited), if it sent one
+		var acmeErr acme.Error
+		if errors.As(err, &acmeErr) && acmeErr.RetryAfter > retryAfter {
+			retryAfter = acmeErr.RetryAfter
 		}
-	}(job.orderId, service)
+	}
 
+	// if shutting down, the job was likely interrupted (not failed) so release it
+	// to resume right away on next start
+	if service.shutdownContext.Err() != nil {
+		service.events.Record(job.OrderID, order_events.TypeJobRetryScheduled, "", "interrupted by shutdown, resumes on next start", err)
+		err = service.storage.PutOrderJobRetry(job.ID, job.Attempts, int(time.Now().Unix()), lastError)
+		if err != nil {
+			service.logger.Errorf("failed to release order job %d (%s)", job.ID, err)
+		}
+		return
+	}
+
+	// canceled; remove the job (the order remains incomplete and can be retried
+	// manually or by the next automatic ordering run)
+	if ctx.Err() != nil {
+		service.logger.Infof("order %d: job %d canceled", job.OrderID, job.ID)
+		service.events.Record(job.OrderID, order_events.TypeJobCanceled, "", "", err)
+		err = service.storage.DeleteOrderJob(job.ID)
+		if err != nil {
+			service.logger.Errorf("failed to remove order job %d from queue (%s)", job.ID, err)
+		}
+		service.deleteAbandonedRotationKey(job.OrderID)
+		return
+	}
+
+	// out of attempts; remove the job (the order remains incomplete and can be
+	// retried manually or by the next automatic ordering run)
+	attempts := job.Attempts + 1
+	if attempts >= service.jobMaxAttempts {
+		service.logger.Errorf("order %d: giving up after %d attempts", job.OrderID, attempts)
+		service.events.Record(job.OrderID, order_events.TypeJobAbandoned, "", fmt.Sprintf("out of attempts (%d)", attempts), err)
+		err = service.storage.DeleteOrderJob(job.ID)
+		if err != nil {
+			service.logger.Errorf("failed to remove order job %d from queue (%s)", job.ID, err)
+		}
+		service.deleteAbandonedRotationKey(job.OrderID)
+		return
+	}
+
+	// wait the longer of backoff or what the ACME server asked for
+	delay := service.jobRetryDelay(attempts)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+
+	service.logger.Debugf("order %d: attempt %d incomplete, next attempt in %s", job.OrderID, attempts, delay)
+	service.events.Record(job.OrderID, order_events.TypeJobRetryScheduled, "", fmt.Sprintf("next attempt in %s", delay), err)
+	err = service.storage.PutOrderJobRetry(job.ID, attempts, int(time.Now().Add(delay).Unix()), lastError)
+	if err != nil {
+		service.logger.Errorf("failed to reschedule order job %d (%s)", job.ID, err)
+	}
+}
+
+// fulfillOrder attempts to move the order to a final ('valid' or 'invalid') state,
+// saving results to storage. If the order should be attempted again later, retry is
+// true and retryAfter is the delay the ACME server suggested (0 if none). The error
+// (if any) is why this attempt failed. Canceling ctx stops fulfilling the order
+// (and retry is true, since the order isn't finished).
+func (service *Service) fulfillOrder(ctx context.Context, orderId int) (retry bool, retryAfter time.Duration, err error) {
 	// fetch the relevant order
-	orderDb, err := service.storage.GetOneOrder(job.orderId)
+	orderDb, err := service.storage.GetOneOrder(orderId)
 	if err != nil {
-		service.logger.Error(err)
-		return // done, failed
+		// retry unless the order no longer exists
+		return !errors.Is(err, storage.ErrNoRecord), 0, err
 	}
 
 	// update certificate timestamp after fulfiller is done
 	defer func(certId int) {
-		err = service.storage.UpdateCertUpdatedTime(certId)
-		if err != nil {
-			service.logger.Error(err)
+		updateErr := service.storage.UpdateCertUpdatedTime(certId)
+		if updateErr != nil {
+			service.logger.Error(updateErr)
 		}
 	}(orderDb.Certificate.ID)
 
 	// get account key
 	key, err := orderDb.Certificate.CertificateAccount.AcmeAccountKey()
 	if err != nil {
-		service.logger.Error(err)
-		return // done, failed
+		return false, 0, err // done, failed
+	}
+
+	// key to finalize with (the order's new key, if the cert is rotating keys)
+	orderKey, err := service.orderKey(orderDb)
+	if err != nil {
+		return !errors.Is(err, storage.ErrNoRecord), 0, err
 	}
 
 	// make cert CSR
-	csr, err := orderDb.Certificate.MakeCsrDer()
+	csr, err := orderDb.Certificate.MakeCsrDer(orderKey)
 	if err != nil {
-		service.logger.Error(err)
-		return // done, failed
+		return false, 0, err // done, failed
 	}
 
 	// acmeOrder to hold the Order responses and to later update storage
@@ -103,12 +188,20 @@ func (service *Service) doOrderJob(job orderJob) {
 		acmeService = service.acmeProd
 	}
 
-	// Use loop to retry order. Cap retries to avoid indefinite loop.
+	// Use loop to move the order through its states. Cap tries to avoid indefinite
+	// loop (e.g. if the cert url is missing); the job is retried later if reached.
 	maxTries := 5
+	finished := false
 fulfillLoop:
 	for i := 1; i <= maxTries; i++ {
+		// stop if canceled
+		if ctx.Err() != nil {
+			return true, 0, errOrderJobCanceled
+		}
+
 		// Get the order (for most recent Order object and Status)
 		acmeOrder, err = acmeService.GetOrder(orderDb.Location, key)
+		service.events.Record(orderId, order_events.TypeOrderFetched, "", acmeOrder.Status, err)
 		if err != nil {
 			// if ACME returned 404, the order object is now invalid
 			// assume the ACME server deleted it and update accordingly
@@ -116,20 +209,22 @@ fulfillLoop:
 			// actions before the "expires" time, then the server SHOULD change the
 			// status of the order to "invalid" and MAY delete the order resource.")
 			if acmeErr, ok := err.(acme.Error); ok && acmeErr.Status == http.StatusNotFound {
-				service.storage.PutOrderInvalid(job.orderId)
+				invalidErr := service.storage.PutOrderInvalid(orderId)
+				if invalidErr != nil {
+					service.logger.Error(invalidErr)
+				}
+				return false, 0, err // done, failed
 			}
-			service.logger.Error(err)
-			return // done, failed
+			return true, 0, err
 		}
 
 		// action depends on order's current Status
 		switch acmeOrder.Status {
 		case "pending": // needs to be authed
 			var authStatus string
-			authStatus, err = service.authorizations.FulfillAuths(acmeOrder.Authorizations, orderDb.Certificate.ChallengeMethod, key, orderDb.Certificate.CertificateAccount.IsStaging)
+			authStatus, err = service.authorizations.FulfillAuths(ctx, acmeOrder.Authorizations, orderDb.Certificate, key, orderDb.Certificate.CertificateAccount.IsStaging, orderId)
 			if err != nil {
-				service.logger.Error(err)
-				return // done, failed
+				return true, 0, err
 			}
 			// auth should be valid (thus making order ready)
 			// if not valid, should be invalid, loop to get updated order (also now invalid)
@@ -138,21 +233,24 @@ fulfillLoop:
 			}
 
 			// auths were valid, fallthrough to "ready" (which order should now be in)
+			// (unless canceled after the auths were solved)
+			if ctx.Err() != nil {
+				return true, 0, errOrderJobCanceled
+			}
 			fallthrough
 
 		case "ready": // needs to be finalized
 			// save finalized_key_id in storage
-			err = service.storage.UpdateFinalizedKey(orderDb.ID, orderDb.Certificate.CertificateKey.ID)
+			err = service.storage.UpdateFinalizedKey(orderDb.ID, orderKey.ID)
 			if err != nil {
-				service.logger.Error(err)
-				return // done, failed
+				return true, 0, err
 			}
 
 			// finalize the order
 			acmeOrder, err = acmeService.FinalizeOrder(acmeOrder.Finalize, csr, key)
+			service.events.Record(orderId, order_events.TypeFinalizeResponse, "", acmeOrder.Status, err)
 			if err != nil {
-				service.logger.Error(err)
-				return // done, failed
+				return true, 0, err
 			}
 
 			// should now be valid, if not, probably processing
@@ -167,54 +265,48 @@ fulfillLoop:
 			// download cert pem
 			// nil check (make sure there is a cert URL)
 			if acmeOrder.Certificate != nil {
-				certPemChain, err := acmeService.DownloadCertificate(*acmeOrder.Certificate, key)
-				if err != nil {
-					service.logger.Error(err)
-					return // done, failed
+				certPemChain, err := acmeService.DownloadCertificate(*acmeOrder.Certificate, key, orderDb.Certificate.PreferredRootCN)
+				if err == nil {
+					// process pem and save to storage
+					err = service.savePemChain(orderDb.ID, certPemChain)
 				}
-
-				// process pem and save to storage
-				err = service.savePemChain(orderDb.ID, certPemChain)
+				// switch the cert to the order's new key (if rotating keys)
+				if err == nil && orderDb.NewKeyID != nil {
+					err = service.storage.PutCertRotatedKey(orderDb.Certificate.ID, *orderDb.NewKeyID)
+				}
+				service.events.Record(orderId, order_events.TypeCertificateDownloaded, "", *acmeOrder.Certificate, err)
 				if err != nil {
-					service.logger.Error(err)
-					return
+					return true, 0, err
 				}
 
+				finished = true
 				break fulfillLoop
 			}
 
 			// if cert url is missing (nil), loop again (which will refresh order info)
 
 		case "processing":
-			// TODO: Implement exponential backoff
-			if i != maxTries {
-				// cancel on shutdown context
-				select {
-				case <-service.shutdownContext.Done():
-					// abort refreshing due to shutdown
-					service.logger.Error("order job canceled due to shutdown")
-					return
-
-				case <-time.After(time.Duration(i) * 30 * time.Second):
-					// sleep and retry
-				}
-			}
+			// check again later (after the server's Retry-After, if it sent one)
+			retryAfter = acmeOrder.RetryAfter
+			break fulfillLoop
 
 		case "invalid": // break, irrecoverable
 			service.logger.Debugf("order status invalid; acme error: %s", acmeOrder.Error)
+			finished = true
 			break fulfillLoop
 
 		// Note: there is no 'expired' Status case. If the order expires it simply moves to 'invalid'.
 
 		default:
-			service.logger.Error(errors.New("order status unknown"))
-			return // done, failed
+			return false, 0, errors.New("order status unknown") // done, failed
 		}
 	}
 
 	// update order in storage
-	err = service.storage.PutOrderAcme(makeUpdateOrderAcmePayload(job.orderId, acmeOrder))
+	err = service.storage.PutOrderAcme(makeUpdateOrderAcmePayload(orderId, acmeOrder))
 	if err != nil {
 		service.logger.Error(err)
 	}
+
+	return !finished, retryAfter, nil
 }
@@ -16,8 +16,11 @@ type Key struct {
 	ApiKeyNew      string
 	ApiKeyDisabled bool
 	ApiKeyViaUrl   bool
-	CreatedAt      int
-	UpdatedAt      int
+	// Archived keys were replaced by a certificate's rotated key and are kept (not
+	// deleted), but aren't available to use again
+	Archived  bool
+	CreatedAt int
+	UpdatedAt int
 }
 
 // keySummaryResponse is a JSON response containing only
@@ -29,6 +32,7 @@ type KeySummaryResponse struct {
 	Algorithm      key_crypto.Algorithm `json:"algorithm"`
 	ApiKeyDisabled bool                 `json:"api_key_disabled"`
 	ApiKeyViaUrl   bool                 `json:"api_key_via_url"`
+	Archived       bool                 `json:"archived"`
 }
 
 func (key Key) SummaryResponse() KeySummaryResponse {
@@ -39,6 +43,7 @@ func (key Key) SummaryResponse() KeySummaryResponse {
 		Algorithm:      key.Algorithm,
 		ApiKeyDisabled: key.ApiKeyDisabled,
 		ApiKeyViaUrl:   key.ApiKeyViaUrl,
+		Archived:       key.Archived,
 	}
 }
 
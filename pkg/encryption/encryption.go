@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Secrets (e.g. third party credentials) are encrypted before being saved to
+// storage using AES-256-GCM. The key is kept in a file outside of the database
+// so a copy of the database alone does not expose the secrets.
+
+const keyLength = 32
+
+var (
+	errKeyInvalid        = errors.New("encryption key is invalid (must be 32 hex encoded bytes)")
+	errCiphertextInvalid = errors.New("encrypted value is invalid")
+)
+
+// Cipher encrypts and decrypts strings
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// LoadOrCreateKey reads the hex encoded key from the file at path. If the file
+// does not exist, a new random key is generated and saved to the file.
+func LoadOrCreateKey(path string) ([]byte, error) {
+	keyHex, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+		if err != nil || len(key) != keyLength {
+			return nil, errKeyInvalid
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// generate new key
+	key := make([]byte, keyLength)
+	_, err = io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// write to a temp file (created 0600) and then rename it into place, so a
+	// crash never leaves a partial key file
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	_, err = file.WriteString(hex.EncodeToString(key) + "\n")
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// New creates a Cipher using the specified 32 byte key
+func New(key []byte) (*Cipher, error) {
+	if len(key) != keyLength {
+		return nil, errKeyInvalid
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// EncryptString encrypts plaintext and returns the base64 encoded nonce and
+// ciphertext
+func (c *Cipher) EncryptString(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString decrypts a value that was encrypted with EncryptString
+func (c *Cipher) DecryptString(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errCiphertextInvalid
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errCiphertextInvalid
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", errCiphertextInvalid
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,136 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func newTestCipher(t *testing.T) *Cipher {
+	t.Helper()
+
+	cipher, err := New(bytes.Repeat([]byte{7}, keyLength))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %s", err)
+	}
+
+	return cipher
+}
+
+// TestEncryptDecrypt confirms values decrypt to the original plaintext and that
+// encrypting the same value twice gives different ciphertexts
+func TestEncryptDecrypt(t *testing.T) {
+	cipher := newTestCipher(t)
+
+	for _, plaintext := range []string{"", "secret", "a longer secret with unicode ✓"} {
+		encrypted, err := cipher.EncryptString(plaintext)
+		if err != nil {
+			t.Fatalf("failed to encrypt %q: %s", plaintext, err)
+		}
+
+		again, _ := cipher.EncryptString(plaintext)
+		if again == encrypted {
+			t.Errorf("%q: encrypted twice to the same ciphertext (nonce reused)", plaintext)
+		}
+
+		decrypted, err := cipher.DecryptString(encrypted)
+		if err != nil {
+			t.Fatalf("failed to decrypt %q: %s", plaintext, err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("decrypted: got %q, want %q", decrypted, plaintext)
+		}
+	}
+}
+
+// TestDecryptRejects confirms tampered, short, and wrong key ciphertexts are
+// rejected
+func TestDecryptRejects(t *testing.T) {
+	cipher := newTestCipher(t)
+
+	encrypted, err := cipher.EncryptString("secret")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %s", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(encrypted)
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	otherCipher, err := New(bytes.Repeat([]byte{8}, keyLength))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %s", err)
+	}
+	_, err = otherCipher.DecryptString(encrypted)
+	if !errors.Is(err, errCiphertextInvalid) {
+		t.Errorf("wrong key: got %v, want %v", err, errCiphertextInvalid)
+	}
+
+	tests := map[string]string{
+		"tampered":           base64.StdEncoding.EncodeToString(tampered),
+		"shorter than nonce": base64.StdEncoding.EncodeToString(sealed[:5]),
+		"nonce only":         base64.StdEncoding.EncodeToString(sealed[:12]),
+		"empty":              "",
+		"not base64":         "not base64!",
+	}
+	for name, value := range tests {
+		_, err := cipher.DecryptString(value)
+		if !errors.Is(err, errCiphertextInvalid) {
+			t.Errorf("%s: got %v, want %v", name, err, errCiphertextInvalid)
+		}
+	}
+
+	_, err = New(make([]byte, 16))
+	if !errors.Is(err, errKeyInvalid) {
+		t.Errorf("short key: got %v, want %v", err, errKeyInvalid)
+	}
+}
+
+// TestLoadOrCreateKey confirms a new key is saved (privately and without leaving
+// temp files) and then loaded again, and that an invalid key file is rejected
+func TestLoadOrCreateKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "encryption.key")
+
+	key, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatalf("failed to create key: %s", err)
+	}
+	if len(key) != keyLength {
+		t.Errorf("key length: got %d, want %d", len(key), keyLength)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("key file not saved: %s", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode: got %s, want %s", info.Mode().Perm(), os.FileMode(0600))
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d files, want only the key file", len(entries))
+	}
+
+	reloaded, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatalf("failed to reload key: %s", err)
+	}
+	if !bytes.Equal(reloaded, key) {
+		t.Error("reloaded key is not the created key")
+	}
+
+	// a truncated key file is rejected rather than replaced
+	err = os.WriteFile(path, []byte("abcd\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key file: %s", err)
+	}
+	_, err = LoadOrCreateKey(path)
+	if !errors.Is(err, errKeyInvalid) {
+		t.Errorf("truncated key: got %v, want %v", err, errKeyInvalid)
+	}
+}
@@ -49,6 +49,17 @@ func New(userAgent string, devMode bool) (client *Client) {
 	return client
 }
 
+// WithTimeout returns a copy of the client that uses the specified overall
+// request timeout (instead of the client's default)
+func (client *Client) WithTimeout(timeout time.Duration) *Client {
+	timeoutClient := new(Client)
+	timeoutClient.http.Timeout = timeout
+	timeoutClient.http.Transport = client.http.Transport
+	timeoutClient.userAgent = client.userAgent
+
+	return timeoutClient
+}
+
 // newRequest creates an http request for the client to later do
 func (client *Client) NewRequest(method string, url string, body io.Reader) (*http.Request, error) {
 	request, err := http.NewRequest(method, url, body)
@@ -0,0 +1,139 @@
+package order_events
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Each step of processing an order (fetching the order and its authorizations,
+// provisioning and checking challenge resources, answering challenges, finalizing,
+// etc.) is recorded as an Event for the order. This provides a timeline of what
+// happened to the order without needing to search the logs.
+
+// NoOrder is the orderId used when a step isn't for an order (e.g. a challenge
+// provider self test). Events without an order are not recorded.
+const NoOrder = 0
+
+var errServiceComponent = errors.New("necessary order events component is missing")
+
+// Type is the type of step an Event records
+type Type string
+
+const (
+	// order job
+	TypeJobStarted        Type = "job_started"
+	TypeJobRetryScheduled Type = "job_retry_scheduled"
+	TypeJobFinished       Type = "job_finished"
+	TypeJobAbandoned      Type = "job_abandoned"
+	TypeJobCanceled       Type = "job_canceled"
+
+	// acme order
+	TypeOrderFetched          Type = "order_fetched"
+	TypeFinalizeResponse      Type = "finalize_response"
+	TypeCertificateDownloaded Type = "certificate_downloaded"
+
+	// authorizations and challenges
+	TypeAuthorizationFetched   Type = "authorization_fetched"
+	TypeResourceProvisioned    Type = "resource_provisioned"
+	TypePropagationConfirmed   Type = "propagation_confirmed"
+	TypeChallengeAnswered      Type = "challenge_answered"
+	TypeChallengeCompleted     Type = "challenge_completed"
+	TypeResourceDeprovisioned  Type = "resource_deprovisioned"
+	TypeAuthorizationCompleted Type = "authorization_completed"
+)
+
+// Event is a single step of processing an order. If the step failed, Error
+// describes why (it is blank if the step succeeded).
+type Event struct {
+	ID         int
+	OrderID    int
+	Type       Type
+	Identifier string
+	Detail     string
+	Error      string
+	CreatedAt  int
+}
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	PostNewOrderEvent(event Event) (id int, err error)
+}
+
+// Recorder records order events to storage
+type Recorder struct {
+	logger    *zap.SugaredLogger
+	storageMu sync.RWMutex
+	storage   Storage
+}
+
+// NewRecorder creates a new Recorder. Events are only logged (not saved) until
+// storage is configured.
+func NewRecorder(app App) (*Recorder, error) {
+	recorder := &Recorder{
+		logger: app.GetLogger(),
+	}
+	if recorder.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	return recorder, nil
+}
+
+// ConfigureStorage sets the storage events are saved to. Storage is configured
+// after the recorder is created since the app's storage depends on services
+// that use the recorder.
+func (recorder *Recorder) ConfigureStorage(storage Storage) error {
+	if storage == nil {
+		return errServiceComponent
+	}
+
+	recorder.storageMu.Lock()
+	defer recorder.storageMu.Unlock()
+
+	recorder.storage = storage
+	return nil
+}
+
+// Record saves an event for the order. identifier is the identifier value the
+// step was for (blank if the step was for the whole order) and stepErr is the
+// reason the step failed (nil if it succeeded). If saving fails, the error is
+// logged but processing continues.
+func (recorder *Recorder) Record(orderId int, eventType Type, identifier string, detail string, stepErr error) {
+	// nil recorder is a no-op (e.g. services used without the app)
+	if recorder == nil || orderId == NoOrder {
+		return
+	}
+
+	event := Event{
+		OrderID:    orderId,
+		Type:       eventType,
+		Identifier: identifier,
+		Detail:     detail,
+		CreatedAt:  int(time.Now().Unix()),
+	}
+	if stepErr != nil {
+		event.Error = stepErr.Error()
+	}
+
+	recorder.logger.Debugf("order %d event: %s %s (%s) %s", orderId, event.Type, event.Identifier, event.Detail, event.Error)
+
+	recorder.storageMu.RLock()
+	storage := recorder.storage
+	recorder.storageMu.RUnlock()
+	if storage == nil {
+		return
+	}
+
+	_, err := storage.PostNewOrderEvent(event)
+	if err != nil {
+		recorder.logger.Errorf("failed to save %s event for order %d (%s)", eventType, orderId, err)
+	}
+}
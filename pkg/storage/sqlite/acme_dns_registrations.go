@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/challenges"
+)
+
+// acmeDnsRegistrationDb is a single automatic acme-dns registration, as database
+// table fields
+// corresponds to challenges.AcmeDnsRegistration
+type acmeDnsRegistrationDb struct {
+	id                int
+	acmeDnsAddress    string
+	realDomain        string
+	fullDomain        string
+	usernameEncrypted string
+	passwordEncrypted string
+	createdAt         int
+}
+
+// toAcmeDnsRegistration maps the database registration to the challenges
+// AcmeDnsRegistration object
+func (reg acmeDnsRegistrationDb) toAcmeDnsRegistration() challenges.AcmeDnsRegistration {
+	return challenges.AcmeDnsRegistration{
+		ID:                reg.id,
+		AcmeDnsAddress:    reg.acmeDnsAddress,
+		RealDomain:        reg.realDomain,
+		FullDomain:        reg.fullDomain,
+		UsernameEncrypted: reg.usernameEncrypted,
+		PasswordEncrypted: reg.passwordEncrypted,
+		CreatedAt:         reg.createdAt,
+	}
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/storage"
+)
+
+// DeleteAcmeDnsRegistration deletes an automatic acme-dns registration from the
+// db
+func (store *Storage) DeleteAcmeDnsRegistration(id int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		acme_dns_registrations
+	WHERE
+		id = $1
+	`
+
+	result, err := store.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
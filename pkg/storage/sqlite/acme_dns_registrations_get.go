@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/storage"
+)
+
+// GetAllAcmeDnsRegistrations returns all of the automatic acme-dns registrations
+func (store *Storage) GetAllAcmeDnsRegistrations() (registrations []challenges.AcmeDnsRegistration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, acme_dns_address, real_domain, full_domain, username, password, created_at
+	FROM
+		acme_dns_registrations
+	ORDER BY
+		real_domain, id
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneRegistration acmeDnsRegistrationDb
+		err = rows.Scan(
+			&oneRegistration.id,
+			&oneRegistration.acmeDnsAddress,
+			&oneRegistration.realDomain,
+			&oneRegistration.fullDomain,
+			&oneRegistration.usernameEncrypted,
+			&oneRegistration.passwordEncrypted,
+			&oneRegistration.createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		registrations = append(registrations, oneRegistration.toAcmeDnsRegistration())
+	}
+
+	return registrations, nil
+}
+
+// GetAcmeDnsRegistration returns the registration of the real domain with the
+// specified acme-dns server
+func (store *Storage) GetAcmeDnsRegistration(acmeDnsAddress string, realDomain string) (registration challenges.AcmeDnsRegistration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, acme_dns_address, real_domain, full_domain, username, password, created_at
+	FROM
+		acme_dns_registrations
+	WHERE
+		acme_dns_address = $1
+		AND
+		real_domain = $2
+	`
+
+	row := store.Db.QueryRowContext(ctx, query, acmeDnsAddress, realDomain)
+
+	var oneRegistration acmeDnsRegistrationDb
+	err = row.Scan(
+		&oneRegistration.id,
+		&oneRegistration.acmeDnsAddress,
+		&oneRegistration.realDomain,
+		&oneRegistration.fullDomain,
+		&oneRegistration.usernameEncrypted,
+		&oneRegistration.passwordEncrypted,
+		&oneRegistration.createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = storage.ErrNoRecord
+		}
+		return challenges.AcmeDnsRegistration{}, err
+	}
+
+	return oneRegistration.toAcmeDnsRegistration(), nil
+}
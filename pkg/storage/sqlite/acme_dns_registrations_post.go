@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// PostNewAcmeDnsRegistration saves a new automatic acme-dns registration to the
+// db
+func (store *Storage) PostNewAcmeDnsRegistration(registration challenges.AcmeDnsRegistration) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO acme_dns_registrations (acme_dns_address, real_domain, full_domain, username, password, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	err = store.Db.QueryRowContext(ctx, query,
+		registration.AcmeDnsAddress,
+		registration.RealDomain,
+		registration.FullDomain,
+		registration.UsernameEncrypted,
+		registration.PasswordEncrypted,
+		registration.CreatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
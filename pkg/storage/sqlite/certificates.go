@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"encoding/json"
 	"legocerthub-backend/pkg/challenges"
 	"legocerthub-backend/pkg/domain/certificates"
 )
@@ -26,6 +27,12 @@ type certificateDb struct {
 	apiKey               string
 	apiKeyNew            string
 	apiKeyViaUrl         bool
+	preferredRootCN      string
+	challengeMethodMap   methodMapJson
+	renewalPolicy        string
+	renewalPolicyValue   int
+	keyRotation          bool
+	keyRotationAlgorithm string
 }
 
 func (cert certificateDb) toCertificate(store *Storage) certificates.Certificate {
@@ -38,6 +45,7 @@ func (cert certificateDb) toCertificate(store *Storage) certificates.Certificate
 		Subject:            cert.subject,
 		SubjectAltNames:    cert.subjectAltNames.toSlice(),
 		ChallengeMethod:    store.challenges.MethodByStorageValue(cert.challengeMethodValue),
+		ChallengeMethodMap: cert.challengeMethodMap.toMethodMap(store),
 		Organization:       cert.organization,
 		OrganizationalUnit: cert.organizationalUnit,
 		Country:            cert.country,
@@ -48,5 +56,60 @@ func (cert certificateDb) toCertificate(store *Storage) certificates.Certificate
 		ApiKey:             cert.apiKey,
 		ApiKeyNew:          cert.apiKeyNew,
 		ApiKeyViaUrl:       cert.apiKeyViaUrl,
+		PreferredRootCN:    cert.preferredRootCN,
+		RenewalPolicy: certificates.RenewalPolicy{
+			Type:  certificates.RenewalPolicyType(cert.renewalPolicy),
+			Value: cert.renewalPolicyValue,
+		},
+		KeyRotation: certificates.KeyRotation{
+			Enabled:        cert.keyRotation,
+			AlgorithmValue: cert.keyRotationAlgorithm,
+		},
 	}
 }
+
+// methodMapJson is a certificate's challenge method map, stored as a json
+// object of identifier (or suffix) to method value
+type methodMapJson string
+
+// toMethodMap transforms the stored json into a map of Methods
+func (mmj methodMapJson) toMethodMap(store *Storage) map[string]challenges.Method {
+	methodMap := make(map[string]challenges.Method)
+	if mmj == "" {
+		return methodMap
+	}
+
+	var methodValues map[string]challenges.MethodValue
+	err := json.Unmarshal([]byte(mmj), &methodValues)
+	if err != nil {
+		// if unmarshal fails, return empty map
+		return methodMap
+	}
+
+	for key, value := range methodValues {
+		methodMap[key] = store.challenges.MethodByStorageValue(value)
+	}
+
+	return methodMap
+}
+
+// makeMethodMapJson creates the json to store from a map of method values. A nil
+// map returns nil.
+func makeMethodMapJson(methodValues map[string]challenges.MethodValue) (*methodMapJson, error) {
+	if methodValues == nil {
+		return nil, nil
+	}
+
+	mmj := new(methodMapJson)
+	if len(methodValues) == 0 {
+		return mmj, nil
+	}
+
+	jsonBytes, err := json.Marshal(methodValues)
+	if err != nil {
+		return nil, err
+	}
+	*mmj = methodMapJson(jsonBytes)
+
+	return mmj, nil
+}
@@ -44,7 +44,9 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 	SELECT 
 		c.id, c.name, c.description, c.subject, c.subject_alts, c.challenge_method, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url,
+		c.api_key, c.api_key_new, c.api_key_via_url, c.preferred_root_cn,
+		c.challenge_method_map, c.renewal_policy, c.renewal_policy_value,
+		c.key_rotation, c.key_rotation_algorithm,
 		
 		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
 		pk.api_key_disabled, pk.api_key_via_url, pk.created_at, pk.updated_at,
@@ -101,6 +103,12 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 			&oneCert.apiKey,
 			&oneCert.apiKeyNew,
 			&oneCert.apiKeyViaUrl,
+			&oneCert.preferredRootCN,
+			&oneCert.challengeMethodMap,
+			&oneCert.renewalPolicy,
+			&oneCert.renewalPolicyValue,
+			&oneCert.keyRotation,
+			&oneCert.keyRotationAlgorithm,
 
 			&oneCert.certificateKeyDb.id,
 			&oneCert.certificateKeyDb.name,
@@ -169,7 +177,9 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 	SELECT
 		c.id, c.name, c.description, c.subject, c.subject_alts, c.challenge_method, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url,
+		c.api_key, c.api_key_new, c.api_key_via_url, c.preferred_root_cn,
+		c.challenge_method_map, c.renewal_policy, c.renewal_policy_value,
+		c.key_rotation, c.key_rotation_algorithm,
 		
 		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
 		pk.api_key_disabled, pk.api_key_via_url, pk.created_at, pk.updated_at,
@@ -210,6 +220,12 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		&oneCert.apiKey,
 		&oneCert.apiKeyNew,
 		&oneCert.apiKeyViaUrl,
+		&oneCert.preferredRootCN,
+		&oneCert.challengeMethodMap,
+		&oneCert.renewalPolicy,
+		&oneCert.renewalPolicyValue,
+		&oneCert.keyRotation,
+		&oneCert.keyRotationAlgorithm,
 
 		&oneCert.certificateKeyDb.id,
 		&oneCert.certificateKeyDb.name,
@@ -14,11 +14,22 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (id int, err
 	// don't check for in use in storage. main app business logic should
 	// take care of it
 
+	// method map json (never null for a new cert)
+	methodMap, err := makeMethodMapJson(payload.ChallengeMethodMap)
+	if err != nil {
+		return -2, err
+	}
+	if methodMap == nil {
+		methodMap = new(methodMapJson)
+	}
+
 	// insert the new cert
 	query := `
 	INSERT INTO certificates (name, description, private_key_id, acme_account_id, challenge_method, subject, subject_alts, 
-		csr_org, csr_ou, csr_country, csr_state, csr_city, created_at, updated_at, api_key, api_key_via_url)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		csr_org, csr_ou, csr_country, csr_state, csr_city, created_at, updated_at, api_key, api_key_via_url,
+		preferred_root_cn, challenge_method_map, renewal_policy, renewal_policy_value, key_rotation,
+		key_rotation_algorithm)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	RETURNING id
 	`
 
@@ -39,6 +50,12 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (id int, err
 		payload.UpdatedAt,
 		payload.ApiKey,
 		payload.ApiKeyViaUrl,
+		payload.PreferredRootCN,
+		methodMap,
+		payload.RenewalPolicy.Type,
+		payload.RenewalPolicy.Value,
+		payload.KeyRotation.Enabled,
+		payload.KeyRotation.AlgorithmValue,
 	).Scan(&id)
 
 	if err != nil {
@@ -2,7 +2,11 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/storage"
 	"time"
 )
 
@@ -13,6 +17,28 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
 	defer cancel()
 
+	// method map json (nil if not being updated)
+	methodMap, err := makeMethodMapJson(payload.ChallengeMethodMap)
+	if err != nil {
+		return err
+	}
+
+	// renewal policy (nil if not being updated)
+	var renewalPolicyType *certificates.RenewalPolicyType
+	var renewalPolicyValue *int
+	if payload.RenewalPolicy != nil {
+		renewalPolicyType = &payload.RenewalPolicy.Type
+		renewalPolicyValue = &payload.RenewalPolicy.Value
+	}
+
+	// key rotation (nil if not being updated)
+	var keyRotation *bool
+	var keyRotationAlgorithm *string
+	if payload.KeyRotation != nil {
+		keyRotation = &payload.KeyRotation.Enabled
+		keyRotationAlgorithm = &payload.KeyRotation.AlgorithmValue
+	}
+
 	query := `
 		UPDATE
 			certificates
@@ -27,10 +53,16 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 			csr_country = case when $8 is null then csr_country else $8 end,
 			csr_state = case when $9 is null then csr_state else $9 end,
 			csr_city = case when $10 is null then csr_city else $10 end,
-			api_key_via_url = case when $12 is null then api_key_via_url else $12 end,
-			updated_at = $11
+			api_key_via_url = case when $11 is null then api_key_via_url else $11 end,
+			preferred_root_cn = case when $12 is null then preferred_root_cn else $12 end,
+			challenge_method_map = case when $13 is null then challenge_method_map else $13 end,
+			renewal_policy = case when $14 is null then renewal_policy else $14 end,
+			renewal_policy_value = case when $15 is null then renewal_policy_value else $15 end,
+			key_rotation = case when $16 is null then key_rotation else $16 end,
+			key_rotation_algorithm = case when $17 is null then key_rotation_algorithm else $17 end,
+			updated_at = $18
 		WHERE
-			id = $13
+			id = $19
 		`
 
 	_, err = store.Db.ExecContext(ctx, query,
@@ -45,6 +77,12 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 		payload.State,
 		payload.City,
 		payload.ApiKeyViaUrl,
+		payload.PreferredRootCN,
+		methodMap,
+		renewalPolicyType,
+		renewalPolicyValue,
+		keyRotation,
+		keyRotationAlgorithm,
 		payload.UpdatedAt,
 		payload.ID,
 	)
@@ -140,3 +178,98 @@ func (store *Storage) PutCertApiKey(certId int, apiKey string, updateTimeUnix in
 
 	return nil
 }
+
+// PutCertRotatedKey switches the cert to its rotated (new) key and archives the
+// key it replaced. The new key takes the old key's name (the old key is renamed
+// with an archived suffix). If the cert already uses the new key, nothing is
+// changed.
+func (store *Storage) PutCertRotatedKey(certId int, newKeyId int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	// transaction
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// current key
+	query := `
+	SELECT
+		c.private_key_id, pk.name
+	FROM
+		certificates c
+		LEFT JOIN private_keys pk on (c.private_key_id = pk.id)
+	WHERE
+		c.id = $1
+	`
+
+	var oldKeyId int
+	var oldKeyName string
+	err = tx.QueryRowContext(ctx, query, certId).Scan(&oldKeyId, &oldKeyName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return err
+	}
+	if oldKeyId == newKeyId {
+		return nil
+	}
+
+	updatedAt := time.Now().Unix()
+
+	// switch to the new key
+	query = `
+	UPDATE
+		certificates
+	SET
+		private_key_id = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	_, err = tx.ExecContext(ctx, query, newKeyId, updatedAt, certId)
+	if err != nil {
+		return err
+	}
+
+	// archive the old key (renaming it first, to free its name) and disable its
+	// api key (the new key takes over the same api key)
+	query = `
+	UPDATE
+		private_keys
+	SET
+		name = $1,
+		archived = 1,
+		api_key_disabled = 1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	_, err = tx.ExecContext(ctx, query, fmt.Sprintf("%s_archived_%d", oldKeyName, updatedAt), updatedAt, oldKeyId)
+	if err != nil {
+		return err
+	}
+
+	// new key takes the old key's name
+	query = `
+	UPDATE
+		private_keys
+	SET
+		name = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	_, err = tx.ExecContext(ctx, query, oldKeyName, updatedAt, newKeyId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/domain/private_keys"
+	"testing"
+)
+
+// TestPutDetailsCert confirms each updated detail is saved to its own column
+func TestPutDetailsCert(t *testing.T) {
+	store := openTestStorage(t)
+	certId := postTestCert(t, store)
+
+	name := "updated-name"
+	description := "updated description"
+	org := "updated org"
+	ou := "updated ou"
+	country := "US"
+	state := "updated state"
+	city := "updated city"
+	apiKeyViaUrl := true
+	preferredRootCN := "Updated Root X1"
+
+	err := store.PutDetailsCert(certificates.DetailsUpdatePayload{
+		ID:                 certId,
+		Name:               &name,
+		Description:        &description,
+		SubjectAltNames:    []string{"www.example.com", "mail.example.com"},
+		ChallengeMethodMap: map[string]challenges.MethodValue{"www.example.com": "dns-01-manual"},
+		Organization:       &org,
+		OrganizationalUnit: &ou,
+		Country:            &country,
+		State:              &state,
+		City:               &city,
+		ApiKeyViaUrl:       &apiKeyViaUrl,
+		PreferredRootCN:    &preferredRootCN,
+		RenewalPolicy:      &certificates.RenewalPolicy{Type: certificates.RenewalPolicyDaysRemaining, Value: 20},
+		KeyRotation:        &certificates.KeyRotation{Enabled: true, AlgorithmValue: "rsa2048"},
+		UpdatedAt:          12345,
+	})
+	if err != nil {
+		t.Fatalf("failed to put cert details: %s", err)
+	}
+
+	cert, err := store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+
+	checks := []struct {
+		field string
+		got   any
+		want  any
+	}{
+		{"name", cert.Name, name},
+		{"description", cert.Description, description},
+		{"subject_alts", len(cert.SubjectAltNames), 2},
+		{"challenge_method_map", len(cert.ChallengeMethodMap), 1},
+		{"csr_org", cert.Organization, org},
+		{"csr_ou", cert.OrganizationalUnit, ou},
+		{"csr_country", cert.Country, country},
+		{"csr_state", cert.State, state},
+		{"csr_city", cert.City, city},
+		{"api_key_via_url", cert.ApiKeyViaUrl, apiKeyViaUrl},
+		{"preferred_root_cn", cert.PreferredRootCN, preferredRootCN},
+		{"renewal_policy", cert.RenewalPolicy.Type, certificates.RenewalPolicyDaysRemaining},
+		{"renewal_policy_value", cert.RenewalPolicy.Value, 20},
+		{"key_rotation", cert.KeyRotation.Enabled, true},
+		{"key_rotation_algorithm", cert.KeyRotation.AlgorithmValue, "rsa2048"},
+		{"updated_at", cert.UpdatedAt, 12345},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: got %v, want %v", c.field, c.got, c.want)
+		}
+	}
+
+	// unspecified details must be left alone
+	err = store.PutDetailsCert(certificates.DetailsUpdatePayload{
+		ID:        certId,
+		UpdatedAt: 23456,
+	})
+	if err != nil {
+		t.Fatalf("failed to put empty cert details: %s", err)
+	}
+
+	cert, err = store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+
+	if cert.Name != name || cert.PreferredRootCN != preferredRootCN || !cert.ApiKeyViaUrl {
+		t.Errorf("details changed by an empty update: name %q, preferred_root_cn %q, api_key_via_url %t",
+			cert.Name, cert.PreferredRootCN, cert.ApiKeyViaUrl)
+	}
+	if cert.UpdatedAt != 23456 {
+		t.Errorf("updated_at: got %d, want %d", cert.UpdatedAt, 23456)
+	}
+}
+
+// TestPutCertRotatedKey confirms the cert switches to the new key and the old key
+// is archived with its api key disabled
+func TestPutCertRotatedKey(t *testing.T) {
+	store := openTestStorage(t)
+	certId := postTestCert(t, store)
+
+	cert, err := store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+	oldKey := cert.CertificateKey
+
+	newKeyName := "test-key_next"
+	newKeyDesc := "rotated key"
+	newKeyAlg := "ecdsap256"
+	newKeyPem := "new-test-pem"
+	newKeyApiKeyDisabled := false
+	newKeyId, err := store.PostNewKey(private_keys.NewPayload{
+		Name:           &newKeyName,
+		Description:    &newKeyDesc,
+		AlgorithmValue: &newKeyAlg,
+		PemContent:     &newKeyPem,
+		ApiKey:         oldKey.ApiKey,
+		ApiKeyDisabled: &newKeyApiKeyDisabled,
+	})
+	if err != nil {
+		t.Fatalf("failed to post new key: %s", err)
+	}
+
+	err = store.PutCertRotatedKey(certId, newKeyId)
+	if err != nil {
+		t.Fatalf("failed to rotate cert key: %s", err)
+	}
+
+	cert, err = store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+	if cert.CertificateKey.ID != newKeyId {
+		t.Errorf("cert key: got %d, want %d", cert.CertificateKey.ID, newKeyId)
+	}
+	if cert.CertificateKey.Name != oldKey.Name {
+		t.Errorf("new key name: got %s, want %s", cert.CertificateKey.Name, oldKey.Name)
+	}
+
+	archivedKey, err := store.GetOneKeyById(oldKey.ID)
+	if err != nil {
+		t.Fatalf("failed to get old key: %s", err)
+	}
+	if !archivedKey.Archived {
+		t.Error("old key was not archived")
+	}
+	if !archivedKey.ApiKeyDisabled {
+		t.Error("old key's api key was not disabled")
+	}
+}
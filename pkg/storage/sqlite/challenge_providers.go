@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// challengeProviderDb is a single stored challenge provider config, as database
+// table fields
+// corresponds to challenges.ProviderConfig
+type challengeProviderDb struct {
+	id          int
+	methodValue string
+	config      string
+	createdAt   int
+	updatedAt   int
+}
+
+// toProviderConfig maps the database provider config to the challenges
+// ProviderConfig object
+func (pc challengeProviderDb) toProviderConfig() challenges.ProviderConfig {
+	return challenges.ProviderConfig{
+		ID:          pc.id,
+		MethodValue: challenges.MethodValue(pc.methodValue),
+		Config:      json.RawMessage(pc.config),
+		CreatedAt:   pc.createdAt,
+		UpdatedAt:   pc.updatedAt,
+	}
+}
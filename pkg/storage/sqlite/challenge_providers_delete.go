@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/storage"
+)
+
+// DeleteProviderConfig deletes a challenge provider config from the db
+func (store *Storage) DeleteProviderConfig(id int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		challenge_providers
+	WHERE
+		id = $1
+	`
+
+	result, err := store.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/storage"
+)
+
+// GetAllProviderConfigs returns all of the stored challenge provider configs
+func (store *Storage) GetAllProviderConfigs() (providerConfigs []challenges.ProviderConfig, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, method_value, config, created_at, updated_at
+	FROM
+		challenge_providers
+	ORDER BY
+		id
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneProvider challengeProviderDb
+		err = rows.Scan(
+			&oneProvider.id,
+			&oneProvider.methodValue,
+			&oneProvider.config,
+			&oneProvider.createdAt,
+			&oneProvider.updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		providerConfigs = append(providerConfigs, oneProvider.toProviderConfig())
+	}
+
+	return providerConfigs, nil
+}
+
+// GetOneProviderConfig returns the stored challenge provider config with the
+// specified id
+func (store *Storage) GetOneProviderConfig(id int) (providerConfig challenges.ProviderConfig, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, method_value, config, created_at, updated_at
+	FROM
+		challenge_providers
+	WHERE
+		id = $1
+	`
+
+	row := store.Db.QueryRowContext(ctx, query, id)
+
+	var oneProvider challengeProviderDb
+	err = row.Scan(
+		&oneProvider.id,
+		&oneProvider.methodValue,
+		&oneProvider.config,
+		&oneProvider.createdAt,
+		&oneProvider.updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = storage.ErrNoRecord
+		}
+		return challenges.ProviderConfig{}, err
+	}
+
+	return oneProvider.toProviderConfig(), nil
+}
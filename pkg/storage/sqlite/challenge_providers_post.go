@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// PostNewProviderConfig saves a new challenge provider config to the db
+func (store *Storage) PostNewProviderConfig(providerConfig challenges.ProviderConfig) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO challenge_providers (method_value, config, created_at, updated_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	err = store.Db.QueryRowContext(ctx, query,
+		providerConfig.MethodValue,
+		string(providerConfig.Config),
+		providerConfig.CreatedAt,
+		providerConfig.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/storage"
+)
+
+// PutProviderConfig updates an existing challenge provider config's config
+func (store *Storage) PutProviderConfig(providerConfig challenges.ProviderConfig) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		challenge_providers
+	SET
+		config = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+	`
+
+	result, err := store.Db.ExecContext(ctx, query,
+		string(providerConfig.Config),
+		providerConfig.UpdatedAt,
+		providerConfig.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/challenges"
+)
+
+// challengeResourceDb is a single provisioned challenge resource, as database
+// table fields
+// corresponds to challenges.ChallengeResource
+type challengeResourceDb struct {
+	id              int
+	methodValue     string
+	identifierType  string
+	identifierValue string
+	resourceName    string
+	resourceContent string
+	createdAt       int
+}
+
+// toChallengeResource maps the database challenge resource to the challenges
+// ChallengeResource object
+func (cr challengeResourceDb) toChallengeResource() challenges.ChallengeResource {
+	return challenges.ChallengeResource{
+		ID:              cr.id,
+		MethodValue:     challenges.MethodValue(cr.methodValue),
+		IdentifierType:  cr.identifierType,
+		IdentifierValue: cr.identifierValue,
+		ResourceName:    cr.resourceName,
+		ResourceContent: cr.resourceContent,
+		CreatedAt:       cr.createdAt,
+	}
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/storage"
+)
+
+// DeleteChallengeResource deletes a deprovisioned challenge resource from the db.
+// The resource is matched by its method value, name, and content.
+func (store *Storage) DeleteChallengeResource(resource challenges.ChallengeResource) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		challenge_resources
+	WHERE
+		method_value = $1
+		AND
+		resource_name = $2
+		AND
+		resource_content = $3
+	`
+
+	result, err := store.Db.ExecContext(ctx, query,
+		resource.MethodValue,
+		resource.ResourceName,
+		resource.ResourceContent,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
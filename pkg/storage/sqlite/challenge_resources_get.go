@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// GetAllChallengeResources returns all of the provisioned challenge resources
+func (store *Storage) GetAllChallengeResources() (resources []challenges.ChallengeResource, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, method_value, identifier_type, identifier_value, resource_name, resource_content, created_at
+	FROM
+		challenge_resources
+	ORDER BY
+		id
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneResource challengeResourceDb
+		err = rows.Scan(
+			&oneResource.id,
+			&oneResource.methodValue,
+			&oneResource.identifierType,
+			&oneResource.identifierValue,
+			&oneResource.resourceName,
+			&oneResource.resourceContent,
+			&oneResource.createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, oneResource.toChallengeResource())
+	}
+
+	return resources, nil
+}
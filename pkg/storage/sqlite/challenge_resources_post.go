@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// PostNewChallengeResource saves a newly provisioned challenge resource to the db
+func (store *Storage) PostNewChallengeResource(resource challenges.ChallengeResource) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO challenge_resources (method_value, identifier_type, identifier_value, resource_name, resource_content, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	err = store.Db.QueryRowContext(ctx, query,
+		resource.MethodValue,
+		resource.IdentifierType,
+		resource.IdentifierValue,
+		resource.ResourceName,
+		resource.ResourceContent,
+		resource.CreatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
@@ -17,6 +17,7 @@ type keyDb struct {
 	apiKeyNew      string
 	apiKeyDisabled bool
 	apiKeyViaUrl   bool
+	archived       bool
 	createdAt      int
 	updatedAt      int
 }
@@ -34,6 +35,7 @@ func (key keyDb) toKey() private_keys.Key {
 		ApiKeyNew:      key.apiKeyNew,
 		ApiKeyDisabled: key.apiKeyDisabled,
 		ApiKeyViaUrl:   key.apiKeyViaUrl,
+		Archived:       key.archived,
 		CreatedAt:      key.createdAt,
 		UpdatedAt:      key.updatedAt,
 	}
@@ -57,6 +57,21 @@ func (store *Storage) KeyInUse(id int) (inUse bool, err error) {
 		return true, nil
 	}
 
+	// check not reserved as the new key of an incomplete order (cert key rotation)
+	// if scan in succeeds, record exists in acme_orders
+	query = `
+	SELECT id
+	FROM acme_orders
+	WHERE new_key_id = $1 AND status IN ("pending", "ready", "processing")
+	`
+
+	row = store.Db.QueryRowContext(ctx, query, id)
+	temp = -2
+	row.Scan(&temp)
+	if temp != -2 {
+		return true, nil
+	}
+
 	// check not in use by valid order on an existing cert_id with longest dated expiration
 	// query groups valid orders by cert_id and then returns a result if the
 	// order has the max valid_to for a particular key (the one being deleted)
@@ -120,3 +135,58 @@ func (store *Storage) DeleteKey(id int) error {
 
 	return nil
 }
+
+// DeleteOrderNewKey removes the specified new key (cert key rotation) from the
+// order and deletes the key. The key is only deleted if nothing else uses it (e.g.
+// if the cert already switched to it, it is kept).
+func (store *Storage) DeleteOrderNewKey(orderId int, keyId int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	// transaction
+	tx, err := store.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// remove from the order
+	query := `
+	UPDATE
+		acme_orders
+	SET
+		new_key_id = NULL
+	WHERE
+		id = $1
+		AND
+		new_key_id = $2
+	`
+
+	_, err = tx.ExecContext(ctx, query, orderId, keyId)
+	if err != nil {
+		return err
+	}
+
+	// delete the key, if unused
+	query = `
+	DELETE FROM
+		private_keys
+	WHERE
+		id = $1
+		AND
+		NOT EXISTS (SELECT id FROM acme_accounts WHERE private_key_id = $1)
+		AND
+		NOT EXISTS (SELECT id FROM certificates WHERE private_key_id = $1)
+		AND
+		NOT EXISTS (SELECT id FROM acme_orders WHERE new_key_id = $1)
+		AND
+		NOT EXISTS (SELECT id FROM acme_orders WHERE finalized_key_id = $1 AND status = "valid")
+	`
+
+	_, err = tx.ExecContext(ctx, query, keyId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
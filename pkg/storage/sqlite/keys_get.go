@@ -35,7 +35,7 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 	query := fmt.Sprintf(`
 	SELECT
 		id, name, description, algorithm, pem, api_key, api_key_new, api_key_disabled,
-		api_key_via_url, created_at, updated_at,
+		api_key_via_url, archived, created_at, updated_at,
 
 		count(*) OVER() AS full_count
 	FROM
@@ -73,6 +73,7 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
 			&oneKeyDb.apiKeyViaUrl,
+			&oneKeyDb.archived,
 			&oneKeyDb.createdAt,
 			&oneKeyDb.updatedAt,
 
@@ -108,7 +109,7 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 	query := `
 	SELECT
 		id, name, description, algorithm, pem, api_key, api_key_new, api_key_disabled,
-		api_key_via_url, created_at, updated_at
+		api_key_via_url, archived, created_at, updated_at
 	FROM
 		private_keys
 	WHERE
@@ -130,6 +131,7 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 		&oneKeyDb.apiKeyNew,
 		&oneKeyDb.apiKeyDisabled,
 		&oneKeyDb.apiKeyViaUrl,
+		&oneKeyDb.archived,
 		&oneKeyDb.createdAt,
 		&oneKeyDb.updatedAt,
 	)
@@ -160,10 +162,12 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 	query := `
 		SELECT
 			pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
-			pk.api_key_disabled, pk.api_key_via_url, pk.created_at, pk.updated_at
+			pk.api_key_disabled, pk.api_key_via_url, pk.archived, pk.created_at, pk.updated_at
 		FROM
 		  private_keys pk
 		WHERE
+			pk.archived = 0
+			AND
 			NOT EXISTS(
 				SELECT
 					aa.private_key_id
@@ -181,6 +185,17 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 				WHERE
 					pk.id = c.private_key_id
 			)
+			AND
+			NOT EXISTS(
+				SELECT
+					ao.new_key_id
+				FROM
+					acme_orders ao
+				WHERE
+					pk.id = ao.new_key_id
+					AND
+					ao.status IN ("pending", "ready", "processing")
+			)
 		ORDER BY name
 	`
 
@@ -204,6 +219,7 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
 			&oneKeyDb.apiKeyViaUrl,
+			&oneKeyDb.archived,
 			&oneKeyDb.createdAt,
 			&oneKeyDb.updatedAt,
 		)
@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrations are schema changes made after the original tables were created by
+// createDBTables. The db's user_version tracks how many of these have been applied,
+// so migrations must only ever be appended to (never edited or reordered).
+var migrations = []string{
+	// 1: certificate preferred alternate chain
+	`ALTER TABLE certificates ADD COLUMN preferred_root_cn text NOT NULL DEFAULT ''`,
+	// 2: order ip identifiers (RFC 8738)
+	`ALTER TABLE acme_orders ADD COLUMN ip_identifiers text NOT NULL DEFAULT ''`,
+	// 3: certificate per identifier challenge methods (stored as json object)
+	`ALTER TABLE certificates ADD COLUMN challenge_method_map text NOT NULL DEFAULT ''`,
+	// 4: runtime configured challenge providers (config stored as json object)
+	`CREATE TABLE IF NOT EXISTS challenge_providers (
+		id integer PRIMARY KEY,
+		method_value text NOT NULL UNIQUE,
+		config text NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`,
+	// 5: provisioned challenge resources (to sweep any left behind after a crash)
+	`CREATE TABLE IF NOT EXISTS challenge_resources (
+		id integer PRIMARY KEY,
+		method_value text NOT NULL,
+		identifier_value text NOT NULL,
+		resource_name text NOT NULL,
+		resource_content text NOT NULL,
+		created_at integer NOT NULL
+	)`,
+
+	// 6: automatic acme-dns registrations (credentials are encrypted)
+	`CREATE TABLE IF NOT EXISTS acme_dns_registrations (
+		id integer PRIMARY KEY,
+		acme_dns_address text NOT NULL,
+		real_domain text NOT NULL COLLATE NOCASE,
+		full_domain text NOT NULL,
+		username text NOT NULL,
+		password text NOT NULL,
+		created_at integer NOT NULL,
+		UNIQUE(acme_dns_address, real_domain)
+	)`,
+
+	// 7: external challenge provider executions (e.g. dns scripts) per order
+	`CREATE TABLE IF NOT EXISTS provider_exec_logs (
+		id integer PRIMARY KEY,
+		order_id integer NOT NULL,
+		method_value text NOT NULL,
+		identifier_value text NOT NULL,
+		action text NOT NULL,
+		command text NOT NULL,
+		exit_code integer NOT NULL,
+		timed_out boolean NOT NULL,
+		stdout text NOT NULL,
+		stderr text NOT NULL,
+		error text NOT NULL,
+		started_at integer NOT NULL,
+		duration_ms integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	);
+	CREATE INDEX IF NOT EXISTS provider_exec_logs_order_id ON provider_exec_logs (order_id)`,
+
+	// 8: persistent order job queue (incomplete orders are queued to resume)
+	`CREATE TABLE IF NOT EXISTS order_jobs (
+		id integer PRIMARY KEY,
+		order_id integer NOT NULL UNIQUE,
+		high_priority boolean NOT NULL,
+		attempts integer NOT NULL,
+		next_attempt_at integer NOT NULL,
+		claimed_at integer NOT NULL,
+		last_error text NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	);
+	CREATE INDEX IF NOT EXISTS order_jobs_next_attempt_at ON order_jobs (next_attempt_at);
+	INSERT INTO order_jobs (order_id, high_priority, attempts, next_attempt_at, claimed_at, last_error, created_at, updated_at)
+		SELECT id, false, 0, CAST(strftime('%s', 'now') AS integer), 0, '', CAST(strftime('%s', 'now') AS integer),
+			CAST(strftime('%s', 'now') AS integer)
+		FROM acme_orders
+		WHERE status IN ('pending', 'ready', 'processing')`,
+
+	// 9: order processing event timeline
+	`CREATE TABLE IF NOT EXISTS order_events (
+		id integer PRIMARY KEY,
+		order_id integer NOT NULL,
+		type text NOT NULL,
+		identifier text NOT NULL,
+		detail text NOT NULL,
+		error text NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	);
+	CREATE INDEX IF NOT EXISTS order_events_order_id ON order_events (order_id)`,
+
+	// 10: certificate renewal policy
+	`ALTER TABLE certificates ADD COLUMN renewal_policy text NOT NULL DEFAULT 'default';
+	ALTER TABLE certificates ADD COLUMN renewal_policy_value integer NOT NULL DEFAULT 0`,
+	// 11: certificate private key rotation (new key per order, old keys archived)
+	`ALTER TABLE certificates ADD COLUMN key_rotation integer NOT NULL DEFAULT 0 CHECK(key_rotation IN (0,1));
+	ALTER TABLE certificates ADD COLUMN key_rotation_algorithm text NOT NULL DEFAULT '';
+	ALTER TABLE acme_orders ADD COLUMN new_key_id integer REFERENCES private_keys (id) ON DELETE SET NULL;
+	ALTER TABLE private_keys ADD COLUMN archived integer NOT NULL DEFAULT 0 CHECK(archived IN (0,1))`,
+	// 12: challenge resource identifier type (blank for resources saved before this
+	// column existed, the type is then determined from the value)
+	`ALTER TABLE challenge_resources ADD COLUMN identifier_type text NOT NULL DEFAULT ''`,
+}
+
+// migrateDB applies any migrations that have not yet been applied to the db
+func (store *Storage) migrateDB() error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	// current schema version
+	var userVersion int
+	err := store.Db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&userVersion)
+	if err != nil {
+		return err
+	}
+
+	// apply each outstanding migration in its own transaction
+	for i := userVersion; i < len(migrations); i++ {
+		tx, err := store.Db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, migrations[i])
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("db migration %d failed (%s)", i+1, err)
+		}
+
+		// PRAGMA does not support placeholders, but the value is an int
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, i+1))
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/order_events"
+)
+
+// orderEventDb is a single order event, as database table fields
+// corresponds to order_events.Event
+type orderEventDb struct {
+	id         int
+	orderId    int
+	eventType  string
+	identifier string
+	detail     string
+	error      string
+	createdAt  int
+}
+
+// toOrderEvent maps the database order event to the order_events Event object
+func (oe orderEventDb) toOrderEvent() order_events.Event {
+	return order_events.Event{
+		ID:         oe.id,
+		OrderID:    oe.orderId,
+		Type:       order_events.Type(oe.eventType),
+		Identifier: oe.identifier,
+		Detail:     oe.detail,
+		Error:      oe.error,
+		CreatedAt:  oe.createdAt,
+	}
+}
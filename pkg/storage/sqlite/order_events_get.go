@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/order_events"
+)
+
+// GetOrderEventsByOrder returns all of the events for the specified order, in
+// the order they occurred
+func (store *Storage) GetOrderEventsByOrder(orderId int) (events []order_events.Event, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, order_id, type, identifier, detail, error, created_at
+	FROM
+		order_events
+	WHERE
+		order_id = $1
+	ORDER BY
+		id
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneEvent orderEventDb
+		err = rows.Scan(
+			&oneEvent.id,
+			&oneEvent.orderId,
+			&oneEvent.eventType,
+			&oneEvent.identifier,
+			&oneEvent.detail,
+			&oneEvent.error,
+			&oneEvent.createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, oneEvent.toOrderEvent())
+	}
+
+	return events, nil
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/order_events"
+)
+
+// PostNewOrderEvent saves an order event to the db
+func (store *Storage) PostNewOrderEvent(event order_events.Event) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO order_events (order_id, type, identifier, detail, error, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	err = store.Db.QueryRowContext(ctx, query,
+		event.OrderID,
+		event.Type,
+		event.Identifier,
+		event.Detail,
+		event.Error,
+		event.CreatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
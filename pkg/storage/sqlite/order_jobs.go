@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/domain/orders"
+)
+
+// orderJobDb is a single queued order job, as database table fields
+// corresponds to orders.OrderJob
+type orderJobDb struct {
+	id            int
+	orderId       int
+	highPriority  bool
+	attempts      int
+	nextAttemptAt int
+	claimedAt     int
+	lastError     string
+	createdAt     int
+	updatedAt     int
+}
+
+// toOrderJob maps the database order job to the orders OrderJob object
+func (job orderJobDb) toOrderJob() orders.OrderJob {
+	return orders.OrderJob{
+		ID:            job.id,
+		OrderID:       job.orderId,
+		HighPriority:  job.highPriority,
+		Attempts:      job.attempts,
+		NextAttemptAt: job.nextAttemptAt,
+		ClaimedAt:     job.claimedAt,
+		LastError:     job.lastError,
+		CreatedAt:     job.createdAt,
+		UpdatedAt:     job.updatedAt,
+	}
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"legocerthub-backend/pkg/storage"
+)
+
+// DeleteOrderJob removes a job from the order job queue
+func (store *Storage) DeleteOrderJob(jobId int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		order_jobs
+	WHERE
+		id = $1
+	`
+
+	result, err := store.Db.ExecContext(ctx, query, jobId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteUnclaimedOrderJob removes a job from the order job queue and returns the
+// job's order id, unless the job has been claimed by a worker (in which case
+// storage.ErrInUse is returned)
+func (store *Storage) DeleteUnclaimedOrderJob(jobId int) (orderId int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		order_jobs
+	WHERE
+		id = $1
+		AND
+		claimed_at = 0
+	RETURNING
+		order_id
+	`
+
+	err = store.Db.QueryRowContext(ctx, query, jobId).Scan(&orderId)
+	if err == nil {
+		return orderId, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return -2, err
+	}
+
+	// nothing deleted, check if the job exists (and is claimed)
+	query = `
+	SELECT
+		EXISTS (
+			SELECT
+				id
+			FROM
+				order_jobs
+			WHERE
+				id = $1
+		)
+	`
+
+	var exists bool
+	err = store.Db.QueryRowContext(ctx, query, jobId).Scan(&exists)
+	if err != nil {
+		return -2, err
+	}
+	if exists {
+		return -2, storage.ErrInUse
+	}
+
+	return -2, storage.ErrNoRecord
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"legocerthub-backend/pkg/domain/orders"
+	"legocerthub-backend/pkg/order_events"
+)
+
+// GetAllOrderJobs returns all of the jobs in the order job queue, each with the
+// most recent event of its order (its current step). Claimed jobs are first,
+// followed by the unclaimed jobs in the order they will be claimed.
+func (store *Storage) GetAllOrderJobs() (jobs []orders.OrderJob, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		oj.id, oj.order_id, oj.high_priority, oj.attempts, oj.next_attempt_at, oj.claimed_at,
+		oj.last_error, oj.created_at, oj.updated_at,
+		oe.id, oe.type, oe.identifier, oe.detail, oe.error, oe.created_at
+	FROM
+		order_jobs oj
+		LEFT JOIN order_events oe on (oe.id = (
+			SELECT
+				MAX(id)
+			FROM
+				order_events
+			WHERE
+				order_id = oj.order_id
+		))
+	ORDER BY
+		oj.claimed_at = 0 ASC,
+		oj.high_priority DESC,
+		oj.next_attempt_at ASC,
+		oj.id ASC
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneJob orderJobDb
+		var eventId sql.NullInt32
+		var eventType, eventIdentifier, eventDetail, eventError sql.NullString
+		var eventCreatedAt sql.NullInt32
+		err = rows.Scan(
+			&oneJob.id,
+			&oneJob.orderId,
+			&oneJob.highPriority,
+			&oneJob.attempts,
+			&oneJob.nextAttemptAt,
+			&oneJob.claimedAt,
+			&oneJob.lastError,
+			&oneJob.createdAt,
+			&oneJob.updatedAt,
+			&eventId,
+			&eventType,
+			&eventIdentifier,
+			&eventDetail,
+			&eventError,
+			&eventCreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		job := oneJob.toOrderJob()
+
+		// current step (if the order has any events)
+		if eventId.Valid {
+			job.CurrentStep = &order_events.Event{
+				ID:         int(eventId.Int32),
+				OrderID:    oneJob.orderId,
+				Type:       order_events.Type(eventType.String),
+				Identifier: eventIdentifier.String,
+				Detail:     eventDetail.String,
+				Error:      eventError.String,
+				CreatedAt:  int(eventCreatedAt.Int32),
+			}
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
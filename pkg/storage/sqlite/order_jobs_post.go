@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/storage"
+	"time"
+)
+
+// PostOrderJob queues a job for the order that is due at nextAttemptAt. If the
+// order already has a queued job, the job keeps its attempts and when it is due
+// (so re-queuing doesn't skip its backoff), unless highPriority, in which case it
+// is raised to high priority and updated to be due at nextAttemptAt (with its
+// attempts reset). If the order's job is currently claimed by a worker,
+// storage.ErrInUse is returned.
+func (store *Storage) PostOrderJob(orderId int, highPriority bool, nextAttemptAt int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO order_jobs (order_id, high_priority, attempts, next_attempt_at, claimed_at, last_error,
+		created_at, updated_at)
+	VALUES ($1, $2, 0, $3, 0, '', $4, $4)
+	ON CONFLICT (order_id) DO UPDATE
+	SET
+		high_priority = MAX(high_priority, excluded.high_priority),
+		attempts = case when excluded.high_priority then 0 else attempts end,
+		next_attempt_at = case when excluded.high_priority then excluded.next_attempt_at else next_attempt_at end,
+		updated_at = excluded.updated_at
+	WHERE
+		claimed_at = 0
+	`
+
+	result, err := store.Db.ExecContext(ctx, query,
+		orderId,
+		highPriority,
+		nextAttemptAt,
+		int(time.Now().Unix()),
+	)
+	if err != nil {
+		return err
+	}
+
+	// no rows means the conflicting job was claimed (so it wasn't updated)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrInUse
+	}
+
+	return nil
+}
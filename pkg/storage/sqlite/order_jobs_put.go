@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"legocerthub-backend/pkg/domain/orders"
+	"legocerthub-backend/pkg/storage"
+	"time"
+)
+
+// ClaimNextOrderJob claims the next job that is due (as of now) and returns it.
+// High priority jobs are claimed first, then the jobs that have been due the
+// longest. If no job is due, storage.ErrNoRecord is returned.
+func (store *Storage) ClaimNextOrderJob(now int) (job orders.OrderJob, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	// single statement, so two workers can't claim the same job
+	query := `
+	UPDATE
+		order_jobs
+	SET
+		claimed_at = $1,
+		updated_at = $1
+	WHERE
+		claimed_at = 0
+		AND
+		id = (
+			SELECT
+				id
+			FROM
+				order_jobs
+			WHERE
+				claimed_at = 0
+				AND
+				next_attempt_at <= $1
+			ORDER BY
+				high_priority DESC,
+				next_attempt_at ASC,
+				id ASC
+			LIMIT 1
+		)
+	RETURNING
+		id, order_id, high_priority, attempts, next_attempt_at, claimed_at, last_error, created_at,
+		updated_at
+	`
+
+	var oneJob orderJobDb
+	err = store.Db.QueryRowContext(ctx, query, now).Scan(
+		&oneJob.id,
+		&oneJob.orderId,
+		&oneJob.highPriority,
+		&oneJob.attempts,
+		&oneJob.nextAttemptAt,
+		&oneJob.claimedAt,
+		&oneJob.lastError,
+		&oneJob.createdAt,
+		&oneJob.updatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return orders.OrderJob{}, err
+	}
+
+	return oneJob.toOrderJob(), nil
+}
+
+// PutOrderJobRetry releases a claimed job so it is attempted again at
+// nextAttemptAt, recording the number of attempts made and the last error
+func (store *Storage) PutOrderJobRetry(jobId int, attempts int, nextAttemptAt int, lastError string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		order_jobs
+	SET
+		attempts = $1,
+		next_attempt_at = $2,
+		last_error = $3,
+		claimed_at = 0,
+		updated_at = $4
+	WHERE
+		id = $5
+	`
+
+	result, err := store.Db.ExecContext(ctx, query,
+		attempts,
+		nextAttemptAt,
+		lastError,
+		int(time.Now().Unix()),
+		jobId,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
+
+// ResetOrderJobClaims releases all claimed jobs. Claims are only held while a
+// worker is running the job, so any that exist at startup were interrupted
+// (e.g. by a crash) and should be attempted again.
+func (store *Storage) ResetOrderJobClaims() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		order_jobs
+	SET
+		claimed_at = 0,
+		updated_at = $1
+	WHERE
+		claimed_at != 0
+	`
+
+	_, err = store.Db.ExecContext(ctx, query, int(time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
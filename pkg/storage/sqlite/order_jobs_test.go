@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"errors"
+	"legocerthub-backend/pkg/domain/orders"
+	"legocerthub-backend/pkg/storage"
+	"testing"
+)
+
+// getTestOrderJob returns the queued job of the order
+func getTestOrderJob(t *testing.T, store *Storage, orderId int) orders.OrderJob {
+	t.Helper()
+
+	jobs, err := store.GetAllOrderJobs()
+	if err != nil {
+		t.Fatalf("failed to get order jobs: %s", err)
+	}
+
+	for _, job := range jobs {
+		if job.OrderID == orderId {
+			return job
+		}
+	}
+
+	t.Fatalf("order %d has no queued job", orderId)
+	return orders.OrderJob{}
+}
+
+// TestClaimNextOrderJob confirms due jobs are claimed high priority first, then
+// by when they were due, and that claimed and not yet due jobs aren't claimed
+func TestClaimNextOrderJob(t *testing.T) {
+	store := openTestStorage(t)
+	certId := postTestCert(t, store)
+
+	jobs := []struct {
+		name          string
+		highPriority  bool
+		nextAttemptAt int
+	}{
+		{"low due last", false, 100},
+		{"low due first", false, 50},
+		{"high due last", true, 200},
+		{"high due first", true, 150},
+		{"low not due", false, 1000},
+	}
+
+	orderNames := make(map[int]string)
+	for _, job := range jobs {
+		orderId := postTestOrder(t, store, certId)
+		orderNames[orderId] = job.name
+
+		err := store.PostOrderJob(orderId, job.highPriority, job.nextAttemptAt)
+		if err != nil {
+			t.Fatalf("failed to post order job: %s", err)
+		}
+	}
+
+	wantOrder := []string{"high due first", "high due last", "low due first", "low due last"}
+	for _, want := range wantOrder {
+		job, err := store.ClaimNextOrderJob(300)
+		if err != nil {
+			t.Fatalf("failed to claim %s: %s", want, err)
+		}
+		if orderNames[job.OrderID] != want {
+			t.Errorf("claimed %s, want %s", orderNames[job.OrderID], want)
+		}
+		if job.ClaimedAt != 300 {
+			t.Errorf("%s claimed at: got %d, want 300", want, job.ClaimedAt)
+		}
+	}
+
+	// everything due is claimed, claimed jobs must not be claimed again
+	_, err := store.ClaimNextOrderJob(300)
+	if !errors.Is(err, storage.ErrNoRecord) {
+		t.Errorf("claim with none due: got %v, want %v", err, storage.ErrNoRecord)
+	}
+
+	// once due, the last job can be claimed
+	job, err := store.ClaimNextOrderJob(1000)
+	if err != nil {
+		t.Fatalf("failed to claim low not due: %s", err)
+	}
+	if orderNames[job.OrderID] != "low not due" {
+		t.Errorf("claimed %s, want low not due", orderNames[job.OrderID])
+	}
+
+	// released claims can be claimed again
+	err = store.ResetOrderJobClaims()
+	if err != nil {
+		t.Fatalf("failed to reset claims: %s", err)
+	}
+	job, err = store.ClaimNextOrderJob(1000)
+	if err != nil {
+		t.Fatalf("failed to claim after reset: %s", err)
+	}
+	if orderNames[job.OrderID] != "high due first" {
+		t.Errorf("claimed %s after reset, want high due first", orderNames[job.OrderID])
+	}
+}
+
+// TestPostOrderJobRequeue confirms re-queuing an order keeps its job's backoff
+// unless it is re-queued at high priority, and that a claimed job can't be
+// re-queued or deleted
+func TestPostOrderJobRequeue(t *testing.T) {
+	store := openTestStorage(t)
+	certId := postTestCert(t, store)
+	orderId := postTestOrder(t, store, certId)
+
+	err := store.PostOrderJob(orderId, false, 100)
+	if err != nil {
+		t.Fatalf("failed to post order job: %s", err)
+	}
+
+	// fail an attempt so the job is backing off
+	job, err := store.ClaimNextOrderJob(100)
+	if err != nil {
+		t.Fatalf("failed to claim job: %s", err)
+	}
+	err = store.PutOrderJobRetry(job.ID, 3, 500, "attempt failed")
+	if err != nil {
+		t.Fatalf("failed to retry job: %s", err)
+	}
+
+	checkJob := func(step string, highPriority bool, attempts int, nextAttemptAt int) {
+		t.Helper()
+		job := getTestOrderJob(t, store, orderId)
+		if job.HighPriority != highPriority || job.Attempts != attempts || job.NextAttemptAt != nextAttemptAt {
+			t.Errorf("%s: got high priority %t, attempts %d, next attempt %d, want %t, %d, %d", step,
+				job.HighPriority, job.Attempts, job.NextAttemptAt, highPriority, attempts, nextAttemptAt)
+		}
+	}
+
+	// low priority keeps the backoff
+	err = store.PostOrderJob(orderId, false, 200)
+	if err != nil {
+		t.Fatalf("failed to re-queue at low priority: %s", err)
+	}
+	checkJob("low priority re-queue", false, 3, 500)
+
+	// high priority resets the backoff
+	err = store.PostOrderJob(orderId, true, 200)
+	if err != nil {
+		t.Fatalf("failed to re-queue at high priority: %s", err)
+	}
+	checkJob("high priority re-queue", true, 0, 200)
+
+	// low priority doesn't lower the priority
+	err = store.PostOrderJob(orderId, false, 300)
+	if err != nil {
+		t.Fatalf("failed to re-queue at low priority: %s", err)
+	}
+	checkJob("low priority re-queue of high priority", true, 0, 200)
+
+	// claimed job is in use
+	job, err = store.ClaimNextOrderJob(200)
+	if err != nil {
+		t.Fatalf("failed to claim job: %s", err)
+	}
+	err = store.PostOrderJob(orderId, true, 200)
+	if !errors.Is(err, storage.ErrInUse) {
+		t.Errorf("re-queue of claimed job: got %v, want %v", err, storage.ErrInUse)
+	}
+	_, err = store.DeleteUnclaimedOrderJob(job.ID)
+	if !errors.Is(err, storage.ErrInUse) {
+		t.Errorf("delete of claimed job: got %v, want %v", err, storage.ErrInUse)
+	}
+
+	// once released, it can be deleted
+	err = store.PutOrderJobRetry(job.ID, 1, 400, "attempt failed")
+	if err != nil {
+		t.Fatalf("failed to retry job: %s", err)
+	}
+	deletedOrderId, err := store.DeleteUnclaimedOrderJob(job.ID)
+	if err != nil {
+		t.Fatalf("failed to delete unclaimed job: %s", err)
+	}
+	if deletedOrderId != orderId {
+		t.Errorf("deleted job's order: got %d, want %d", deletedOrderId, orderId)
+	}
+	_, err = store.DeleteUnclaimedOrderJob(job.ID)
+	if !errors.Is(err, storage.ErrNoRecord) {
+		t.Errorf("delete of missing job: got %v, want %v", err, storage.ErrNoRecord)
+	}
+}
@@ -18,9 +18,11 @@ type orderDb struct {
 	err            sql.NullString // stored as json object
 	expires        sql.NullInt32
 	dnsIdentifiers commaJoinedStrings // will be a comma separated list from storage
+	ipIdentifiers  commaJoinedStrings // will be a comma separated list from storage
 	authorizations commaJoinedStrings // will be a comma separated list from storage
 	finalize       string
 	finalizedKey   keyDb
+	newKeyId       sql.NullInt32
 	certificateUrl sql.NullString
 	pem            sql.NullString
 	validFrom      sql.NullInt32
@@ -52,9 +54,11 @@ func (order orderDb) toOrder(store *Storage) orders.Order {
 		Error:          acmeErr,
 		Expires:        nullInt32ToInt(order.expires),
 		DnsIdentifiers: order.dnsIdentifiers.toSlice(),
+		IpIdentifiers:  order.ipIdentifiers.toSlice(),
 		Authorizations: order.authorizations.toSlice(),
 		Finalize:       order.finalize,
 		FinalizedKey:   key,
+		NewKeyID:       nullInt32ToInt(order.newKeyId),
 		CertificateUrl: nullStringToString(order.certificateUrl),
 		Pem:            nullStringToString(order.pem),
 		ValidFrom:      nullInt32ToInt(order.validFrom),
@@ -38,13 +38,15 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 	SELECT
 		/* order */
 		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
-		ao.authorizations, ao.finalize, ao.certificate_url, ao.valid_from, ao.valid_to, ao.created_at,
-		ao.updated_at, 
+		ao.ip_identifiers, ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.created_at,
+		ao.updated_at, ao.new_key_id,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts, c.challenge_method, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url,
+		c.api_key, c.api_key_new, c.api_key_via_url, c.preferred_root_cn,
+		c.challenge_method_map, c.renewal_policy, c.renewal_policy_value,
+		c.key_rotation, c.key_rotation_algorithm,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new,
@@ -120,13 +122,16 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
+			&oneOrder.pem,
 			&oneOrder.validFrom,
 			&oneOrder.validTo,
 			&oneOrder.createdAt,
 			&oneOrder.updatedAt,
+			&oneOrder.newKeyId,
 
 			&oneOrder.certificate.id,
 			&oneOrder.certificate.name,
@@ -144,6 +149,12 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.certificate.apiKey,
 			&oneOrder.certificate.apiKeyNew,
 			&oneOrder.certificate.apiKeyViaUrl,
+			&oneOrder.certificate.preferredRootCN,
+			&oneOrder.certificate.challengeMethodMap,
+			&oneOrder.certificate.renewalPolicy,
+			&oneOrder.certificate.renewalPolicyValue,
+			&oneOrder.certificate.keyRotation,
+			&oneOrder.certificate.keyRotationAlgorithm,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
@@ -237,13 +248,15 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 	SELECT
 		/* order */
 		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
-		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.created_at,
-		ao.updated_at, 
+		ao.ip_identifiers, ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.created_at,
+		ao.updated_at, ao.new_key_id,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts, c.challenge_method, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url,
+		c.api_key, c.api_key_new, c.api_key_via_url, c.preferred_root_cn,
+		c.challenge_method_map, c.renewal_policy, c.renewal_policy_value,
+		c.key_rotation, c.key_rotation_algorithm,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new, ck.api_key_disabled,
@@ -305,6 +318,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -313,6 +327,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.validTo,
 			&oneOrder.createdAt,
 			&oneOrder.updatedAt,
+			&oneOrder.newKeyId,
 
 			&oneOrder.certificate.id,
 			&oneOrder.certificate.name,
@@ -330,6 +345,12 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.certificate.apiKey,
 			&oneOrder.certificate.apiKeyNew,
 			&oneOrder.certificate.apiKeyViaUrl,
+			&oneOrder.certificate.preferredRootCN,
+			&oneOrder.certificate.challengeMethodMap,
+			&oneOrder.certificate.renewalPolicy,
+			&oneOrder.certificate.renewalPolicyValue,
+			&oneOrder.certificate.keyRotation,
+			&oneOrder.certificate.keyRotationAlgorithm,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
@@ -432,8 +453,10 @@ func (store *Storage) GetAllIncompleteOrderIds() (orderIds []int, err error) {
 	return orderIds, nil
 }
 
-// GetExpiringCertIds returns a slice of certificate ids for certificates that are valid for less
-// than the specified maxTimeRemaining. If a cert does not have a valid order, it is excluded.
+// GetExpiringCertIds returns a slice of certificate ids for certificates that should be renewed
+// according to their renewal policy. Certificates using the default policy are included if they
+// are valid for less than the specified maxTimeRemaining. Certificates that are never renewed, or
+// that do not have a valid order, are excluded.
 func (store *Storage) GetExpiringCertIds(maxTimeRemaining time.Duration) (certIds []int, err error) {
 	// query
 	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
@@ -444,6 +467,7 @@ func (store *Storage) GetExpiringCertIds(maxTimeRemaining time.Duration) (certId
 			ao.certificate_id
 		FROM
 			acme_orders ao
+			LEFT JOIN certificates c on (ao.certificate_id = c.id)
 		WHERE 
 			ao.status = "valid"
 			AND
@@ -459,7 +483,14 @@ func (store *Storage) GetExpiringCertIds(maxTimeRemaining time.Duration) (certId
 		HAVING
 			MAX(ao.valid_to)
 			AND
-			ao.valid_to < $2
+			(
+				(c.renewal_policy = 'default' AND ao.valid_to < $2)
+				OR
+				(c.renewal_policy = 'days_remaining' AND ao.valid_to < $1 + c.renewal_policy_value * 86400)
+				OR
+				(c.renewal_policy = 'percent_remaining' AND
+					(ao.valid_to - $1) * 100 < c.renewal_policy_value * (ao.valid_to - ao.valid_from))
+			)
 		`
 
 	// calculate the max expiration (unix) for the query
@@ -533,6 +564,41 @@ func (store *Storage) GetNewestIncompleteCertOrderId(certId int) (orderId int, e
 	return orderId, nil
 }
 
+// GetNewestValidCertOrderPem returns the pem of the most recent valid (and not known
+// revoked) order for the specified certId, assuming there is one.
+func (store *Storage) GetNewestValidCertOrderPem(certId int) (orderPem string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		pem
+	FROM
+		acme_orders
+	WHERE
+		certificate_id = $1
+		AND
+		status = "valid"
+		AND
+		known_revoked = 0
+		AND
+		pem NOT NULL
+	ORDER BY
+		valid_to DESC
+	LIMIT
+		1
+	`
+
+	row := store.Db.QueryRowContext(ctx, query, certId)
+
+	err = row.Scan(&orderPem)
+	if err != nil {
+		return "", err
+	}
+
+	return orderPem, nil
+}
+
 // GetOneOrder fetches a specific Order by ID
 func (store *Storage) GetOneOrder(orderId int) (order orders.Order, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
@@ -542,13 +608,15 @@ func (store *Storage) GetOneOrder(orderId int) (order orders.Order, err error) {
 	SELECT
 		/* order */
 		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
-		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.created_at,
-		ao.updated_at, 
+		ao.ip_identifiers, ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.created_at,
+		ao.updated_at, ao.new_key_id,
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts, c.challenge_method, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url,
+		c.api_key, c.api_key_new, c.api_key_via_url, c.preferred_root_cn,
+		c.challenge_method_map, c.renewal_policy, c.renewal_policy_value,
+		c.key_rotation, c.key_rotation_algorithm,
 		
 		/* cert's key */
 		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ak.api_key_new, ck.api_key_disabled,
@@ -592,6 +660,7 @@ func (store *Storage) GetOneOrder(orderId int) (order orders.Order, err error) {
 		&oneOrder.err,
 		&oneOrder.expires,
 		&oneOrder.dnsIdentifiers,
+		&oneOrder.ipIdentifiers,
 		&oneOrder.authorizations,
 		&oneOrder.finalize,
 		&oneOrder.certificateUrl,
@@ -600,6 +669,7 @@ func (store *Storage) GetOneOrder(orderId int) (order orders.Order, err error) {
 		&oneOrder.validTo,
 		&oneOrder.createdAt,
 		&oneOrder.updatedAt,
+		&oneOrder.newKeyId,
 
 		&oneOrder.certificate.id,
 		&oneOrder.certificate.name,
@@ -617,6 +687,12 @@ func (store *Storage) GetOneOrder(orderId int) (order orders.Order, err error) {
 		&oneOrder.certificate.apiKey,
 		&oneOrder.certificate.apiKeyNew,
 		&oneOrder.certificate.apiKeyViaUrl,
+		&oneOrder.certificate.preferredRootCN,
+		&oneOrder.certificate.challengeMethodMap,
+		&oneOrder.certificate.renewalPolicy,
+		&oneOrder.certificate.renewalPolicyValue,
+		&oneOrder.certificate.keyRotation,
+		&oneOrder.certificate.keyRotationAlgorithm,
 
 		&oneOrder.certificate.certificateKeyDb.id,
 		&oneOrder.certificate.certificateKeyDb.name,
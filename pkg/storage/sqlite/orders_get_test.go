@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/domain/orders"
+	"sort"
+	"testing"
+	"time"
+)
+
+// postValidTestOrder saves a valid order for the certificate with a pem that is
+// valid between the specified times
+func postValidTestOrder(t *testing.T, store *Storage, certId int, validFrom time.Time, validTo time.Time) {
+	t.Helper()
+
+	orderId := postTestOrder(t, store, certId)
+	order, err := store.GetOneOrder(orderId)
+	if err != nil {
+		t.Fatalf("failed to get order: %s", err)
+	}
+
+	certUrl := order.Location + "/cert"
+	err = store.PutOrderAcme(orders.UpdateAcmeOrderPayload{
+		Status:         "valid",
+		DnsIds:         order.DnsIdentifiers,
+		Authorizations: order.Authorizations,
+		Finalize:       order.Finalize,
+		CertificateUrl: &certUrl,
+		UpdatedAt:      200,
+		OrderId:        orderId,
+	})
+	if err != nil {
+		t.Fatalf("failed to put order acme: %s", err)
+	}
+
+	err = store.UpdateOrderCert(orderId, orders.CertPayload{
+		Pem:       "test-pem",
+		ValidFrom: int(validFrom.Unix()),
+		ValidTo:   int(validTo.Unix()),
+	})
+	if err != nil {
+		t.Fatalf("failed to update order cert: %s", err)
+	}
+}
+
+// TestGetExpiringCertIds confirms each renewal policy type includes certs that
+// are on the renewal side of its threshold and excludes the others
+func TestGetExpiringCertIds(t *testing.T) {
+	store := openTestStorage(t)
+
+	const day = 24 * time.Hour
+	now := time.Now()
+
+	type testOrder struct {
+		validFrom time.Duration // relative to now
+		validTo   time.Duration // relative to now
+	}
+
+	tests := []struct {
+		name   string
+		policy certificates.RenewalPolicy
+		orders []testOrder
+		want   bool
+	}{
+		{"default inside threshold", certificates.DefaultRenewalPolicy, []testOrder{{-60 * day, 30 * day}}, true},
+		{"default outside threshold", certificates.DefaultRenewalPolicy, []testOrder{{-40 * day, 50 * day}}, false},
+		{"default expired", certificates.DefaultRenewalPolicy, []testOrder{{-90 * day, -1 * day}}, false},
+		{"days inside threshold",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyDaysRemaining, Value: 20},
+			[]testOrder{{-80 * day, 10 * day}}, true},
+		{"days outside threshold (but inside default's)",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyDaysRemaining, Value: 20},
+			[]testOrder{{-65 * day, 25 * day}}, false},
+		{"percent inside threshold",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyPercentRemaining, Value: 30},
+			[]testOrder{{-70 * day, 20 * day}}, true},
+		{"percent outside threshold (but inside default's)",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyPercentRemaining, Value: 30},
+			[]testOrder{{-55 * day, 35 * day}}, false},
+		{"percent of a short lived cert",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyPercentRemaining, Value: 50},
+			[]testOrder{{-4 * day, 2 * day}}, true},
+		{"never", certificates.RenewalPolicy{Type: certificates.RenewalPolicyNever},
+			[]testOrder{{-89 * day, 1 * day}}, false},
+		{"default newest order outside threshold", certificates.DefaultRenewalPolicy,
+			[]testOrder{{-80 * day, 10 * day}, {-10 * day, 80 * day}}, false},
+		{"default newest order inside threshold", certificates.DefaultRenewalPolicy,
+			[]testOrder{{-85 * day, 5 * day}, {-70 * day, 20 * day}}, true},
+		{"days newest order outside threshold (order posted first)",
+			certificates.RenewalPolicy{Type: certificates.RenewalPolicyDaysRemaining, Value: 20},
+			[]testOrder{{-10 * day, 80 * day}, {-80 * day, 10 * day}}, false},
+	}
+
+	var want []int
+	certNames := make(map[int]string)
+	for _, tt := range tests {
+		certId := postTestCert(t, store)
+		certNames[certId] = tt.name
+
+		policy := tt.policy
+		err := store.PutDetailsCert(certificates.DetailsUpdatePayload{
+			ID:            certId,
+			RenewalPolicy: &policy,
+			UpdatedAt:     200,
+		})
+		if err != nil {
+			t.Fatalf("failed to put %s renewal policy: %s", tt.name, err)
+		}
+
+		for _, o := range tt.orders {
+			postValidTestOrder(t, store, certId, now.Add(o.validFrom), now.Add(o.validTo))
+		}
+
+		if tt.want {
+			want = append(want, certId)
+		}
+	}
+
+	got, err := store.GetExpiringCertIds(40 * day)
+	if err != nil {
+		t.Fatalf("failed to get expiring certs: %s", err)
+	}
+	sort.Ints(got)
+
+	gotSet := make(map[int]bool)
+	for _, certId := range got {
+		gotSet[certId] = true
+	}
+	wantSet := make(map[int]bool)
+	for _, certId := range want {
+		wantSet[certId] = true
+	}
+
+	for certId, name := range certNames {
+		if gotSet[certId] != wantSet[certId] {
+			t.Errorf("%s: got expiring %t, want %t", name, gotSet[certId], wantSet[certId])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expiring cert ids: got %v, want %v", got, want)
+	}
+}
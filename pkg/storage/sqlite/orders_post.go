@@ -55,7 +55,9 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				finalize,
 				acme_location,
 				created_at,
-				updated_at
+				updated_at,
+				ip_identifiers,
+				new_key_id
 			)
 	VALUES
 			(
@@ -70,7 +72,9 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				$9,
 				$10,
 				$11,
-				$12
+				$12,
+				$13,
+				$14
 			)
 	RETURNING
 		id
@@ -89,6 +93,8 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 		payload.Location,
 		payload.CreatedAt,
 		payload.UpdatedAt,
+		makeCommaJoinedString(payload.IpIds),
+		payload.NewKeyID,
 	).Scan(&newId)
 
 	err = tx.Commit()
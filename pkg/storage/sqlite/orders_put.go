@@ -23,9 +23,10 @@ func (store *Storage) PutOrderAcme(payload orders.UpdateAcmeOrderPayload) (err e
 			authorizations = $5,
 			finalize = $6,
 			certificate_url = case when $7 is null then certificate_url else $7 end,
-			updated_at = $8
+			updated_at = $8,
+			ip_identifiers = $9
 		WHERE
-			id = $9
+			id = $10
 		`
 
 	_, err = store.Db.ExecContext(ctx, query,
@@ -37,6 +38,7 @@ func (store *Storage) PutOrderAcme(payload orders.UpdateAcmeOrderPayload) (err e
 		payload.Finalize,
 		payload.CertificateUrl,
 		payload.UpdatedAt,
+		makeCommaJoinedString(payload.IpIds),
 		payload.OrderId,
 	)
 
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/domain/orders"
+	"reflect"
+	"testing"
+)
+
+// TestPutOrderAcmeIpIdentifiers confirms an order with ip identifiers saves and
+// reads back its identifiers and acme fields to the correct columns
+func TestPutOrderAcmeIpIdentifiers(t *testing.T) {
+	store := openTestStorage(t)
+	certId := postTestCert(t, store)
+
+	cert, err := store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+
+	orderId, err := store.PostNewOrder(orders.NewOrderAcmePayload{
+		CertId:         certId,
+		AccountId:      cert.CertificateAccount.ID,
+		Status:         "pending",
+		Expires:        1000,
+		DnsIds:         []string{"example.com"},
+		IpIds:          []string{"192.0.2.1"},
+		Authorizations: []string{"https://acme.example.com/authz/1"},
+		Finalize:       "https://acme.example.com/finalize/1",
+		Location:       "https://acme.example.com/order/1",
+		CreatedAt:      100,
+		UpdatedAt:      100,
+	})
+	if err != nil {
+		t.Fatalf("failed to post order: %s", err)
+	}
+
+	expires := 2000
+	certUrl := "https://acme.example.com/cert/1"
+	err = store.PutOrderAcme(orders.UpdateAcmeOrderPayload{
+		Status:         "valid",
+		Expires:        &expires,
+		DnsIds:         []string{"example.com"},
+		IpIds:          []string{"192.0.2.1", "2001:db8::1"},
+		Authorizations: []string{"https://acme.example.com/authz/1", "https://acme.example.com/authz/2"},
+		Finalize:       "https://acme.example.com/finalize/1",
+		CertificateUrl: &certUrl,
+		UpdatedAt:      200,
+		OrderId:        orderId,
+	})
+	if err != nil {
+		t.Fatalf("failed to put order acme: %s", err)
+	}
+
+	order, err := store.GetOneOrder(orderId)
+	if err != nil {
+		t.Fatalf("failed to get order: %s", err)
+	}
+
+	if order.Status != "valid" {
+		t.Errorf("status: got %s, want valid", order.Status)
+	}
+	if order.Expires == nil {
+		t.Errorf("expires: got nil, want %d", expires)
+	} else if *order.Expires != expires {
+		t.Errorf("expires: got %d, want %d", *order.Expires, expires)
+	}
+	if !reflect.DeepEqual(order.DnsIdentifiers, []string{"example.com"}) {
+		t.Errorf("dns identifiers: got %v", order.DnsIdentifiers)
+	}
+	if !reflect.DeepEqual(order.IpIdentifiers, []string{"192.0.2.1", "2001:db8::1"}) {
+		t.Errorf("ip identifiers: got %v", order.IpIdentifiers)
+	}
+	if len(order.Authorizations) != 2 {
+		t.Errorf("authorizations: got %v", order.Authorizations)
+	}
+	if order.CertificateUrl == nil {
+		t.Errorf("certificate url: got nil, want %s", certUrl)
+	} else if *order.CertificateUrl != certUrl {
+		t.Errorf("certificate url: got %s, want %s", *order.CertificateUrl, certUrl)
+	}
+	if order.UpdatedAt != 200 {
+		t.Errorf("updated_at: got %d, want 200", order.UpdatedAt)
+	}
+}
@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"legocerthub-backend/pkg/challenges"
+)
+
+// providerExecLogDb is a single provider execution log, as database table fields
+// corresponds to challenges.ProviderExecLog
+type providerExecLogDb struct {
+	id              int
+	orderId         int
+	methodValue     string
+	identifierValue string
+	action          string
+	command         string
+	exitCode        int
+	timedOut        bool
+	stdout          string
+	stderr          string
+	error           string
+	startedAt       int
+	durationMs      int
+}
+
+// toProviderExecLog maps the database execution log to the challenges
+// ProviderExecLog object
+func (pel providerExecLogDb) toProviderExecLog() challenges.ProviderExecLog {
+	return challenges.ProviderExecLog{
+		ID:              pel.id,
+		OrderID:         pel.orderId,
+		MethodValue:     challenges.MethodValue(pel.methodValue),
+		IdentifierValue: pel.identifierValue,
+		Action:          pel.action,
+		Command:         pel.command,
+		ExitCode:        pel.exitCode,
+		TimedOut:        pel.timedOut,
+		Stdout:          pel.stdout,
+		Stderr:          pel.stderr,
+		Error:           pel.error,
+		StartedAt:       pel.startedAt,
+		DurationMs:      pel.durationMs,
+	}
+}
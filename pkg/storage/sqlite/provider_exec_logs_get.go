@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// GetProviderExecLogsByOrder returns all of the provider execution logs for the
+// specified order, oldest first
+func (store *Storage) GetProviderExecLogsByOrder(orderId int) (execLogs []challenges.ProviderExecLog, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, order_id, method_value, identifier_value, action, command, exit_code,
+		timed_out, stdout, stderr, error, started_at, duration_ms
+	FROM
+		provider_exec_logs
+	WHERE
+		order_id = $1
+	ORDER BY
+		started_at, id
+	`
+
+	rows, err := store.Db.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oneLog providerExecLogDb
+		err = rows.Scan(
+			&oneLog.id,
+			&oneLog.orderId,
+			&oneLog.methodValue,
+			&oneLog.identifierValue,
+			&oneLog.action,
+			&oneLog.command,
+			&oneLog.exitCode,
+			&oneLog.timedOut,
+			&oneLog.stdout,
+			&oneLog.stderr,
+			&oneLog.error,
+			&oneLog.startedAt,
+			&oneLog.durationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		execLogs = append(execLogs, oneLog.toProviderExecLog())
+	}
+
+	return execLogs, nil
+}
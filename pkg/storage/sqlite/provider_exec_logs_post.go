@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"context"
+	"legocerthub-backend/pkg/challenges"
+)
+
+// PostNewProviderExecLog saves a provider execution log to the db
+func (store *Storage) PostNewProviderExecLog(execLog challenges.ProviderExecLog) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.Timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO provider_exec_logs (order_id, method_value, identifier_value, action, command, exit_code,
+		timed_out, stdout, stderr, error, started_at, duration_ms)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	err = store.Db.QueryRowContext(ctx, query,
+		execLog.OrderID,
+		execLog.MethodValue,
+		execLog.IdentifierValue,
+		execLog.Action,
+		execLog.Command,
+		execLog.ExitCode,
+		execLog.TimedOut,
+		execLog.Stdout,
+		execLog.Stderr,
+		execLog.Error,
+		execLog.StartedAt,
+		execLog.DurationMs,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
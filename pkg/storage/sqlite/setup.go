@@ -102,6 +102,17 @@ func OpenStorage(app App, dataPath string) (*Storage, error) {
 		}
 	}
 
+	// update the schema of new or existing db to the current version
+	err = store.migrateDB()
+	if err != nil {
+		// delete new db on error setting it up
+		if !dbExists {
+			_ = store.Db.Close()
+			_ = os.Remove(dbWithPath)
+		}
+		return nil, err
+	}
+
 	return store, nil
 }
 
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"fmt"
+	"legocerthub-backend/pkg/challenges"
+	"legocerthub-backend/pkg/domain/acme_accounts"
+	"legocerthub-backend/pkg/domain/certificates"
+	"legocerthub-backend/pkg/domain/orders"
+	"legocerthub-backend/pkg/domain/private_keys"
+	"testing"
+)
+
+// testApp satisfies App for opening a test Storage
+type testApp struct{}
+
+func (testApp) GetChallengesService() *challenges.Service {
+	return new(challenges.Service)
+}
+
+// openTestStorage opens a new, migrated Storage in a temp directory
+func openTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	store, err := OpenStorage(testApp{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open storage: %s", err)
+	}
+	t.Cleanup(func() { _ = store.Db.Close() })
+
+	return store
+}
+
+// testNameSeq makes the names of test objects unique
+var testNameSeq int
+
+// postTestCert saves a private key, an acme account, and a certificate using
+// them. It returns the new certificate's id.
+func postTestCert(t *testing.T, store *Storage) int {
+	t.Helper()
+
+	testNameSeq++
+	keyName := fmt.Sprintf("test-key-%d", testNameSeq)
+	keyDesc := "test key"
+	keyAlg := "ecdsap256"
+	keyPem := fmt.Sprintf("test-pem-%d", testNameSeq)
+	keyApiKeyDisabled := false
+	keyId, err := store.PostNewKey(private_keys.NewPayload{
+		Name:           &keyName,
+		Description:    &keyDesc,
+		AlgorithmValue: &keyAlg,
+		PemContent:     &keyPem,
+		ApiKey:         fmt.Sprintf("key-api-key-%d", testNameSeq),
+		ApiKeyDisabled: &keyApiKeyDisabled,
+	})
+	if err != nil {
+		t.Fatalf("failed to post key: %s", err)
+	}
+
+	accountName := fmt.Sprintf("test-account-%d", testNameSeq)
+	accountDesc := "test account"
+	email := "test@example.com"
+	isStaging := true
+	acceptedTos := true
+	accountId, err := store.PostNewAccount(acme_accounts.NewPayload{
+		Name:         &accountName,
+		Description:  &accountDesc,
+		PrivateKeyID: &keyId,
+		Status:       "valid",
+		Email:        &email,
+		IsStaging:    &isStaging,
+		AcceptedTos:  &acceptedTos,
+	})
+	if err != nil {
+		t.Fatalf("failed to post account: %s", err)
+	}
+
+	certName := fmt.Sprintf("test-cert-%d", testNameSeq)
+	certDesc := "test cert"
+	subject := "example.com"
+	method := challenges.MethodValue("http-01-internal")
+	empty := ""
+	certId, err := store.PostNewCert(certificates.NewPayload{
+		Name:                 &certName,
+		Description:          &certDesc,
+		PrivateKeyID:         &keyId,
+		AcmeAccountID:        &accountId,
+		ChallengeMethodValue: &method,
+		Subject:              &subject,
+		Organization:         &empty,
+		OrganizationalUnit:   &empty,
+		Country:              &empty,
+		State:                &empty,
+		City:                 &empty,
+		PreferredRootCN:      &empty,
+		RenewalPolicy:        &certificates.RenewalPolicy{Type: certificates.RenewalPolicyDefault},
+		KeyRotation:          &certificates.KeyRotation{},
+		ApiKey:               fmt.Sprintf("cert-api-key-%d", testNameSeq),
+	})
+	if err != nil {
+		t.Fatalf("failed to post cert: %s", err)
+	}
+
+	return certId
+}
+
+// postTestOrder saves a new pending order for the certificate and returns the
+// new order's id
+func postTestOrder(t *testing.T, store *Storage, certId int) int {
+	t.Helper()
+
+	cert, err := store.GetOneCertById(certId)
+	if err != nil {
+		t.Fatalf("failed to get cert: %s", err)
+	}
+
+	testNameSeq++
+	orderId, err := store.PostNewOrder(orders.NewOrderAcmePayload{
+		CertId:         certId,
+		AccountId:      cert.CertificateAccount.ID,
+		Status:         "pending",
+		Expires:        1000,
+		DnsIds:         []string{"example.com"},
+		Authorizations: []string{fmt.Sprintf("https://acme.example.com/authz/%d", testNameSeq)},
+		Finalize:       fmt.Sprintf("https://acme.example.com/finalize/%d", testNameSeq),
+		Location:       fmt.Sprintf("https://acme.example.com/order/%d", testNameSeq),
+		CreatedAt:      100,
+		UpdatedAt:      100,
+	})
+	if err != nil {
+		t.Fatalf("failed to post order: %s", err)
+	}
+
+	return orderId
+}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"net"
 	"regexp"
 	"strings"
 )
@@ -24,3 +25,22 @@ func DomainValid(domain string, wildOk bool) bool {
 
 	return regexp.MustCompile(DomainValidRegex).MatchString(domain)
 }
+
+// IPAddressValid returns true if the string is a validly formatted IPv4 or
+// IPv6 address (RFC 8738). The address must be in its canonical form (e.g.
+// IPv6 must be compressed and lowercase per RFC 5952) as that is the form
+// ACME will return it in.
+func IPAddressValid(ipAddress string) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+
+	return ip.String() == ipAddress
+}
+
+// DomainOrIPValid returns true if the string is either a valid domain name
+// or a valid IP address. Wildcard is only permitted for domain names.
+func DomainOrIPValid(domainOrIp string, wildOk bool) bool {
+	return DomainValid(domainOrIp, wildOk) || IPAddressValid(domainOrIp)
+}
@@ -102,3 +102,52 @@ func TestValidation_DomainValid(t *testing.T) {
 	}
 
 }
+
+// valid ip addresses
+var validIPAddresses = []string{
+	"192.0.2.5",
+	"10.0.0.1",
+	"2001:db8::1",
+	"fe80::1:2:3:4",
+	"::1",
+}
+
+// invalid ip addresses
+var invalidIPAddresses = []string{
+	"",
+	"192.0.2",
+	"192.0.2.256",
+	"192.0.2.05",
+	" 192.0.2.5",
+	"192.0.2.5 ",
+	"2001:DB8::1",
+	"2001:0db8::1",
+	"2001:db8::1%eth0",
+	"::ffff:192.0.2.5",
+	"*.192.0.2.5",
+	"example.com",
+}
+
+func TestValidation_IPAddressValid(t *testing.T) {
+	for _, ip := range validIPAddresses {
+		if !IPAddressValid(ip) {
+			t.Errorf("valid ip address test case '%s' returned invalid", ip)
+		}
+
+		// ip is also valid for domain or ip (wildcard setting doesn't matter)
+		if !DomainOrIPValid(ip, false) || !DomainOrIPValid(ip, true) {
+			t.Errorf("valid ip address test case '%s' returned invalid for domain or ip", ip)
+		}
+
+		// wildcard never applies to ip
+		if DomainOrIPValid("*."+ip, true) {
+			t.Errorf("wildcard ip address test case '*.%s' returned valid", ip)
+		}
+	}
+
+	for _, ip := range invalidIPAddresses {
+		if IPAddressValid(ip) {
+			t.Errorf("invalid ip address test case '%s' returned valid", ip)
+		}
+	}
+}